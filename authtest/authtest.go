@@ -0,0 +1,351 @@
+// Package authtest is an HTTP-level test suite for the framework adapters (ginhandler,
+// httphandler, echohandler, fiberhandler). Run drives the handler an adapter builds
+// through registration, email verification, login and the auth and role middlewares,
+// and checks the status codes, error codes and JSON envelope of every response, so
+// that all adapters behave alike, down to the rejection of sender-constrained tokens. Adapters written outside this module can run it too:
+//
+//	func TestAdapter(t *testing.T) {
+//		authtest.Run(t, func(t *testing.T, deps authtest.Deps) http.Handler {
+//			return newRouter(deps) // Serves the routes listed in Deps
+//		})
+//	}
+package authtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/hash"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// Routes the handler under test must serve.
+const (
+	RegisterPath    = "/auth/register"     // POST, the register handler
+	LoginPath       = "/auth/login"        // POST, the login handler
+	VerifyEmailPath = "/auth/verify-email" // GET, the email verification handler
+	UserInfoPath    = "/api/me"            // GET, the user info handler behind the auth middleware
+	AdminPath       = "/admin/me"          // GET, the user info handler behind the auth and "admin" role middlewares
+)
+
+// Deps are the dependencies the handler under test must be built from.
+type Deps struct {
+	Store      core.UserStorer
+	TokenMaker token.Maker
+	Hasher     hash.PasswordHasher
+	Mailer     core.EmailSender
+	Config     *config.AuthConfig
+
+	Auth          *service.Auth          // Built from the dependencies above
+	Authenticator *service.Authenticator // Built from the dependencies above
+}
+
+// NewHandlerFunc builds the handler serving the suite's routes with an adapter.
+type NewHandlerFunc func(t *testing.T, deps Deps) http.Handler
+
+// suiteTokenKey is the PASETO key of the suite's token maker.
+const suiteTokenKey = "authtest-symmetric-key-32-bytes!"
+
+// Run runs the suite against the handler built by newHandler. Each top-level
+// test gets a handler with fresh dependencies.
+func Run(t *testing.T, newHandler NewHandlerFunc) {
+	t.Run("Register", func(t *testing.T) {
+		s := newSuite(t, newHandler)
+		r := s.do(http.MethodPost, RegisterPath, `{"email":"ada@example.com","password":"correct-horse","full_name":"Ada Lovelace"}`, "")
+		r.expectSuccess(t, http.StatusCreated)
+		if email := r.data(t)["email"]; email != "ada@example.com" {
+			t.Errorf("registered email = %v, want ada@example.com", email)
+		}
+		if status := r.data(t)["status"]; status != string(core.StatusPending) {
+			t.Errorf("registered status = %v, want %s", status, core.StatusPending)
+		}
+
+		s.do(http.MethodPost, RegisterPath, `{"email":"ada@example.com","password":"correct-horse","full_name":"Ada Lovelace"}`, "").
+			expectError(t, http.StatusConflict, "CONFLICT_RESOURCE")
+	})
+
+	t.Run("RegisterValidation", func(t *testing.T) {
+		s := newSuite(t, newHandler)
+		for name, body := range map[string]string{
+			"malformed JSON":    `{"email":`,
+			"missing email":     `{"password":"correct-horse","full_name":"Ada Lovelace"}`,
+			"invalid email":     `{"email":"ada","password":"correct-horse","full_name":"Ada Lovelace"}`,
+			"missing full name": `{"email":"ada@example.com","password":"correct-horse"}`,
+		} {
+			s.do(http.MethodPost, RegisterPath, body, "").expectError(t, http.StatusBadRequest, "INVALID_REQUEST_BODY", name)
+		}
+		// Adapters with declarative validation reject it as a bad body; the others get
+		// the service's validation error.
+		s.do(http.MethodPost, RegisterPath, `{"email":"ada@example.com","password":"short","full_name":"Ada Lovelace"}`, "").
+			expectError(t, http.StatusBadRequest, "", "short password")
+	})
+
+	t.Run("VerifyEmail", func(t *testing.T) {
+		s := newSuite(t, newHandler)
+		s.do(http.MethodGet, VerifyEmailPath, "", "").expectError(t, http.StatusBadRequest, "INVALID_REQUEST_QUERY")
+		s.do(http.MethodGet, VerifyEmailPath+"?token=unknown", "", "").expectError(t, http.StatusBadRequest, "INVALID_VERIFICATION_TOKEN")
+
+		userID := s.register("ada@example.com")
+		verificationToken := s.store.verificationToken(userID)
+		if verificationToken == "" {
+			t.Fatal("no verification token was stored on registration")
+		}
+		s.do(http.MethodGet, VerifyEmailPath+"?token="+verificationToken, "", "").expectSuccess(t, http.StatusOK)
+		s.do(http.MethodGet, VerifyEmailPath+"?token="+verificationToken, "", "").
+			expectError(t, http.StatusBadRequest, "INVALID_VERIFICATION_TOKEN", "reused token")
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		s := newSuite(t, newHandler)
+		userID := s.register("ada@example.com")
+		s.do(http.MethodPost, LoginPath, `{"email":"ada@example.com","password":"correct-horse"}`, "").
+			expectError(t, http.StatusForbidden, "USER_NOT_VERIFIED")
+
+		s.verify(userID)
+		s.do(http.MethodPost, LoginPath, `{"email":"ada@example.com","password":"wrong-horse"}`, "").
+			expectError(t, http.StatusUnauthorized, "INVALID_CREDENTIALS")
+		s.do(http.MethodPost, LoginPath, `{"email":"bob@example.com","password":"correct-horse"}`, "").
+			expectError(t, http.StatusUnauthorized, "INVALID_CREDENTIALS", "unknown user")
+		s.do(http.MethodPost, LoginPath, `{"email":"ada@example.com"}`, "").
+			expectError(t, http.StatusBadRequest, "INVALID_REQUEST_BODY", "missing password")
+
+		r := s.do(http.MethodPost, LoginPath, `{"email":"ada@example.com","password":"correct-horse"}`, "")
+		r.expectSuccess(t, http.StatusOK)
+		data := r.data(t)
+		if accessToken, _ := data["access_token"].(string); accessToken == "" {
+			t.Error("login response has no access_token")
+		}
+		if _, ok := data["expires_at"]; !ok {
+			t.Error("login response has no expires_at")
+		}
+		if user, _ := data["user"].(map[string]interface{}); user["id"] != userID.String() {
+			t.Errorf("login response user = %v, want id %s", data["user"], userID)
+		}
+	})
+
+	t.Run("AuthMiddleware", func(t *testing.T) {
+		s := newSuite(t, newHandler)
+		userID := s.register("ada@example.com")
+		s.verify(userID)
+		accessToken := s.login("ada@example.com")
+
+		s.do(http.MethodGet, UserInfoPath, "", "").expectError(t, http.StatusUnauthorized, "UNAUTHORIZED", "no header")
+		s.do(http.MethodGet, UserInfoPath, "", accessToken).expectError(t, http.StatusUnauthorized, "UNAUTHORIZED", "no scheme")
+		s.do(http.MethodGet, UserInfoPath, "", "Basic "+accessToken).expectError(t, http.StatusUnauthorized, "UNAUTHORIZED", "basic scheme")
+		s.do(http.MethodGet, UserInfoPath, "", "Bearer not-a-token").expectError(t, http.StatusUnauthorized, "INVALID_TOKEN")
+
+		r := s.do(http.MethodGet, UserInfoPath, "", "Bearer "+accessToken)
+		r.expectSuccess(t, http.StatusOK)
+		if id := r.data(t)["id"]; id != userID.String() {
+			t.Errorf("user info id = %v, want %s", id, userID)
+		}
+		s.do(http.MethodGet, UserInfoPath, "", "bearer "+accessToken).expectSuccess(t, http.StatusOK, "lower-case scheme")
+
+		// Logging in again replaces the session under single-device login
+		s.login("ada@example.com")
+		s.do(http.MethodGet, UserInfoPath, "", "Bearer "+accessToken).expectError(t, http.StatusUnauthorized, "MULTI_DEVICE_LOGIN")
+
+		suspended := core.StatusSuspended
+		if _, err := s.store.UpdateUser(context.Background(), userID, core.UpdateUserParams{Status: &suspended}); err != nil {
+			t.Fatal(err)
+		}
+		s.do(http.MethodGet, UserInfoPath, "", "Bearer "+s.tokenFor(userID)).expectError(t, http.StatusForbidden, "ACCOUNT_INACTIVE")
+	})
+
+	t.Run("RoleMiddleware", func(t *testing.T) {
+		s := newSuite(t, newHandler)
+		userID := s.register("ada@example.com")
+		s.verify(userID)
+
+		s.do(http.MethodGet, AdminPath, "", "").expectError(t, http.StatusUnauthorized, "UNAUTHORIZED")
+		s.do(http.MethodGet, AdminPath, "", "Bearer "+s.login("ada@example.com")).expectError(t, http.StatusForbidden, "FORBIDDEN")
+
+		roles := []string{"Admin"} // Roles match case-insensitively
+		if _, err := s.store.UpdateUser(context.Background(), userID, core.UpdateUserParams{Roles: &roles}); err != nil {
+			t.Fatal(err)
+		}
+		s.do(http.MethodGet, AdminPath, "", "Bearer "+s.login("ada@example.com")).expectSuccess(t, http.StatusOK)
+	})
+
+	t.Run("SenderConstrainedTokens", func(t *testing.T) {
+		s := newSuite(t, newHandler)
+		userID := s.register("ada@example.com")
+		s.verify(userID)
+
+		// Without the key or certificate the tokens are bound to, they are not bearer tokens
+		dpopBound := s.tokenFor(userID, token.WithConfirmation(token.Confirmation{JKT: "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"}))
+		s.do(http.MethodGet, UserInfoPath, "", "Bearer "+dpopBound).expectError(t, http.StatusUnauthorized, "INVALID_DPOP_PROOF", "DPoP-bound")
+		certBound := s.tokenFor(userID, token.WithConfirmation(token.Confirmation{X5tS256: "bwcK0esc3ACC3DB2Y5_lESsXE8o9ltc05O89jdN-dg2"}))
+		s.do(http.MethodGet, UserInfoPath, "", "Bearer "+certBound).expectError(t, http.StatusUnauthorized, "CLIENT_CERTIFICATE_REQUIRED", "certificate-bound")
+	})
+}
+
+// suite holds one handler under test and its dependencies.
+type suite struct {
+	t       *testing.T
+	handler http.Handler
+	store   *memoryStore
+	deps    Deps
+}
+
+func newSuite(t *testing.T, newHandler NewHandlerFunc) *suite {
+	t.Helper()
+	tokenMaker, err := token.NewPasetoMaker(suiteTokenKey)
+	if err != nil {
+		t.Fatalf("failed to create token maker: %v", err)
+	}
+	store := newMemoryStore()
+	cfg := config.DefaultAuthConfig()
+	cfg.EnforceSingleDeviceLogin = true
+	hasher := hash.NewBcryptHasher(0)
+	deps := Deps{
+		Store:         store,
+		TokenMaker:    tokenMaker,
+		Hasher:        hasher,
+		Mailer:        discardMailer{},
+		Config:        cfg,
+		Auth:          service.New(store, tokenMaker, hasher, discardMailer{}, cfg),
+		Authenticator: service.NewAuthenticator(tokenMaker, store, cfg),
+	}
+	return &suite{t: t, handler: newHandler(t, deps), store: store, deps: deps}
+}
+
+// register registers a user with the password "correct-horse" and returns their ID.
+func (s *suite) register(email string) uuid.UUID {
+	s.t.Helper()
+	r := s.do(http.MethodPost, RegisterPath, `{"email":"`+email+`","password":"correct-horse","full_name":"Test User"}`, "")
+	r.expectSuccess(s.t, http.StatusCreated)
+	id, err := uuid.Parse(r.data(s.t)["id"].(string))
+	if err != nil {
+		s.t.Fatalf("registered user has an invalid id: %v", err)
+	}
+	return id
+}
+
+func (s *suite) verify(userID uuid.UUID) {
+	s.t.Helper()
+	s.do(http.MethodGet, VerifyEmailPath+"?token="+s.store.verificationToken(userID), "", "").expectSuccess(s.t, http.StatusOK)
+}
+
+// login logs in a registered user and returns the access token.
+func (s *suite) login(email string) string {
+	s.t.Helper()
+	r := s.do(http.MethodPost, LoginPath, `{"email":"`+email+`","password":"correct-horse"}`, "")
+	r.expectSuccess(s.t, http.StatusOK)
+	accessToken, _ := r.data(s.t)["access_token"].(string)
+	return accessToken
+}
+
+// tokenFor issues a login token for a user without going through the handler.
+func (s *suite) tokenFor(userID uuid.UUID, opts ...token.PayloadOption) string {
+	s.t.Helper()
+	user, err := s.store.GetUserByID(context.Background(), userID)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	accessToken, _, err := s.deps.Auth.IssueLoginToken(context.Background(), user, opts...)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return accessToken
+}
+
+// do sends a request to the handler. body is sent as JSON if not empty.
+func (s *suite) do(method, path, body, authorization string) *response {
+	s.t.Helper()
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reqBody)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return &response{method: method, path: path, status: rec.Code, header: rec.Header(), body: rec.Body.Bytes()}
+}
+
+type response struct {
+	method, path string
+	status       int
+	header       http.Header
+	body         []byte
+	envelope     map[string]interface{}
+}
+
+func (r *response) describe(context []string) string {
+	s := r.method + " " + r.path
+	if len(context) > 0 {
+		s += " (" + strings.Join(context, ", ") + ")"
+	}
+	return s
+}
+
+func (r *response) decode(t *testing.T, context []string) map[string]interface{} {
+	t.Helper()
+	if r.envelope != nil {
+		return r.envelope
+	}
+	if ct := r.header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("%s: Content-Type = %q, want application/json", r.describe(context), ct)
+	}
+	if err := json.NewDecoder(bytes.NewReader(r.body)).Decode(&r.envelope); err != nil {
+		t.Fatalf("%s: response is not a JSON object: %v: %s", r.describe(context), err, r.body)
+	}
+	return r.envelope
+}
+
+// expectSuccess checks the status and the success envelope.
+func (r *response) expectSuccess(t *testing.T, status int, context ...string) {
+	t.Helper()
+	if r.status != status {
+		t.Fatalf("%s: status = %d, want %d: %s", r.describe(context), r.status, status, r.body)
+	}
+	if got := r.decode(t, context)["status"]; got != "success" {
+		t.Errorf(`%s: envelope status = %v, want "success"`, r.describe(context), got)
+	}
+}
+
+// expectError checks the status and the error envelope. An empty code matches any code.
+func (r *response) expectError(t *testing.T, status int, code string, context ...string) {
+	t.Helper()
+	if r.status != status {
+		t.Errorf("%s: status = %d, want %d: %s", r.describe(context), r.status, status, r.body)
+		return
+	}
+	envelope := r.decode(t, context)
+	if got := envelope["status"]; got != "error" {
+		t.Errorf(`%s: envelope status = %v, want "error"`, r.describe(context), got)
+	}
+	if got, _ := envelope["code"].(string); got == "" || (code != "" && got != code) {
+		t.Errorf("%s: error code = %q, want %q", r.describe(context), got, code)
+	}
+	if got, _ := envelope["message"].(string); got == "" {
+		t.Errorf("%s: error response has no message", r.describe(context))
+	}
+}
+
+// data returns the data of a success response.
+func (r *response) data(t *testing.T) map[string]interface{} {
+	t.Helper()
+	data, ok := r.decode(t, nil)["data"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("%s: success response has no data object: %s", r.describe(nil), r.body)
+	}
+	return data
+}
@@ -0,0 +1,180 @@
+package authtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// memoryStore is the core.UserStorer the suite runs against.
+type memoryStore struct {
+	mu            sync.Mutex
+	users         map[uuid.UUID]core.User
+	verifications map[string]uuid.UUID
+	resetTokens   map[string]uuid.UUID
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		users:         make(map[uuid.UUID]core.User),
+		verifications: make(map[string]uuid.UUID),
+		resetTokens:   make(map[string]uuid.UUID),
+	}
+}
+
+func (s *memoryStore) CreateUser(ctx context.Context, params core.CreateUserParams) (core.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == params.Email {
+			return core.User{}, core.ErrDuplicateEmail
+		}
+	}
+	now := time.Now()
+	user := core.User{
+		ID:           uuid.New(),
+		Username:     params.Username,
+		Email:        params.Email,
+		PhoneNumber:  params.PhoneNumber,
+		PasswordHash: params.PasswordHash,
+		FullName:     params.FullName,
+		Role:         params.Role,
+		Status:       params.Status,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *memoryStore) GetUserByEmail(ctx context.Context, email string) (core.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return core.User{}, core.ErrNotFound
+}
+
+func (s *memoryStore) GetUserByID(ctx context.Context, id uuid.UUID) (core.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return core.User{}, core.ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *memoryStore) UpdateUser(ctx context.Context, userID uuid.UUID, params core.UpdateUserParams) (core.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return core.User{}, core.ErrNotFound
+	}
+	if params.Roles != nil {
+		user.Roles = *params.Roles
+	}
+	if params.Status != nil {
+		user.Status = *params.Status
+	}
+	if params.ActiveToken != nil {
+		user.ActiveToken = *params.ActiveToken
+	}
+	user.UpdatedAt = time.Now()
+	s.users[userID] = user
+	return user, nil
+}
+
+func (s *memoryStore) StoreVerificationData(ctx context.Context, userID uuid.UUID, email string, token string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verifications[token] = userID
+	return nil
+}
+
+func (s *memoryStore) GetVerificationData(ctx context.Context, token string) (uuid.UUID, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userID, ok := s.verifications[token]
+	if !ok {
+		return uuid.Nil, "", core.ErrVerificationNotFound
+	}
+	return userID, s.users[userID].Email, nil
+}
+
+func (s *memoryStore) DeleteVerificationData(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.verifications, token)
+	return nil
+}
+
+func (s *memoryStore) DeleteVerificationDataByUserID(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, id := range s.verifications {
+		if id == userID {
+			delete(s.verifications, token)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) StorePasswordResetToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resetTokens[token] = userID
+	return nil
+}
+
+func (s *memoryStore) GetPasswordResetToken(ctx context.Context, token string) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userID, ok := s.resetTokens[token]
+	if !ok {
+		return uuid.Nil, core.ErrNotFound
+	}
+	return userID, nil
+}
+
+func (s *memoryStore) DeletePasswordResetToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.resetTokens, token)
+	return nil
+}
+
+// verificationToken returns the pending verification token of a user.
+func (s *memoryStore) verificationToken(userID uuid.UUID) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, id := range s.verifications {
+		if id == userID {
+			return token
+		}
+	}
+	return ""
+}
+
+// discardMailer accepts every email without sending it.
+type discardMailer struct{}
+
+func (discardMailer) SendVerificationEmail(ctx context.Context, toEmail, username, verificationLink string) error {
+	return nil
+}
+
+func (discardMailer) SendPasswordResetEmail(ctx context.Context, toEmail, username, resetLink string) error {
+	return nil
+}
+
+func (discardMailer) SendInvitationEmail(ctx context.Context, toEmail, organizationName, inviterName, invitationLink string) error {
+	return nil
+}
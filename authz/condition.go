@@ -0,0 +1,279 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrMissingAttribute is returned by conditions over an attribute the request does not
+// have. It keeps allow policies from applying and makes deny policies apply.
+var ErrMissingAttribute = errors.New("authz: attribute is missing")
+
+// Condition restricts when a policy applies. An error means the condition could not be
+// decided: allow policies then do not apply, deny policies do (see Engine).
+type Condition interface {
+	Evaluate(req Request) (bool, error)
+	String() string // Shown in decision explanations
+}
+
+// When wraps a Go function as a condition. description is shown in explanations.
+func When(description string, fn func(req Request) bool) Condition {
+	return funcCondition{description: description, fn: fn}
+}
+
+type funcCondition struct {
+	description string
+	fn          func(req Request) bool
+}
+
+func (c funcCondition) Evaluate(req Request) (bool, error) { return c.fn(req), nil }
+func (c funcCondition) String() string                     { return "(" + c.description + ")" }
+
+// ParseCondition parses a rule expression: comparisons joined by "&&", such as
+//
+//	resource.owner_id == subject.id
+//	subject.department == resource.department && "manager" in subject.roles
+//	resource.amount <= 1000
+//
+// Operands are attribute paths (see Request.Lookup), quoted strings, numbers, true or
+// false. Operators are ==, !=, <, <=, >, >= (numbers) and "in" (membership in a list).
+func ParseCondition(expr string) (Condition, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	var cond exprCondition
+	cond.source = strings.TrimSpace(expr)
+	for len(tokens) > 0 {
+		if len(tokens) < 3 {
+			return nil, fmt.Errorf("authz: incomplete comparison in %q", expr)
+		}
+		cmp := comparison{left: tokens[0], op: tokens[1].text, right: tokens[2]}
+		if tokens[1].kind != operandOperator || !cmp.left.isValue() || !cmp.right.isValue() {
+			return nil, fmt.Errorf("authz: expected <operand> <operator> <operand> in %q", expr)
+		}
+		cond.comparisons = append(cond.comparisons, cmp)
+		tokens = tokens[3:]
+		if len(tokens) > 0 {
+			if tokens[0].kind != operandAnd {
+				return nil, fmt.Errorf("authz: expected && after %s %s %s in %q", cmp.left.text, cmp.op, cmp.right.text, expr)
+			}
+			tokens = tokens[1:]
+			if len(tokens) == 0 {
+				return nil, fmt.Errorf("authz: dangling && in %q", expr)
+			}
+		}
+	}
+	if len(cond.comparisons) == 0 {
+		return nil, fmt.Errorf("authz: empty condition")
+	}
+	return cond, nil
+}
+
+// MustParseCondition is like ParseCondition but panics on error, for policies declared in Go.
+func MustParseCondition(expr string) Condition {
+	cond, err := ParseCondition(expr)
+	if err != nil {
+		panic(err)
+	}
+	return cond
+}
+
+type exprCondition struct {
+	source      string
+	comparisons []comparison
+}
+
+func (c exprCondition) String() string { return "(" + c.source + ")" }
+
+// Evaluate is false if any comparison is false, whatever the order of the comparisons;
+// otherwise it returns the error of the first comparison that could not be decided.
+func (c exprCondition) Evaluate(req Request) (bool, error) {
+	var firstErr error
+	for _, cmp := range c.comparisons {
+		ok, err := cmp.evaluate(req)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	if firstErr != nil {
+		return false, firstErr
+	}
+	return true, nil
+}
+
+type comparison struct {
+	left  operand
+	op    string
+	right operand
+}
+
+func (c comparison) evaluate(req Request) (bool, error) {
+	left, ok := c.left.value(req)
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrMissingAttribute, c.left.text)
+	}
+	right, ok := c.right.value(req)
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrMissingAttribute, c.right.text)
+	}
+	switch c.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left), nil
+	case "<", "<=", ">", ">=":
+		l, lok := toNumber(left)
+		r, rok := toNumber(right)
+		if !lok || !rok {
+			return false, fmt.Errorf("%s %s %s compares non-numbers", c.left.text, c.op, c.right.text)
+		}
+		switch c.op {
+		case "<":
+			return l < r, nil
+		case "<=":
+			return l <= r, nil
+		case ">":
+			return l > r, nil
+		default:
+			return l >= r, nil
+		}
+	}
+	return false, fmt.Errorf("unknown operator %q", c.op)
+}
+
+// equal compares attribute values loosely, so a uuid.UUID equals its string form
+// and an int equals the same float64 decoded from a token.
+func equal(a, b interface{}) bool {
+	if an, ok := toNumber(a); ok {
+		if bn, ok := toNumber(b); ok {
+			return an == bn
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func contains(list, value interface{}) bool {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < v.Len(); i++ {
+		if equal(v.Index(i).Interface(), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+type operandKind int
+
+const (
+	operandPath operandKind = iota
+	operandLiteral
+	operandOperator
+	operandAnd
+)
+
+type operand struct {
+	kind    operandKind
+	text    string
+	literal interface{}
+}
+
+func (t operand) isValue() bool {
+	return t.kind == operandPath || t.kind == operandLiteral
+}
+
+func (t operand) value(req Request) (interface{}, bool) {
+	if t.kind == operandLiteral {
+		return t.literal, true
+	}
+	return req.Lookup(t.text)
+}
+
+func tokenize(expr string) ([]operand, error) {
+	var tokens []operand
+	for i := 0; i < len(expr); {
+		ch := rune(expr[i])
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '"' || ch == '\'':
+			end := strings.IndexByte(expr[i+1:], byte(ch))
+			if end < 0 {
+				return nil, fmt.Errorf("authz: unterminated string in %q", expr)
+			}
+			text := expr[i+1 : i+1+end]
+			tokens = append(tokens, operand{kind: operandLiteral, text: strconv.Quote(text), literal: text})
+			i += end + 2
+		case strings.ContainsRune("=!<>&", ch):
+			op := expr[i : i+1]
+			if i+1 < len(expr) && strings.ContainsRune("=&", rune(expr[i+1])) {
+				op = expr[i : i+2]
+			}
+			switch op {
+			case "==", "!=", "<", "<=", ">", ">=":
+				tokens = append(tokens, operand{kind: operandOperator, text: op})
+			case "&&":
+				tokens = append(tokens, operand{kind: operandAnd, text: op})
+			default:
+				return nil, fmt.Errorf("authz: unknown operator %q in %q", op, expr)
+			}
+			i += len(op)
+		default:
+			start := i
+			for i < len(expr) && !unicode.IsSpace(rune(expr[i])) && !strings.ContainsRune("=!<>&\"'", rune(expr[i])) {
+				i++
+			}
+			word := expr[start:i]
+			switch {
+			case word == "in":
+				tokens = append(tokens, operand{kind: operandOperator, text: word})
+			case word == "true" || word == "false":
+				tokens = append(tokens, operand{kind: operandLiteral, text: word, literal: word == "true"})
+			default:
+				if n, err := strconv.ParseFloat(word, 64); err == nil {
+					tokens = append(tokens, operand{kind: operandLiteral, text: word, literal: n})
+				} else {
+					tokens = append(tokens, operand{kind: operandPath, text: word})
+				}
+			}
+		}
+	}
+	return tokens, nil
+}
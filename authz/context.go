@@ -0,0 +1,43 @@
+package authz
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoEngine is returned by Authorize when the context carries no engine or subject.
+var ErrNoEngine = errors.New("authz: no engine or subject in context")
+
+type contextKey int
+
+const (
+	engineKey contextKey = iota
+	subjectKey
+)
+
+// NewContext returns a copy of ctx carrying the engine and the subject, for Authorize.
+func NewContext(ctx context.Context, engine *Engine, subject Subject) context.Context {
+	ctx = context.WithValue(ctx, engineKey, engine)
+	return context.WithValue(ctx, subjectKey, subject)
+}
+
+// SubjectFromContext returns the subject stored by NewContext.
+func SubjectFromContext(ctx context.Context) (Subject, bool) {
+	subject, ok := ctx.Value(subjectKey).(Subject)
+	return subject, ok
+}
+
+// Authorize decides whether the subject in ctx may perform action on resource,
+// using the engine in ctx. It is meant for checks inside handlers, once the
+// resource has been loaded.
+func Authorize(ctx context.Context, action string, resource Resource) (Decision, error) {
+	engine, ok := ctx.Value(engineKey).(*Engine)
+	if !ok || engine == nil {
+		return Decision{}, ErrNoEngine
+	}
+	subject, ok := SubjectFromContext(ctx)
+	if !ok {
+		return Decision{}, ErrNoEngine
+	}
+	return engine.Evaluate(Request{Subject: subject, Action: action, Resource: resource}), nil
+}
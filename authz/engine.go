@@ -0,0 +1,245 @@
+// Package authz is a small attribute-based authorization engine. Policies match an
+// action on a resource type, optionally restricted to roles, and may carry a condition
+// over subject, resource and action attributes, e.g. "resource.owner_id == subject.id".
+// Policies are declared in Go or loaded from a JSON rule file; every decision explains
+// which policy decided it and why the others did not apply.
+package authz
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// Effect is the outcome of a matching policy.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Any matches every action or resource type.
+const Any = "*"
+
+// Subject is the party requesting access.
+type Subject struct {
+	ID         string
+	Roles      []string
+	Attributes map[string]interface{} // e.g. "department", "client_id" or custom token claims
+}
+
+// SubjectFromPayload builds a subject from an access token. Its roles are the token's
+// effective roles, i.e. the organization roles for tokens scoped to an organization.
+// The token's custom claims, username, tenant ID, client ID and scopes become
+// attributes; attrs (e.g. loaded from the user record) are added on top.
+func SubjectFromPayload(payload *token.Payload, attrs map[string]interface{}) Subject {
+	attributes := make(map[string]interface{}, len(payload.Claims)+len(attrs)+3)
+	for key, value := range payload.Claims {
+		attributes[key] = value
+	}
+	attributes["username"] = payload.Username
+	if payload.HasTenant() {
+		attributes["tenant_id"] = payload.TenantID
+	}
+	if payload.ClientID != "" {
+		attributes["client_id"] = payload.ClientID
+		attributes["scopes"] = payload.Scopes()
+	}
+	for key, value := range attrs {
+		attributes[key] = value
+	}
+	return Subject{ID: payload.UserID.String(), Roles: payload.EffectiveRoles(), Attributes: attributes}
+}
+
+// Resource is the object being accessed.
+type Resource struct {
+	Type       string // e.g. "document"
+	ID         string
+	Attributes map[string]interface{} // e.g. "owner_id", "department"
+}
+
+// Request is an authorization question: may Subject perform Action on Resource?
+type Request struct {
+	Subject  Subject
+	Action   string
+	Resource Resource
+}
+
+// Lookup resolves an attribute path used in conditions: "action", "subject.id",
+// "subject.roles", "subject.<attribute>", "resource.type", "resource.id" and
+// "resource.<attribute>".
+func (r Request) Lookup(path string) (interface{}, bool) {
+	if path == "action" {
+		return r.Action, true
+	}
+	scope, name, ok := strings.Cut(path, ".")
+	if !ok {
+		return nil, false
+	}
+	switch scope {
+	case "subject":
+		switch name {
+		case "id":
+			return r.Subject.ID, true
+		case "roles":
+			return r.Subject.Roles, true
+		}
+		value, ok := r.Subject.Attributes[name]
+		return value, ok
+	case "resource":
+		switch name {
+		case "type":
+			return r.Resource.Type, true
+		case "id":
+			return r.Resource.ID, true
+		}
+		value, ok := r.Resource.Attributes[name]
+		return value, ok
+	}
+	return nil, false
+}
+
+// Policy grants or denies actions on resource types.
+type Policy struct {
+	Name        string
+	Description string
+	Effect      Effect
+	Actions     []string  // Action names or Any
+	Resources   []string  // Resource types or Any
+	Roles       []string  // Optional: the subject must hold one of these roles
+	Condition   Condition // Optional
+}
+
+// Decision is the result of an evaluation.
+type Decision struct {
+	Allowed bool
+	Policy  string   // Name of the deciding policy; empty for the default deny
+	Reason  string   // One-line explanation
+	Trace   []string // Why each policy did or did not apply, for debugging
+}
+
+func (d Decision) String() string {
+	return d.Reason
+}
+
+// Engine evaluates requests against a set of policies. A matching deny policy
+// overrides any allow; without a matching allow policy, access is denied. Deny policies
+// fail closed: one whose condition cannot be decided, e.g. over a missing attribute,
+// applies.
+type Engine struct {
+	policies []Policy
+}
+
+// NewEngine creates an engine. It fails if a policy has no name, an unknown effect,
+// or no actions or resources.
+func NewEngine(policies ...Policy) (*Engine, error) {
+	seen := make(map[string]bool, len(policies))
+	for _, p := range policies {
+		if p.Name == "" {
+			return nil, fmt.Errorf("authz: policy without a name")
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("authz: policy %q is defined twice", p.Name)
+		}
+		seen[p.Name] = true
+		if p.Effect != Allow && p.Effect != Deny {
+			return nil, fmt.Errorf("authz: policy %q has unknown effect %q", p.Name, p.Effect)
+		}
+		if len(p.Actions) == 0 || len(p.Resources) == 0 {
+			return nil, fmt.Errorf("authz: policy %q must list actions and resources", p.Name)
+		}
+	}
+	return &Engine{policies: policies}, nil
+}
+
+// Evaluate decides req.
+func (e *Engine) Evaluate(req Request) Decision {
+	var allowedBy *Policy
+	trace := make([]string, 0, len(e.policies))
+	for i := range e.policies {
+		p := &e.policies[i]
+		applies, why := p.applies(req)
+		trace = append(trace, fmt.Sprintf("%s %q: %s", p.Effect, p.Name, why))
+		if !applies {
+			continue
+		}
+		if p.Effect == Deny {
+			return Decision{
+				Allowed: false,
+				Policy:  p.Name,
+				Reason:  fmt.Sprintf("%q on %s denied by policy %q", req.Action, describe(req.Resource), p.Name),
+				Trace:   trace,
+			}
+		}
+		if allowedBy == nil {
+			allowedBy = p
+		}
+	}
+	if allowedBy != nil {
+		return Decision{
+			Allowed: true,
+			Policy:  allowedBy.Name,
+			Reason:  fmt.Sprintf("%q on %s allowed by policy %q", req.Action, describe(req.Resource), allowedBy.Name),
+			Trace:   trace,
+		}
+	}
+	return Decision{
+		Allowed: false,
+		Reason:  fmt.Sprintf("no policy allows %q on %s", req.Action, describe(req.Resource)),
+		Trace:   trace,
+	}
+}
+
+// applies reports whether the policy matches req, and explains why or why not.
+func (p *Policy) applies(req Request) (bool, string) {
+	if !matches(p.Actions, req.Action) {
+		return false, fmt.Sprintf("action %q not covered", req.Action)
+	}
+	if !matches(p.Resources, req.Resource.Type) {
+		return false, fmt.Sprintf("resource type %q not covered", req.Resource.Type)
+	}
+	if len(p.Roles) > 0 && !holdsAny(req.Subject.Roles, p.Roles) {
+		return false, fmt.Sprintf("subject roles %v include none of %v", req.Subject.Roles, p.Roles)
+	}
+	if p.Condition != nil {
+		ok, err := p.Condition.Evaluate(req)
+		if err != nil {
+			if p.Effect == Deny {
+				return true, fmt.Sprintf("applies, condition %s could not be decided: %v", p.Condition, err)
+			}
+			return false, fmt.Sprintf("condition %s failed: %v", p.Condition, err)
+		}
+		if !ok {
+			return false, fmt.Sprintf("condition %s not met", p.Condition)
+		}
+		return true, fmt.Sprintf("applies, condition %s met", p.Condition)
+	}
+	return true, "applies"
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == Any || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func holdsAny(held, wanted []string) bool {
+	for _, role := range held {
+		if matches(wanted, role) {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(r Resource) string {
+	if r.ID == "" {
+		return r.Type
+	}
+	return r.Type + " " + r.ID
+}
@@ -0,0 +1,114 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	engine, err := NewEngine(
+		Policy{
+			Name:      "edit-own-documents",
+			Effect:    Allow,
+			Actions:   []string{"edit"},
+			Resources: []string{"document"},
+			Condition: MustParseCondition("resource.owner_id == subject.id"),
+		},
+		Policy{
+			Name:      "admins-do-anything",
+			Effect:    Allow,
+			Actions:   []string{Any},
+			Resources: []string{Any},
+			Roles:     []string{"admin"},
+		},
+		Policy{
+			Name:      "no-edits-when-locked",
+			Effect:    Deny,
+			Actions:   []string{"edit"},
+			Resources: []string{"document"},
+			Condition: MustParseCondition("resource.locked == false && resource.classification != \"secret\""),
+		},
+		Policy{
+			Name:      "spending-limit",
+			Effect:    Deny,
+			Actions:   []string{"approve"},
+			Resources: []string{"expense"},
+			Condition: MustParseCondition("resource.amount > 1000"),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := Subject{ID: "u1"}
+	admin := Subject{ID: "u2", Roles: []string{"admin"}}
+	unlocked := map[string]interface{}{"owner_id": "u1", "locked": true, "classification": "public"}
+
+	tests := []struct {
+		name    string
+		req     Request
+		allowed bool
+		policy  string
+	}{
+		{"allowed by condition", Request{owner, "edit", Resource{Type: "document", Attributes: unlocked}}, true, "edit-own-documents"},
+		{"default deny", Request{owner, "delete", Resource{Type: "document", Attributes: unlocked}}, false, ""},
+		{"allowed by role", Request{admin, "delete", Resource{Type: "document"}}, true, "admins-do-anything"},
+		{"deny overrides allow", Request{owner, "edit", Resource{Type: "document", Attributes: map[string]interface{}{
+			"owner_id": "u1", "locked": false, "classification": "public",
+		}}}, false, "no-edits-when-locked"},
+		// Deny policies fail closed
+		{"deny with a missing attribute", Request{admin, "edit", Resource{Type: "document", Attributes: map[string]interface{}{
+			"locked": true,
+		}}}, true, "admins-do-anything"},
+		{"deny with all attributes missing", Request{admin, "edit", Resource{Type: "document"}}, false, "no-edits-when-locked"},
+		{"deny with a non-number", Request{admin, "approve", Resource{Type: "expense", Attributes: map[string]interface{}{
+			"amount": "lots",
+		}}}, false, "spending-limit"},
+		{"deny with an attribute missing", Request{admin, "approve", Resource{Type: "expense"}}, false, "spending-limit"},
+		{"deny not met", Request{admin, "approve", Resource{Type: "expense", Attributes: map[string]interface{}{
+			"amount": 10,
+		}}}, true, "admins-do-anything"},
+		// Allow policies fail closed too
+		{"allow with a missing attribute", Request{owner, "edit", Resource{Type: "document", Attributes: map[string]interface{}{
+			"locked": true, "classification": "public",
+		}}}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := engine.Evaluate(tt.req)
+			if decision.Allowed != tt.allowed || decision.Policy != tt.policy {
+				t.Errorf("Evaluate() = allowed %v by %q, want allowed %v by %q\n%s\ntrace: %v",
+					decision.Allowed, decision.Policy, tt.allowed, tt.policy, decision.Reason, decision.Trace)
+			}
+		})
+	}
+}
+
+func TestConditionMissingAttribute(t *testing.T) {
+	cond := MustParseCondition("resource.owner_id == subject.id && resource.amount > 10")
+	req := Request{Subject: Subject{ID: "u1"}, Resource: Resource{Attributes: map[string]interface{}{"owner_id": "u2"}}}
+
+	// A false comparison decides the condition, wherever the missing attribute is
+	if ok, err := cond.Evaluate(req); ok || err != nil {
+		t.Errorf("Evaluate() = %v, %v, want false, nil", ok, err)
+	}
+	req.Resource.Attributes["owner_id"] = "u1"
+	if ok, err := cond.Evaluate(req); ok || !errors.Is(err, ErrMissingAttribute) {
+		t.Errorf("Evaluate() = %v, %v, want false, ErrMissingAttribute", ok, err)
+	}
+}
+
+func TestNewEngineValidation(t *testing.T) {
+	for name, policies := range map[string][]Policy{
+		"no name":        {{Effect: Allow, Actions: []string{Any}, Resources: []string{Any}}},
+		"unknown effect": {{Name: "p", Effect: "maybe", Actions: []string{Any}, Resources: []string{Any}}},
+		"no actions":     {{Name: "p", Effect: Allow, Resources: []string{Any}}},
+		"duplicate": {
+			{Name: "p", Effect: Allow, Actions: []string{Any}, Resources: []string{Any}},
+			{Name: "p", Effect: Deny, Actions: []string{Any}, Resources: []string{Any}},
+		},
+	} {
+		if _, err := NewEngine(policies...); err == nil {
+			t.Errorf("NewEngine() with %s succeeded, want an error", name)
+		}
+	}
+}
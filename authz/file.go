@@ -0,0 +1,88 @@
+package authz
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ruleFile is the JSON rule file format:
+//
+//	{
+//	  "policies": [
+//	    {
+//	      "name": "edit-own-documents",
+//	      "effect": "allow",
+//	      "actions": ["edit", "delete"],
+//	      "resources": ["document"],
+//	      "when": "resource.owner_id == subject.id"
+//	    },
+//	    {
+//	      "name": "managers-approve-in-department",
+//	      "effect": "allow",
+//	      "actions": ["approve"],
+//	      "resources": ["expense"],
+//	      "roles": ["manager"],
+//	      "when": "resource.department == subject.department"
+//	    }
+//	  ]
+//	}
+type ruleFile struct {
+	Policies []rule `json:"policies"`
+}
+
+type rule struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Effect      Effect   `json:"effect"`
+	Actions     []string `json:"actions"`
+	Resources   []string `json:"resources"`
+	Roles       []string `json:"roles"`
+	When        string   `json:"when"`
+}
+
+// ParsePolicies reads policies from a JSON rule file. Conditions ("when") use the
+// syntax of ParseCondition.
+func ParsePolicies(r io.Reader) ([]Policy, error) {
+	var file ruleFile
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("authz: invalid rule file: %w", err)
+	}
+	policies := make([]Policy, 0, len(file.Policies))
+	for _, rule := range file.Policies {
+		policy := Policy{
+			Name:        rule.Name,
+			Description: rule.Description,
+			Effect:      rule.Effect,
+			Actions:     rule.Actions,
+			Resources:   rule.Resources,
+			Roles:       rule.Roles,
+		}
+		if rule.When != "" {
+			cond, err := ParseCondition(rule.When)
+			if err != nil {
+				return nil, fmt.Errorf("authz: policy %q: %w", rule.Name, err)
+			}
+			policy.Condition = cond
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// LoadEngine creates an engine from the rule file at path, plus any policies declared in Go.
+func LoadEngine(path string, policies ...Policy) (*Engine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("authz: failed to open rule file: %w", err)
+	}
+	defer f.Close()
+	fromFile, err := ParsePolicies(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(append(fromFile, policies...)...)
+}
@@ -1,33 +1,140 @@
 package config
 
-import "time"
+import (
+	"net/http"
+	"time"
+
+	"github.com/shawgichan/go-authkit/core"
+)
 
 // AuthConfig holds configuration for the auth SDK.
 type AuthConfig struct {
-	TokenSymmetricKey   string // For Paseto/JWT
-	AccessTokenDuration time.Duration
-	//RefreshTokenDuration           time.Duration // TODO: later when we implement refresh tokens
+	TokenSymmetricKey    string // For Paseto/JWT
+	AccessTokenDuration  time.Duration
+	RefreshTokenDuration time.Duration
+
+	// Access token claims. When set, issued tokens carry them and AuthMiddleware requires them,
+	// so a token minted for one service is not accepted by another sharing the key.
+	TokenIssuer                    string
+	TokenAudience                  []string      // A token is accepted if its audience contains any of these
+	TokenLeeway                    time.Duration // Tolerated clock skew for expiry and not-before
 	PasswordResetTokenDuration     time.Duration
 	EmailVerificationTokenDuration time.Duration
 
 	AppBaseURL string //  for constructing email links
 
-	// Role definitions // TODO: will add more later
+	// Link sent to verify email addresses, with ?token=; defaults to AppBaseURL + "/auth/verify-email",
+	// or the route mounted by ginhandler's RegisterRoutes
+	EmailVerificationURI string
+
+	// Role definitions
 	DefaultUserRole string
 	AdminRole       string
+	Roles           []core.Role // Permissions and inheritance, see rbac.NewPolicy. May instead come from a core.RoleStorer
 
 	EnforceSingleDeviceLogin bool
+
+	// One-time passcodes (email/SMS)
+	OTPLength                  int // Number of digits, 6-8
+	OTPDuration                time.Duration
+	OTPMaxAttempts             int           // Failed attempts before a code is invalidated; re-sending does not renew them
+	OTPResendInterval          time.Duration // Minimum time between codes sent to a user for the same purpose
+	OTPHashKey                 string        // Server secret the stored codes are hashed with (HMAC); defaults to TokenSymmetricKey
+	UseOTPForEmailVerification bool          // Send a code instead of a link on registration
+
+	// OAuth2 social login
+	OAuthStateDuration time.Duration // How long a login may take between redirect and callback
+	OAuthBindingCookie string        // Ties social login and linking callbacks to the browser that started them
+
+	// Sensitive actions (e.g. linking identities) require a password or a login at most this old
+	ReauthenticationWindow time.Duration
+
+	// OAuth2 authorization server
+	AuthorizationCodeDuration time.Duration
+
+	// OAuth2 device authorization grant
+	DeviceCodeDuration     time.Duration
+	DeviceCodeInterval     time.Duration // Minimum polling interval
+	DeviceVerificationURI  string        // Page where the user enters the code; defaults to the mounted device route under AppBaseURL
+	DeviceUserCodeAttempts int           // User codes, right or wrong, a user may enter within DeviceCodeDuration; 0 means unlimited
+
+	// Lifetime of tokens minted by administrators to act as a user
+	ImpersonationDuration time.Duration
+
+	// Organizations
+	OrganizationOwnerRole  string        // Role given to the user who creates an organization
+	OrganizationMemberRole string        // Default role of invited users
+	InvitationDuration     time.Duration // How long an invitation can be accepted
+	MaxPendingInvitations  int           // Per organization; 0 means unlimited
+	InvitationURI          string        // Page where invitations are accepted; defaults to the mounted invitation route under AppBaseURL
+
+	// API keys
+	APIKeyPrefix      string        // Keys look like "<prefix>_<secret>"
+	APIKeyScopes      []string      // Scopes a key may be granted; empty allows any
+	APIKeyMaxDuration time.Duration // Longest allowed key lifetime; 0 allows keys that never expire
+	MaxAPIKeysPerUser int           // 0 means unlimited
+
+	// Default role of new service accounts
+	ServiceAccountRole string
+
+	// Signed requests are rejected if their timestamp differs from the server clock by more than this
+	SignatureMaxSkew time.Duration
+
+	// DPoP proof-of-possession
+	DPoPProofMaxAge time.Duration // Oldest accepted proof
+	DPoPBaseURL     string        // Public scheme and host of the API behind a proxy, for matching proofs; defaults to the request's
+
+	// Cookie sessions for browser apps (see ginhandler.CookieTransport)
+	AccessTokenCookieName  string
+	RefreshTokenCookieName string
+	CSRFCookieName         string // Readable by scripts, which echo it in CSRFHeaderName
+	CSRFHeaderName         string
+	CSRFKey                string // Server secret CSRF tokens are derived from (HMAC of the session ID); defaults to TokenSymmetricKey
+	CookieDomain           string // Empty for host-only cookies
+	CookiePath             string
+	RefreshCookiePath      string // Limits where the refresh token is sent; defaults to the directory of the mounted session routes, else CookiePath
+	CookieSameSite         http.SameSite
+	CookieInsecure         bool // Allows the cookies over plain HTTP, for local development only
 }
 
 // DefaultConfig returns a config with sensible defaults.
 func DefaultAuthConfig() *AuthConfig {
 	return &AuthConfig{
 		AccessTokenDuration:            time.Hour * 24,
+		TokenLeeway:                    time.Second * 30,
+		RefreshTokenDuration:           time.Hour * 24 * 30,
 		PasswordResetTokenDuration:     time.Hour * 1,
 		EmailVerificationTokenDuration: time.Hour * 24,
 		DefaultUserRole:                "user",
 		AdminRole:                      "admin",
 		EnforceSingleDeviceLogin:       true,
+		OTPLength:                      6,
+		OTPDuration:                    time.Minute * 10,
+		OTPMaxAttempts:                 5,
+		OTPResendInterval:              time.Minute,
+		OAuthStateDuration:             time.Minute * 10,
+		OAuthBindingCookie:             "oauth_binding",
+		ReauthenticationWindow:         time.Minute * 5,
+		AuthorizationCodeDuration:      time.Minute * 1,
+		DeviceCodeDuration:             time.Minute * 10,
+		DeviceCodeInterval:             time.Second * 5,
+		DeviceUserCodeAttempts:         20,
+		ImpersonationDuration:          time.Minute * 15,
+		OrganizationOwnerRole:          "owner",
+		OrganizationMemberRole:         "member",
+		InvitationDuration:             time.Hour * 24 * 7,
+		MaxPendingInvitations:          50,
+		APIKeyPrefix:                   "ak",
+		MaxAPIKeysPerUser:              25,
+		ServiceAccountRole:             "service",
+		SignatureMaxSkew:               time.Minute * 5,
+		DPoPProofMaxAge:                time.Minute * 2,
+		AccessTokenCookieName:          "access_token",
+		RefreshTokenCookieName:         "refresh_token",
+		CSRFCookieName:                 "csrf_token",
+		CSRFHeaderName:                 "X-CSRF-Token",
+		CookiePath:                     "/",
+		CookieSameSite:                 http.SameSiteLaxMode,
 		AppBaseURL:                     "http://localhost:3000", // Placeholder
 	}
 }
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a long-lived credential for scripts and integrations, sent as
+// "Authorization: ApiKey <key>". Keys look like "<prefix>_<secret>"; only a hash
+// of the whole key is stored, along with a short hint to recognize it by.
+type APIKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TenantID   string // Organization the key is scoped to, if it was created in one
+	Name       string
+	Hint       string // Prefix and first characters of the secret, e.g. "ak_3kF9x"
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  time.Time // Zero means the key does not expire
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+// IsExpired reports whether the key has expired at now.
+func (k APIKey) IsExpired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
@@ -0,0 +1,26 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit event types recorded by go-authkit.
+const (
+	AuditImpersonationStarted = "impersonation.started"
+	AuditTokenExchanged       = "token.exchanged"
+)
+
+// AuditEvent records a security-relevant action for the audit trail.
+type AuditEvent struct {
+	Type      string
+	ActorID   uuid.UUID // Who performed the action
+	SubjectID uuid.UUID // Who the action was performed on or as
+	ClientID  string    // OAuth2 client involved, if any
+	Reason    string
+	IPAddress string
+	UserAgent string
+	Metadata  map[string]string
+	CreatedAt time.Time
+}
@@ -15,5 +15,44 @@ var (
 	ErrVerificationNotFound  = errors.New("verification data not found or already used")
 	ErrPasswordResetNotFound = errors.New("password reset token not found or already used")
 	ErrForbidden             = errors.New("action is forbidden for this user")
+	ErrOTPNotFound           = errors.New("one-time passcode not found or already used")
+	ErrOTPInvalid            = errors.New("one-time passcode is invalid")
+	ErrOTPExpired            = errors.New("one-time passcode has expired")
+	ErrOTPAttemptsExceeded   = errors.New("too many invalid one-time passcode attempts")
+	ErrOTPChannelUnavailable = errors.New("one-time passcode channel is not available for this user")
+	ErrOAuthStateNotFound    = errors.New("oauth state not found, expired or already used")
+	ErrOAuthProviderNotFound = errors.New("oauth provider not found")
+	ErrEmailNotVerified      = errors.New("email address has not been verified by the provider")
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to a user")
+	ErrLastLoginMethod       = errors.New("cannot remove the last remaining login method")
+	ErrReauthRequired        = errors.New("recent authentication is required for this action")
+	ErrInsufficientScope     = errors.New("token does not have the required scope")
+	ErrTokenRevoked          = errors.New("token has been revoked")
+	ErrImpersonationDenied   = errors.New("action is not allowed with an impersonated or delegated token")
+	ErrPermissionDenied      = errors.New("user does not have the required permission")
+	ErrAccessDenied          = errors.New("access denied by policy")
+	ErrNotMember             = errors.New("user is not a member of the organization")
+	ErrAlreadyMember         = errors.New("user is already a member of the organization")
+	ErrInvitationNotFound    = errors.New("invitation not found, expired or no longer pending")
+	ErrInvitationPending     = errors.New("an invitation for this email is already pending")
+	ErrInvitationLimit       = errors.New("too many pending invitations for this organization")
+	ErrInvitationEmail       = errors.New("invitation was sent to a different email address")
+	ErrAPIKeyInvalid         = errors.New("api key is invalid or expired")
+	ErrAPIKeyLimit           = errors.New("too many api keys for this user")
+	ErrSignatureInvalid      = errors.New("request signature is missing or invalid")
+	ErrSignatureExpired      = errors.New("request signature timestamp is outside the allowed window")
+	ErrRequestReplayed       = errors.New("request nonce has already been used")
+	ErrClientCertRequired    = errors.New("a valid client certificate is required")
+	ErrCertificateMismatch   = errors.New("token is bound to a different client certificate")
+	ErrDPoPProofInvalid      = errors.New("dpop proof is missing or invalid")
+	ErrCSRFTokenInvalid      = errors.New("csrf token is missing or invalid")
+	ErrTooManyAttempts       = errors.New("too many failed attempts, try again later")
+	ErrAuthorizationMissing  = errors.New("authorization header is not provided")
+	ErrAuthorizationInvalid  = errors.New("authorization header is invalid or uses an unsupported type")
+	ErrPasswordTooShort      = errors.New("password must be at least 8 characters long")
+	ErrUserInactive          = errors.New("user account is not active")
+	ErrUserNotFound          = errors.New("authenticated user not found or has been deleted")
+	ErrSessionExpired        = errors.New("session expired, please login again")
+	ErrSessionReplaced       = errors.New("user logged in with a different device or session")
 	// TODO: Add more later
 )
@@ -0,0 +1,22 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentityProviderPassword is the pseudo-provider of the email/password login method.
+// It is not stored as an Identity; a user has it when User.PasswordHash is set.
+const IdentityProviderPassword = "password"
+
+// Identity is an external login method linked to a user, such as a Google or GitHub account.
+// A user may have several identities, each unique per (Provider, Subject).
+type Identity struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	Provider string // e.g. "google", "github" or the name of an OIDC provider
+	Subject  string // The provider's stable user ID
+	Email    string // Email reported by the provider when the identity was linked
+	LinkedAt time.Time
+}
@@ -10,19 +10,24 @@ import (
 // CreateUserParams for UserStorer.CreateUser
 type CreateUserParams struct {
 	Username     string
-	Email        string
+	Email        string // Empty for service accounts
+	PhoneNumber  string
 	PasswordHash string
 	FullName     string
 	Role         string
+	Roles        []string // Additional roles
 	Status       UserStatus
+	Type         UserType // Empty means UserTypeHuman
 }
 
 // UpdateUserParams for UserStorer.UpdateUser
 // Use pointers or a map for partial updates if needed, or dedicated methods.
 type UpdateUserParams struct {
 	FullName     *string
+	PhoneNumber  *string
 	PasswordHash *string
 	Role         *string
+	Roles        *[]string
 	Status       *UserStatus
 	ActiveToken  *string // To set or clear the active token
 }
@@ -47,9 +52,167 @@ type UserStorer interface {
 	DeletePasswordResetToken(ctx context.Context, token string) error
 }
 
+// OTPStorer defines methods an application must implement for one-time passcode persistence.
+// At most one OTP per user and purpose is active; storing a new one replaces the previous one,
+// including its Attempts.
+type OTPStorer interface {
+	StoreOTP(ctx context.Context, otp OTP) error
+	GetOTP(ctx context.Context, userID uuid.UUID, purpose OTPPurpose) (OTP, error) // Returns ErrOTPNotFound if none
+	// IncrementOTPAttempts atomically increments Attempts and returns the new count.
+	// Returns ErrOTPNotFound if none.
+	IncrementOTPAttempts(ctx context.Context, userID uuid.UUID, purpose OTPPurpose) (attempts int, err error)
+	// ConsumeOTP atomically deletes the OTP if it still has codeHash. Returns ErrOTPNotFound
+	// if it does not exist, was already consumed or was replaced by a new code.
+	ConsumeOTP(ctx context.Context, userID uuid.UUID, purpose OTPPurpose, codeHash string) error
+	DeleteOTP(ctx context.Context, userID uuid.UUID, purpose OTPPurpose) error
+}
+
+// OTPSender delivers one-time passcodes over a single channel (email, SMS, ...).
+// See the otp package for ready-made email and SMS implementations.
+type OTPSender interface {
+	SendOTP(ctx context.Context, destination, username, code string, purpose OTPPurpose) error
+}
+
+// OAuthStateStorer defines methods an application must implement to persist OAuth2 login state
+// between the redirect to the provider and the callback.
+type OAuthStateStorer interface {
+	StoreOAuthState(ctx context.Context, state OAuthState) error
+	// ConsumeOAuthState returns and deletes the state, so it can only be used once.
+	// Returns ErrOAuthStateNotFound if it does not exist.
+	ConsumeOAuthState(ctx context.Context, state string) (OAuthState, error)
+}
+
+// IdentityStorer defines methods an application must implement to persist linked login identities.
+type IdentityStorer interface {
+	// CreateIdentity returns ErrIdentityAlreadyLinked if the (Provider, Subject) pair already exists.
+	CreateIdentity(ctx context.Context, identity Identity) (Identity, error)
+	GetIdentity(ctx context.Context, provider, subject string) (Identity, error) // Returns ErrNotFound if none
+	ListIdentitiesByUserID(ctx context.Context, userID uuid.UUID) ([]Identity, error)
+	DeleteIdentity(ctx context.Context, userID uuid.UUID, identityID uuid.UUID) error
+}
+
+// OAuthClientStorer defines methods an application must implement for the OAuth2 client registry.
+type OAuthClientStorer interface {
+	CreateOAuthClient(ctx context.Context, client OAuthClient) (OAuthClient, error)
+	GetOAuthClient(ctx context.Context, clientID string) (OAuthClient, error) // Returns ErrNotFound if none
+	ListOAuthClients(ctx context.Context) ([]OAuthClient, error)
+	DeleteOAuthClient(ctx context.Context, clientID string) error
+}
+
+// OAuthServerStorer defines methods an application must implement to persist
+// authorization server state: authorization codes and user consents.
+type OAuthServerStorer interface {
+	StoreAuthorizationCode(ctx context.Context, code AuthorizationCode) error
+	// ConsumeAuthorizationCode returns and deletes the code. Returns ErrNotFound if it does not exist.
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (AuthorizationCode, error)
+
+	GetConsent(ctx context.Context, userID uuid.UUID, clientID string) (OAuthConsent, error) // Returns ErrNotFound if none
+	SaveConsent(ctx context.Context, consent OAuthConsent) error
+}
+
+// RefreshTokenStorer defines methods an application must implement for refresh token persistence.
+type RefreshTokenStorer interface {
+	StoreRefreshToken(ctx context.Context, token RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) // Returns ErrNotFound if none
+	// ConsumeRefreshToken returns and deletes the token. Returns ErrNotFound if it does not exist.
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error)
+	DeleteRefreshTokensByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteRefreshTokensByClient(ctx context.Context, userID uuid.UUID, clientID string) error // The user's tokens issued to clientID
+}
+
+// DeviceAuthorizationStorer defines methods an application must implement to persist
+// device authorization grants.
+type DeviceAuthorizationStorer interface {
+	StoreDeviceAuthorization(ctx context.Context, auth DeviceAuthorization) error
+	GetDeviceAuthorizationByDeviceCode(ctx context.Context, deviceCodeHash string) (DeviceAuthorization, error) // Returns ErrNotFound if none
+	GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (DeviceAuthorization, error)         // Returns ErrNotFound if none
+	UpdateDeviceAuthorization(ctx context.Context, auth DeviceAuthorization) error                              // Keyed by DeviceCodeHash
+	// TouchDeviceAuthorization records a poll by setting only LastPolledAt and Interval, so
+	// that it cannot undo a decision stored meanwhile. Returns ErrNotFound if none.
+	TouchDeviceAuthorization(ctx context.Context, deviceCodeHash string, polledAt time.Time, interval time.Duration) error
+	DeleteDeviceAuthorization(ctx context.Context, deviceCodeHash string) error
+	// ConsumeDeviceAuthorization returns and deletes the authorization in one atomic step, so
+	// that concurrent polls cannot both redeem it. Returns ErrNotFound if it does not exist.
+	ConsumeDeviceAuthorization(ctx context.Context, deviceCodeHash string) (DeviceAuthorization, error)
+	// CountUserCodeAttempt atomically counts a user code entered by userID and returns the
+	// number entered in the current window, which starts with the first entry and lasts window.
+	CountUserCodeAttempt(ctx context.Context, userID uuid.UUID, window time.Duration) (attempts int, err error)
+}
+
+// TokenRevocationStorer defines methods an application must implement to keep a list
+// of revoked access tokens. Entries can be dropped once expiresAt has passed.
+type TokenRevocationStorer interface {
+	RevokeToken(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error)
+}
+
+// RoleStorer defines methods an application must implement to load role definitions
+// from its database instead of AuthConfig.Roles.
+type RoleStorer interface {
+	ListRoles(ctx context.Context) ([]Role, error)
+}
+
+// OrganizationStorer defines methods an application must implement to persist
+// organizations (tenants) and their memberships.
+type OrganizationStorer interface {
+	CreateOrganization(ctx context.Context, org Organization) (Organization, error)
+	GetOrganization(ctx context.Context, id uuid.UUID) (Organization, error) // Returns ErrNotFound if none
+
+	AddMember(ctx context.Context, membership Membership) error // Returns ErrAlreadyMember if the user is a member
+	UpdateMember(ctx context.Context, membership Membership) error
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
+	GetMembership(ctx context.Context, orgID, userID uuid.UUID) (Membership, error) // Returns ErrNotFound if not a member
+	ListMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]Membership, error)
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]Membership, error)
+}
+
+// InvitationStorer defines methods an application must implement to persist
+// organization invitations.
+type InvitationStorer interface {
+	CreateInvitation(ctx context.Context, invitation Invitation) error
+	GetInvitation(ctx context.Context, id uuid.UUID) (Invitation, error)                                 // Returns ErrNotFound if none
+	GetInvitationByTokenHash(ctx context.Context, tokenHash string) (Invitation, error)                  // Returns ErrNotFound if none
+	UpdateInvitation(ctx context.Context, invitation Invitation) error                                   // Keyed by ID
+	ListInvitations(ctx context.Context, orgID uuid.UUID, status InvitationStatus) ([]Invitation, error) // Includes expired ones
+}
+
+// ServiceAccountStorer defines methods an application must implement to list service
+// accounts separately from people. Service accounts themselves are stored through UserStorer.
+type ServiceAccountStorer interface {
+	ListServiceAccounts(ctx context.Context) ([]User, error)
+}
+
+// APIKeyStorer defines methods an application must implement to persist API keys.
+type APIKeyStorer interface {
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error) // Returns ErrNotFound if none
+	ListAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID, keyID uuid.UUID) error // Returns ErrNotFound if the user has no such key
+	UpdateAPIKeyLastUsed(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error
+}
+
+// SigningKeyStorer defines methods an application must implement to look up the keys
+// of signed requests.
+type SigningKeyStorer interface {
+	GetSigningKey(ctx context.Context, keyID string) (SigningKey, error) // Returns ErrNotFound if none
+}
+
+// NonceStorer remembers single-use values, such as request nonces, to reject replays.
+type NonceStorer interface {
+	// UseNonce records nonce until expiresAt and reports whether it was unused.
+	// It must be atomic: of two concurrent calls with the same nonce, only one may succeed.
+	UseNonce(ctx context.Context, nonce string, expiresAt time.Time) (bool, error)
+}
+
+// AuditLogger defines methods an application must implement to keep an audit trail.
+type AuditLogger interface {
+	LogAuditEvent(ctx context.Context, event AuditEvent) error
+}
+
 // EmailSender defines methods an application must implement for sending emails.
 type EmailSender interface {
 	SendVerificationEmail(ctx context.Context, toEmail, username, verificationLink string) error
 	SendPasswordResetEmail(ctx context.Context, toEmail, username, resetLink string) error
+	SendInvitationEmail(ctx context.Context, toEmail, organizationName, inviterName, invitationLink string) error
 	// TODO: Add other email types as needed (e.g., SendWelcomeEmail)
 }
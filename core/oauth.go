@@ -0,0 +1,19 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthState is the server-side state of an in-flight OAuth2 login,
+// keyed by the random state parameter sent to the provider.
+type OAuthState struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string    // PKCE verifier, never sent to the browser
+	LinkUserID   uuid.UUID // Set when linking the identity to this logged-in user instead of logging in
+	BindingHash  string    // Hash of the browser binding cookie; the callback must present the cookie
+	ExpiresAt    time.Time
+}
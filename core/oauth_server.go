@@ -0,0 +1,112 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuth2 grant types supported by the authorization server.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// TokenTypeAccessToken identifies access tokens in token exchange requests (RFC 8693 section 3).
+const TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+// OAuthClient is an application registered with the authorization server.
+type OAuthClient struct {
+	ID                     uuid.UUID // Internal ID; also the subject of client_credentials tokens
+	ClientID               string    // Public identifier sent by the client
+	SecretHash             string    // Empty for public clients (SPAs, mobile apps), which must use PKCE
+	Name                   string
+	RedirectURIs           []string // Exact-match allow list
+	PostLogoutRedirectURIs []string // Exact-match allow list for OpenID Connect RP-initiated logout
+	GrantTypes             []string
+	Scopes                 []string  // Scopes the client may request
+	FirstParty             bool      // First-party clients skip the consent step
+	ResourceServer         bool      // May introspect tokens issued to other clients and login tokens
+	ServiceAccountID       uuid.UUID // Optional: client_credentials tokens act as this service account
+	CreatedAt              time.Time
+}
+
+// IsPublic reports whether the client has no secret.
+func (c OAuthClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+// AllowsGrant reports whether the client is registered for grantType.
+func (c OAuthClient) AllowsGrant(grantType string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizationCode is a single-use code issued by the authorization endpoint.
+// Only a hash of the code is stored.
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectURI   string
+	Scope         string    // Space-delimited
+	CodeChallenge string    // PKCE S256 challenge
+	Nonce         string    // OpenID Connect nonce, echoed in the ID token
+	AuthTime      time.Time // When the user logged in
+	ExpiresAt     time.Time
+}
+
+// OAuthConsent records the scopes a user has granted to a client.
+type OAuthConsent struct {
+	UserID    uuid.UUID
+	ClientID  string
+	Scopes    []string
+	GrantedAt time.Time
+}
+
+// RefreshToken is a long-lived token exchanged for new access tokens.
+// Only a hash is stored, and refresh tokens are rotated on every use.
+type RefreshToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	ClientID  string
+	Scope     string    // Space-delimited
+	AuthTime  time.Time // When the user originally logged in, for refreshed ID tokens
+	JKT       string    // DPoP key thumbprint the token is bound to (public clients only)
+	TenantID  string    // Organization a cookie session is scoped to
+	SessionID string    // Cookie session the token belongs to; kept when the token is rotated
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// DeviceAuthorizationStatus is the state of a device authorization request.
+type DeviceAuthorizationStatus string
+
+const (
+	DeviceAuthorizationPending  DeviceAuthorizationStatus = "pending"
+	DeviceAuthorizationApproved DeviceAuthorizationStatus = "approved"
+	DeviceAuthorizationDenied   DeviceAuthorizationStatus = "denied"
+)
+
+// DeviceAuthorization is an in-flight device authorization grant (RFC 8628).
+// The device polls with the device code (stored hashed) while the user enters
+// the short user code on another device.
+type DeviceAuthorization struct {
+	DeviceCodeHash string
+	UserCode       string // Normalized, e.g. "BDFGHJKL"
+	ClientID       string
+	Scope          string // Space-delimited
+	Status         DeviceAuthorizationStatus
+	UserID         uuid.UUID // Set once approved
+	AuthTime       time.Time // Login time of the approving user
+	Interval       time.Duration
+	LastPolledAt   time.Time
+	ExpiresAt      time.Time
+}
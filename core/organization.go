@@ -0,0 +1,62 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a tenant: a customer account that users belong to.
+type Organization struct {
+	ID        uuid.UUID
+	Name      string
+	Slug      string // Optional URL-friendly unique name
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Membership links a user to an organization. Roles held in an organization are
+// separate from the user's global roles and only apply while the user's token is
+// scoped to that organization.
+type Membership struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Role           string   // e.g. "owner", "member"
+	Roles          []string // Additional roles held in the organization
+	JoinedAt       time.Time
+}
+
+// AllRoles returns Role followed by the additional Roles, without duplicates.
+func (m Membership) AllRoles() []string {
+	return mergeRoles(m.Role, m.Roles)
+}
+
+// InvitationStatus is the state of an organization invitation.
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationDeclined InvitationStatus = "declined"
+	InvitationRevoked  InvitationStatus = "revoked"
+)
+
+// Invitation invites someone by email to join an organization with a role.
+// Only the hash of the invitation token is stored; the token itself is sent by email.
+type Invitation struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Email          string
+	Role           string
+	InvitedBy      uuid.UUID
+	TokenHash      string
+	Status         InvitationStatus
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+	RespondedAt    time.Time // When it was accepted, declined or revoked
+}
+
+// IsPending reports whether the invitation can still be accepted at now.
+func (i Invitation) IsPending(now time.Time) bool {
+	return i.Status == InvitationPending && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OTPPurpose identifies what a one-time passcode may be used for.
+type OTPPurpose string
+
+const (
+	OTPPurposeLogin             OTPPurpose = "login"
+	OTPPurposeEmailVerification OTPPurpose = "email_verification"
+)
+
+// OTPChannel identifies how a one-time passcode is delivered to the user.
+type OTPChannel string
+
+const (
+	OTPChannelEmail OTPChannel = "email"
+	OTPChannelSMS   OTPChannel = "sms"
+)
+
+// OTP is a numeric one-time passcode issued to a user.
+// Only a hash of the code is persisted, never the code itself.
+type OTP struct {
+	UserID      uuid.UUID
+	Purpose     OTPPurpose
+	Channel     OTPChannel
+	CodeHash    string
+	Attempts    int // Failed verification attempts so far
+	MaxAttempts int // The code is invalidated once Attempts reaches this
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,9 @@
+package core
+
+// Role is a named set of permissions such as "invoices:write". A role inherits the
+// permissions of the roles it lists in Inherits, e.g. admin inherits editor inherits user.
+type Role struct {
+	Name        string
+	Permissions []string
+	Inherits    []string
+}
@@ -0,0 +1,22 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SigningKey is a shared secret for HMAC-signed server-to-server requests. Unlike API
+// keys, the secret itself must be stored, since the server recomputes signatures with it.
+type SigningKey struct {
+	ID        string    // Sent by the caller to select the key
+	UserID    uuid.UUID // Optional: the user or service account the caller acts as
+	Secret    []byte
+	ExpiresAt time.Time // Zero means the key does not expire
+	CreatedAt time.Time
+}
+
+// IsExpired reports whether the key has expired at now.
+func (k SigningKey) IsExpired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
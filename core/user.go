@@ -17,6 +17,14 @@ const (
 	StatusPendingDelete UserStatus = "pending_delete" // Soft delete
 )
 
+// UserType distinguishes people from machine identities.
+type UserType string
+
+const (
+	UserTypeHuman   UserType = "human"
+	UserTypeService UserType = "service" // Service account: no password or email, authenticates with API keys or client credentials
+)
+
 // User is the canonical user representation the SDK works with.
 // The UserStorer implementation is responsible for mapping this
 // to/from the application's actual database schema.
@@ -24,10 +32,13 @@ type User struct {
 	ID           uuid.UUID
 	Username     string
 	Email        string
+	PhoneNumber  string // Optional, E.164 format. Used for SMS one-time passcodes
 	PasswordHash string `json:"-"` // Exclude from default JSON responses
 	FullName     string
-	Role         string // e.g., "user", "admin"
+	Role         string   // e.g., "user", "admin"
+	Roles        []string // Additional roles held besides Role
 	Status       UserStatus
+	Type         UserType // Empty means UserTypeHuman
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 
@@ -35,3 +46,26 @@ type User struct {
 	ActiveToken string `json:"-"` // Store the currently active access token
 	// TODO: later we will Consider a separate struct/table for more complex session management
 }
+
+// IsServiceAccount reports whether the user is a machine identity rather than a person.
+func (u User) IsServiceAccount() bool {
+	return u.Type == UserTypeService
+}
+
+// AllRoles returns Role followed by the additional Roles, without duplicates.
+func (u User) AllRoles() []string {
+	return mergeRoles(u.Role, u.Roles)
+}
+
+func mergeRoles(primary string, additional []string) []string {
+	roles := make([]string, 0, 1+len(additional))
+	seen := make(map[string]bool, 1+len(additional))
+	for _, role := range append([]string{primary}, additional...) {
+		if role == "" || seen[role] {
+			continue
+		}
+		seen[role] = true
+		roles = append(roles, role)
+	}
+	return roles
+}
@@ -0,0 +1,168 @@
+// Package dpop implements DPoP proof-of-possession (RFC 9449). A client signs a short
+// proof JWT for every request with a key it holds; tokens issued with a proof are bound
+// to the key's JWK thumbprint ("cnf.jkt") and are useless without the key.
+package dpop
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/jose"
+)
+
+// HeaderName is the request header carrying the proof.
+const HeaderName = "DPoP"
+
+// proofType is the required "typ" header of a proof.
+const proofType = "dpop+jwt"
+
+// Claims are the claims of a DPoP proof.
+type Claims struct {
+	JTI             string           `json:"jti"`
+	Method          string           `json:"htm"`
+	URL             string           `json:"htu"`
+	IssuedAt        jose.NumericDate `json:"iat"`
+	AccessTokenHash string           `json:"ath,omitempty"` // Required when the proof accompanies an access token
+}
+
+// Proof is a verified DPoP proof.
+type Proof struct {
+	Claims
+	Thumbprint string // JWK thumbprint of the proof key, the value of "cnf.jkt"
+}
+
+// Verifier checks DPoP proofs and remembers their IDs to reject replays.
+type Verifier struct {
+	nonces core.NonceStorer
+
+	MaxAge time.Duration // Oldest accepted proof
+	Leeway time.Duration // Tolerated clock skew for proofs issued in the future
+}
+
+// NewVerifier creates a verifier. Proof IDs are remembered in nonces until the proof
+// is too old to be accepted anyway.
+func NewVerifier(nonces core.NonceStorer, maxAge, leeway time.Duration) *Verifier {
+	return &Verifier{nonces: nonces, MaxAge: maxAge, Leeway: leeway}
+}
+
+// Verify checks a proof for a request to method and requestURL. For requests carrying a
+// DPoP-bound access token, accessToken must be set and the proof must contain its hash.
+// Failures are returned as core.ErrDPoPProofInvalid, explained by the wrapped message.
+func (v *Verifier) Verify(ctx context.Context, proof, method, requestURL, accessToken string) (Proof, error) {
+	jws, err := jose.Parse(proof)
+	if err != nil {
+		return Proof{}, invalid("malformed proof")
+	}
+	if jws.Header.Typ != proofType {
+		return Proof{}, invalid("typ must be " + proofType)
+	}
+	if !jose.SupportedAlgorithm(jws.Header.Alg) {
+		return Proof{}, invalid(fmt.Sprintf("unsupported algorithm %q", jws.Header.Alg))
+	}
+	if jws.Header.JWK == nil {
+		return Proof{}, invalid("jwk header is required")
+	}
+	pub, err := jws.Header.JWK.PublicKey()
+	if err != nil {
+		return Proof{}, invalid("unsupported jwk")
+	}
+	if err := jws.Verify(pub); err != nil {
+		return Proof{}, invalid("signature does not verify")
+	}
+
+	var claims Claims
+	if err := jws.Claims(&claims); err != nil {
+		return Proof{}, invalid("malformed claims")
+	}
+	if claims.JTI == "" {
+		return Proof{}, invalid("jti is required")
+	}
+	if !strings.EqualFold(claims.Method, method) {
+		return Proof{}, invalid("htm does not match the request method")
+	}
+	if !sameURL(claims.URL, requestURL) {
+		return Proof{}, invalid("htu does not match the request URL")
+	}
+	now := time.Now()
+	issuedAt := claims.IssuedAt.Time()
+	if issuedAt.Before(now.Add(-v.MaxAge)) || issuedAt.After(now.Add(v.Leeway)) {
+		return Proof{}, invalid("iat is outside the accepted window")
+	}
+	if accessToken != "" {
+		if subtle.ConstantTimeCompare([]byte(claims.AccessTokenHash), []byte(AccessTokenHash(accessToken))) != 1 {
+			return Proof{}, invalid("ath does not match the access token")
+		}
+	}
+
+	thumbprint, err := jws.Header.JWK.Thumbprint()
+	if err != nil {
+		return Proof{}, invalid("unsupported jwk")
+	}
+	// Proof IDs only need to be unique per key
+	fresh, err := v.nonces.UseNonce(ctx, "dpop:"+thumbprint+":"+claims.JTI, issuedAt.Add(v.MaxAge+v.Leeway))
+	if err != nil {
+		return Proof{}, fmt.Errorf("failed to record dpop proof: %w", err)
+	}
+	if !fresh {
+		return Proof{}, invalid("proof has already been used")
+	}
+	return Proof{Claims: claims, Thumbprint: thumbprint}, nil
+}
+
+// AccessTokenHash returns the "ath" value for an access token.
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewProof creates a proof for a request, signed with key using alg (e.g. "ES256").
+// accessToken is empty when requesting tokens and set when using a bound token.
+func NewProof(key crypto.Signer, alg, method, requestURL, accessToken string) (string, error) {
+	jwk, err := jose.NewJSONWebKey(key.Public(), "", "")
+	if err != nil {
+		return "", err
+	}
+	claims := Claims{
+		JTI:      uuid.NewString(),
+		Method:   strings.ToUpper(method),
+		URL:      requestURL,
+		IssuedAt: jose.NewNumericDate(time.Now()),
+	}
+	if accessToken != "" {
+		claims.AccessTokenHash = AccessTokenHash(accessToken)
+	}
+	return jose.Sign(jose.Header{Alg: alg, Typ: proofType, JWK: &jwk}, claims, key)
+}
+
+// sameURL compares htu with the request URL, ignoring query and fragment (RFC 9449 section 4.3).
+func sameURL(htu, requestURL string) bool {
+	a, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host) && a.EscapedPath() == b.EscapedPath()
+}
+
+func invalid(reason string) error {
+	return fmt.Errorf("%w: %s", core.ErrDPoPProofInvalid, reason)
+}
+
+// IsInvalidProof reports whether err is a rejected proof rather than a storage failure.
+func IsInvalidProof(err error) bool {
+	return errors.Is(err, core.ErrDPoPProofInvalid)
+}
@@ -0,0 +1,148 @@
+package dpop
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/jose"
+)
+
+type nonceStore map[string]bool
+
+func (s nonceStore) UseNonce(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	if s[nonce] {
+		return false, nil
+	}
+	s[nonce] = true
+	return true, nil
+}
+
+func TestVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err := jose.NewJSONWebKey(key.Public(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		method      = "POST"
+		requestURL  = "https://api.example.com/invoices"
+		accessToken = "access-token"
+	)
+
+	tests := []struct {
+		name   string
+		header func(h *jose.Header)
+		claims func(c *Claims)
+		signer *ecdsa.PrivateKey // Defaults to key
+		token  string
+		reason string // Empty for a valid proof
+	}{
+		{name: "valid"},
+		{name: "valid with access token", claims: func(c *Claims) { c.AccessTokenHash = AccessTokenHash(accessToken) }, token: accessToken},
+		{name: "method in lowercase", claims: func(c *Claims) { c.Method = "post" }},
+		{name: "query ignored", claims: func(c *Claims) { c.URL = requestURL + "?page=2" }},
+		{name: "wrong typ", header: func(h *jose.Header) { h.Typ = "JWT" }, reason: "typ must be"},
+		{name: "missing jwk", header: func(h *jose.Header) { h.JWK = nil }, reason: "jwk header is required"},
+		{name: "signed with another key", signer: otherKey, reason: "signature does not verify"},
+		{name: "missing jti", claims: func(c *Claims) { c.JTI = "" }, reason: "jti is required"},
+		{name: "wrong htm", claims: func(c *Claims) { c.Method = "GET" }, reason: "htm does not match"},
+		{name: "wrong htu host", claims: func(c *Claims) { c.URL = "https://evil.example.com/invoices" }, reason: "htu does not match"},
+		{name: "wrong htu path", claims: func(c *Claims) { c.URL = "https://api.example.com/payments" }, reason: "htu does not match"},
+		{name: "wrong htu scheme", claims: func(c *Claims) { c.URL = "http://api.example.com/invoices" }, reason: "htu does not match"},
+		{name: "iat too old", claims: func(c *Claims) { c.IssuedAt = jose.NewNumericDate(time.Now().Add(-2 * time.Minute)) }, reason: "iat is outside"},
+		{name: "iat in the future", claims: func(c *Claims) { c.IssuedAt = jose.NewNumericDate(time.Now().Add(time.Minute)) }, reason: "iat is outside"},
+		{name: "iat within the leeway", claims: func(c *Claims) { c.IssuedAt = jose.NewNumericDate(time.Now().Add(3 * time.Second)) }},
+		{name: "ath missing", token: accessToken, reason: "ath does not match"},
+		{name: "ath of another token", claims: func(c *Claims) { c.AccessTokenHash = AccessTokenHash("other-token") }, token: accessToken, reason: "ath does not match"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := jose.Header{Alg: "ES256", Typ: proofType, JWK: &jwk}
+			if tt.header != nil {
+				tt.header(&header)
+			}
+			claims := Claims{JTI: "proof-1", Method: method, URL: requestURL, IssuedAt: jose.NewNumericDate(time.Now())}
+			if tt.claims != nil {
+				tt.claims(&claims)
+			}
+			signer := tt.signer
+			if signer == nil {
+				signer = key
+			}
+			proof, err := jose.Sign(header, claims, signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := NewVerifier(nonceStore{}, time.Minute, 5*time.Second)
+			got, err := v.Verify(context.Background(), proof, method, requestURL, tt.token)
+			if tt.reason != "" {
+				if !errors.Is(err, core.ErrDPoPProofInvalid) || !strings.Contains(err.Error(), tt.reason) {
+					t.Fatalf("Verify() = %v, want ErrDPoPProofInvalid: %s", err, tt.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() = %v, want nil", err)
+			}
+			if want, _ := jwk.Thumbprint(); got.Thumbprint != want {
+				t.Errorf("Thumbprint = %q, want %q", got.Thumbprint, want)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const requestURL = "https://api.example.com/token"
+	v := NewVerifier(nonceStore{}, time.Minute, 5*time.Second)
+	ctx := context.Background()
+
+	proof, err := NewProof(key, "ES256", "POST", requestURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(ctx, proof, "POST", requestURL, ""); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if _, err := v.Verify(ctx, proof, "POST", requestURL, ""); !IsInvalidProof(err) || !strings.Contains(err.Error(), "already been used") {
+		t.Errorf("Verify(replay) = %v, want ErrDPoPProofInvalid", err)
+	}
+
+	// jti only needs to be unique per key
+	for _, signer := range []*ecdsa.PrivateKey{key, otherKey} {
+		jwk, err := jose.NewJSONWebKey(signer.Public(), "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := jose.Sign(jose.Header{Alg: "ES256", Typ: proofType, JWK: &jwk},
+			Claims{JTI: "same-jti", Method: "POST", URL: requestURL, IssuedAt: jose.NewNumericDate(time.Now())}, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := v.Verify(ctx, proof, "POST", requestURL, ""); err != nil {
+			t.Errorf("Verify() of a jti used by another key = %v, want nil", err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package echohandler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/shawgichan/go-authkit/authtest"
+	"github.com/shawgichan/go-authkit/echohandler"
+)
+
+func TestAdapter(t *testing.T) {
+	authtest.Run(t, func(t *testing.T, deps authtest.Deps) http.Handler {
+		h := echohandler.NewAuthEchoHandler(deps.Auth)
+		auth := echohandler.AuthMiddleware(deps.Authenticator)
+		e := echo.New()
+		e.POST(authtest.RegisterPath, h.RegisterUser)
+		e.POST(authtest.LoginPath, h.LoginUser)
+		e.GET(authtest.VerifyEmailPath, h.VerifyEmailHandler)
+		e.GET(authtest.UserInfoPath, h.UserInfoHandler, auth)
+		e.GET(authtest.AdminPath, h.UserInfoHandler, auth, echohandler.RoleMiddleware("admin"))
+		return e
+	})
+}
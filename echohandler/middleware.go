@@ -0,0 +1,71 @@
+package echohandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// AuthorizationPayloadKey is the Echo context key of the verified token payload.
+const AuthorizationPayloadKey = "authorization_payload"
+
+// AuthMiddleware authenticates requests with "Authorization: Bearer <token>", and the
+// ApiKey and DPoP schemes if authenticator accepts them. It verifies the credential,
+// checks sender-constrained tokens and the user's status and active session (see
+// service.Authenticator.AuthenticateRequest), then stores the payload in the Echo
+// context and the request context (see token.FromContext).
+func AuthMiddleware(authenticator *service.Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			payload, err := authenticator.AuthenticateRequest(c.Request(), false)
+			if err != nil {
+				if errors.Is(err, core.ErrDPoPProofInvalid) {
+					c.Response().Header().Set("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+				}
+				return MapSDKErrorToHTTP(c, err)
+			}
+			c.Set(AuthorizationPayloadKey, payload)
+			c.SetRequest(c.Request().WithContext(token.NewContext(c.Request().Context(), payload)))
+			return next(c)
+		}
+	}
+}
+
+// RoleMiddleware passes requests whose user holds any of the allowed roles, in the
+// token's organization if it is scoped to one. It must run behind AuthMiddleware.
+func RoleMiddleware(allowedRoles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			payload, exists := GetAuthPayload(c)
+			if !exists {
+				return RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization payload not found for role check", nil)
+			}
+			roles := payload.EffectiveRoles()
+			for _, allowed := range allowedRoles {
+				for _, role := range roles {
+					if strings.EqualFold(role, allowed) {
+						return next(c)
+					}
+				}
+			}
+			errDetails := fmt.Sprintf("Access denied. Your roles %v are not in allowed roles: %v", roles, allowedRoles)
+			return RespondWithError(c, http.StatusForbidden, "FORBIDDEN", "You do not have permission to access this resource", errDetails)
+		}
+	}
+}
+
+// GetAuthPayload retrieves the token payload set by AuthMiddleware.
+func GetAuthPayload(c echo.Context) (*token.Payload, bool) {
+	payload, ok := c.Get(AuthorizationPayloadKey).(*token.Payload)
+	if !ok {
+		return token.FromContext(c.Request().Context())
+	}
+	return payload, true
+}
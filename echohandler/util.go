@@ -0,0 +1,42 @@
+package echohandler
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/shawgichan/go-authkit/httphandler"
+)
+
+// ErrorResponse is a generic JSON error response.
+type ErrorResponse = httphandler.ErrorResponse
+
+// SuccessResponse is a generic JSON success response.
+type SuccessResponse = httphandler.SuccessResponse
+
+// RespondWithError sends a JSON error response.
+func RespondWithError(c echo.Context, httpStatusCode int, sdkErrorCode string, message string, details interface{}) error {
+	return c.JSON(httpStatusCode, ErrorResponse{
+		Status:  "error",
+		Code:    sdkErrorCode,
+		Message: message,
+		Details: details,
+	})
+}
+
+// MapSDKErrorToHTTP sends the error response for a core SDK error
+// (see httphandler.ErrorStatus).
+func MapSDKErrorToHTTP(c echo.Context, err error) error {
+	httpStatus, errCode := httphandler.ErrorStatus(err)
+	errMsg := "An unexpected error occurred"
+	if err != nil { // Default message from error
+		errMsg = err.Error()
+	}
+	return RespondWithError(c, httpStatus, errCode, errMsg, nil)
+}
+
+// RespondWithSuccess sends a JSON success response.
+func RespondWithSuccess(c echo.Context, httpStatusCode int, data interface{}) error {
+	return c.JSON(httpStatusCode, SuccessResponse{
+		Status: "success",
+		Data:   data,
+	})
+}
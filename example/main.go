@@ -3,9 +3,15 @@ package main
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"errors"
 	"log"
+	"net/http"
+	"net/netip"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,10 +19,19 @@ import (
 	"github.com/google/uuid"
 
 	// Adjust import paths to your SDK
+	"github.com/shawgichan/go-authkit/authz"
 	"github.com/shawgichan/go-authkit/config"
 	"github.com/shawgichan/go-authkit/core"
 	"github.com/shawgichan/go-authkit/ginhandler"
 	"github.com/shawgichan/go-authkit/hash"
+	"github.com/shawgichan/go-authkit/httphandler"
+	"github.com/shawgichan/go-authkit/mtls"
+	"github.com/shawgichan/go-authkit/oauth"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/oidc"
+	"github.com/shawgichan/go-authkit/otp"
+	"github.com/shawgichan/go-authkit/rbac"
+	"github.com/shawgichan/go-authkit/service"
 	"github.com/shawgichan/go-authkit/token"
 )
 
@@ -43,7 +58,7 @@ func (s *InMemoryUserStore) CreateUser(ctx context.Context, params core.CreateUs
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.emailIndex[params.Email]; exists {
+	if _, exists := s.emailIndex[params.Email]; exists && params.Email != "" {
 		return core.User{}, core.ErrDuplicateEmail
 	}
 
@@ -51,18 +66,35 @@ func (s *InMemoryUserStore) CreateUser(ctx context.Context, params core.CreateUs
 		ID:           uuid.New(),
 		Username:     params.Username,
 		Email:        params.Email,
+		PhoneNumber:  params.PhoneNumber,
 		PasswordHash: params.PasswordHash,
 		FullName:     params.FullName,
 		Role:         params.Role,
+		Roles:        params.Roles,
 		Status:       params.Status,
+		Type:         params.Type,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 	s.users[newUser.ID] = newUser
-	s.emailIndex[newUser.Email] = newUser.ID
+	if newUser.Email != "" { // Service accounts have no email
+		s.emailIndex[newUser.Email] = newUser.ID
+	}
 	return newUser, nil
 }
 
+func (s *InMemoryUserStore) ListServiceAccounts(ctx context.Context) ([]core.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var accounts []core.User
+	for _, user := range s.users {
+		if user.IsServiceAccount() {
+			accounts = append(accounts, user)
+		}
+	}
+	return accounts, nil
+}
+
 func (s *InMemoryUserStore) GetUserByEmail(ctx context.Context, email string) (core.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -77,6 +109,17 @@ func (s *InMemoryUserStore) GetUserByEmail(ctx context.Context, email string) (c
 	return user, nil
 }
 
+func (s *InMemoryUserStore) GetUserByUsername(ctx context.Context, username string) (core.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, user := range s.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return core.User{}, core.ErrNotFound
+}
+
 func (s *InMemoryUserStore) GetUserByID(ctx context.Context, id uuid.UUID) (core.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -97,9 +140,18 @@ func (s *InMemoryUserStore) UpdateUser(ctx context.Context, userID uuid.UUID, pa
 	if params.FullName != nil {
 		user.FullName = *params.FullName
 	}
+	if params.PhoneNumber != nil {
+		user.PhoneNumber = *params.PhoneNumber
+	}
 	if params.PasswordHash != nil {
 		user.PasswordHash = *params.PasswordHash
 	}
+	if params.Role != nil {
+		user.Role = *params.Role
+	}
+	if params.Roles != nil {
+		user.Roles = *params.Roles
+	}
 	if params.Status != nil {
 		user.Status = *params.Status
 	}
@@ -150,6 +202,619 @@ func (s *InMemoryUserStore) DeletePasswordResetToken(ctx context.Context, token
 	return nil
 }
 
+// --- Minimal Mock OTPStorer ---
+type InMemoryOTPStore struct {
+	mu   sync.Mutex
+	otps map[string]core.OTP // userID:purpose -> OTP
+}
+
+func NewInMemoryOTPStore() *InMemoryOTPStore {
+	return &InMemoryOTPStore{otps: make(map[string]core.OTP)}
+}
+
+func otpKey(userID uuid.UUID, purpose core.OTPPurpose) string {
+	return userID.String() + ":" + string(purpose)
+}
+
+func (s *InMemoryOTPStore) StoreOTP(ctx context.Context, entry core.OTP) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.otps[otpKey(entry.UserID, entry.Purpose)] = entry
+	return nil
+}
+func (s *InMemoryOTPStore) GetOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) (core.OTP, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, exists := s.otps[otpKey(userID, purpose)]
+	if !exists {
+		return core.OTP{}, core.ErrOTPNotFound
+	}
+	return entry, nil
+}
+func (s *InMemoryOTPStore) IncrementOTPAttempts(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, exists := s.otps[otpKey(userID, purpose)]
+	if !exists {
+		return 0, core.ErrOTPNotFound
+	}
+	entry.Attempts++
+	s.otps[otpKey(userID, purpose)] = entry
+	return entry.Attempts, nil
+}
+func (s *InMemoryOTPStore) ConsumeOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose, codeHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, exists := s.otps[otpKey(userID, purpose)]
+	if !exists || entry.CodeHash != codeHash {
+		return core.ErrOTPNotFound
+	}
+	delete(s.otps, otpKey(userID, purpose))
+	return nil
+}
+func (s *InMemoryOTPStore) DeleteOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.otps, otpKey(userID, purpose))
+	return nil
+}
+
+// --- Minimal Mock OAuthStateStorer ---
+type InMemoryOAuthStateStore struct {
+	mu     sync.Mutex
+	states map[string]core.OAuthState
+}
+
+func NewInMemoryOAuthStateStore() *InMemoryOAuthStateStore {
+	return &InMemoryOAuthStateStore{states: make(map[string]core.OAuthState)}
+}
+
+func (s *InMemoryOAuthStateStore) StoreOAuthState(ctx context.Context, state core.OAuthState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state.State] = state
+	return nil
+}
+func (s *InMemoryOAuthStateStore) ConsumeOAuthState(ctx context.Context, state string) (core.OAuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, exists := s.states[state]
+	if !exists {
+		return core.OAuthState{}, core.ErrOAuthStateNotFound
+	}
+	delete(s.states, state)
+	return entry, nil
+}
+
+// --- Minimal Mock IdentityStorer ---
+type InMemoryIdentityStore struct {
+	mu         sync.RWMutex
+	identities map[uuid.UUID]core.Identity
+}
+
+func NewInMemoryIdentityStore() *InMemoryIdentityStore {
+	return &InMemoryIdentityStore{identities: make(map[uuid.UUID]core.Identity)}
+}
+
+func (s *InMemoryIdentityStore) CreateIdentity(ctx context.Context, identity core.Identity) (core.Identity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return core.Identity{}, core.ErrIdentityAlreadyLinked
+		}
+	}
+	s.identities[identity.ID] = identity
+	return identity, nil
+}
+func (s *InMemoryIdentityStore) GetIdentity(ctx context.Context, provider, subject string) (core.Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, identity := range s.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return core.Identity{}, core.ErrNotFound
+}
+func (s *InMemoryIdentityStore) ListIdentitiesByUserID(ctx context.Context, userID uuid.UUID) ([]core.Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var identities []core.Identity
+	for _, identity := range s.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+func (s *InMemoryIdentityStore) DeleteIdentity(ctx context.Context, userID uuid.UUID, identityID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if identity, exists := s.identities[identityID]; !exists || identity.UserID != userID {
+		return core.ErrNotFound
+	}
+	delete(s.identities, identityID)
+	return nil
+}
+
+// --- Minimal Mock authorization server storage ---
+// Implements core.OAuthClientStorer, core.OAuthServerStorer, core.RefreshTokenStorer
+// and core.DeviceAuthorizationStorer.
+type InMemoryOAuthServerStore struct {
+	mu            sync.Mutex
+	clients       map[string]core.OAuthClient
+	codes         map[string]core.AuthorizationCode
+	consents      map[string]core.OAuthConsent // userID:clientID -> consent
+	refreshTokens map[string]core.RefreshToken
+	devices       map[string]core.DeviceAuthorization // device code hash -> authorization
+	userCodeTries map[uuid.UUID]userCodeWindow
+}
+
+type userCodeWindow struct {
+	attempts int
+	resetAt  time.Time
+}
+
+func NewInMemoryOAuthServerStore() *InMemoryOAuthServerStore {
+	return &InMemoryOAuthServerStore{
+		clients:       make(map[string]core.OAuthClient),
+		codes:         make(map[string]core.AuthorizationCode),
+		consents:      make(map[string]core.OAuthConsent),
+		refreshTokens: make(map[string]core.RefreshToken),
+		devices:       make(map[string]core.DeviceAuthorization),
+		userCodeTries: make(map[uuid.UUID]userCodeWindow),
+	}
+}
+
+func (s *InMemoryOAuthServerStore) CreateOAuthClient(ctx context.Context, client core.OAuthClient) (core.OAuthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[client.ClientID] = client
+	return client, nil
+}
+func (s *InMemoryOAuthServerStore) GetOAuthClient(ctx context.Context, clientID string) (core.OAuthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, exists := s.clients[clientID]
+	if !exists {
+		return core.OAuthClient{}, core.ErrNotFound
+	}
+	return client, nil
+}
+func (s *InMemoryOAuthServerStore) ListOAuthClients(ctx context.Context) ([]core.OAuthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clients := make([]core.OAuthClient, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+func (s *InMemoryOAuthServerStore) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.clients, clientID)
+	return nil
+}
+func (s *InMemoryOAuthServerStore) StoreAuthorizationCode(ctx context.Context, code core.AuthorizationCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[code.CodeHash] = code
+	return nil
+}
+func (s *InMemoryOAuthServerStore) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (core.AuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, exists := s.codes[codeHash]
+	if !exists {
+		return core.AuthorizationCode{}, core.ErrNotFound
+	}
+	delete(s.codes, codeHash)
+	return code, nil
+}
+func (s *InMemoryOAuthServerStore) GetConsent(ctx context.Context, userID uuid.UUID, clientID string) (core.OAuthConsent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	consent, exists := s.consents[userID.String()+":"+clientID]
+	if !exists {
+		return core.OAuthConsent{}, core.ErrNotFound
+	}
+	return consent, nil
+}
+func (s *InMemoryOAuthServerStore) SaveConsent(ctx context.Context, consent core.OAuthConsent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consents[consent.UserID.String()+":"+consent.ClientID] = consent
+	return nil
+}
+func (s *InMemoryOAuthServerStore) StoreRefreshToken(ctx context.Context, token core.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshTokens[token.TokenHash] = token
+	return nil
+}
+func (s *InMemoryOAuthServerStore) GetRefreshToken(ctx context.Context, tokenHash string) (core.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, exists := s.refreshTokens[tokenHash]
+	if !exists {
+		return core.RefreshToken{}, core.ErrNotFound
+	}
+	return token, nil
+}
+func (s *InMemoryOAuthServerStore) ConsumeRefreshToken(ctx context.Context, tokenHash string) (core.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, exists := s.refreshTokens[tokenHash]
+	if !exists {
+		return core.RefreshToken{}, core.ErrNotFound
+	}
+	delete(s.refreshTokens, tokenHash)
+	return token, nil
+}
+func (s *InMemoryOAuthServerStore) DeleteRefreshTokensByUserID(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.refreshTokens {
+		if token.UserID == userID {
+			delete(s.refreshTokens, hash)
+		}
+	}
+	return nil
+}
+func (s *InMemoryOAuthServerStore) DeleteRefreshTokensByClient(ctx context.Context, userID uuid.UUID, clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.refreshTokens {
+		if token.UserID == userID && token.ClientID == clientID {
+			delete(s.refreshTokens, hash)
+		}
+	}
+	return nil
+}
+func (s *InMemoryOAuthServerStore) StoreDeviceAuthorization(ctx context.Context, auth core.DeviceAuthorization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.devices[auth.DeviceCodeHash] = auth
+	return nil
+}
+func (s *InMemoryOAuthServerStore) GetDeviceAuthorizationByDeviceCode(ctx context.Context, deviceCodeHash string) (core.DeviceAuthorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	auth, exists := s.devices[deviceCodeHash]
+	if !exists {
+		return core.DeviceAuthorization{}, core.ErrNotFound
+	}
+	return auth, nil
+}
+func (s *InMemoryOAuthServerStore) GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (core.DeviceAuthorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, auth := range s.devices {
+		if auth.UserCode == userCode {
+			return auth, nil
+		}
+	}
+	return core.DeviceAuthorization{}, core.ErrNotFound
+}
+func (s *InMemoryOAuthServerStore) UpdateDeviceAuthorization(ctx context.Context, auth core.DeviceAuthorization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.devices[auth.DeviceCodeHash]; !exists {
+		return core.ErrNotFound
+	}
+	s.devices[auth.DeviceCodeHash] = auth
+	return nil
+}
+func (s *InMemoryOAuthServerStore) TouchDeviceAuthorization(ctx context.Context, deviceCodeHash string, polledAt time.Time, interval time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	auth, exists := s.devices[deviceCodeHash]
+	if !exists {
+		return core.ErrNotFound
+	}
+	auth.LastPolledAt = polledAt
+	auth.Interval = interval
+	s.devices[deviceCodeHash] = auth
+	return nil
+}
+func (s *InMemoryOAuthServerStore) DeleteDeviceAuthorization(ctx context.Context, deviceCodeHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.devices, deviceCodeHash)
+	return nil
+}
+func (s *InMemoryOAuthServerStore) ConsumeDeviceAuthorization(ctx context.Context, deviceCodeHash string) (core.DeviceAuthorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	auth, exists := s.devices[deviceCodeHash]
+	if !exists {
+		return core.DeviceAuthorization{}, core.ErrNotFound
+	}
+	delete(s.devices, deviceCodeHash)
+	return auth, nil
+}
+func (s *InMemoryOAuthServerStore) CountUserCodeAttempt(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.userCodeTries[userID]
+	if now := time.Now(); !now.Before(w.resetAt) {
+		w = userCodeWindow{resetAt: now.Add(window)}
+	}
+	w.attempts++
+	s.userCodeTries[userID] = w
+	return w.attempts, nil
+}
+
+// --- Minimal Mock token revocation list ---
+type InMemoryRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[uuid.UUID]time.Time // token ID -> token expiry
+}
+
+func NewInMemoryRevocationStore() *InMemoryRevocationStore {
+	return &InMemoryRevocationStore{revoked: make(map[uuid.UUID]time.Time)}
+}
+
+func (s *InMemoryRevocationStore) RevokeToken(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[tokenID] = expiresAt
+	return nil
+}
+func (s *InMemoryRevocationStore) IsTokenRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, revoked := s.revoked[tokenID]
+	return revoked, nil
+}
+
+// --- Minimal Mock OrganizationStorer ---
+type InMemoryOrganizationStore struct {
+	mu          sync.Mutex
+	orgs        map[uuid.UUID]core.Organization
+	memberships map[[2]uuid.UUID]core.Membership // {org ID, user ID} -> membership
+}
+
+func NewInMemoryOrganizationStore() *InMemoryOrganizationStore {
+	return &InMemoryOrganizationStore{
+		orgs:        make(map[uuid.UUID]core.Organization),
+		memberships: make(map[[2]uuid.UUID]core.Membership),
+	}
+}
+
+func (s *InMemoryOrganizationStore) CreateOrganization(ctx context.Context, org core.Organization) (core.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgs[org.ID] = org
+	return org, nil
+}
+func (s *InMemoryOrganizationStore) GetOrganization(ctx context.Context, id uuid.UUID) (core.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org, ok := s.orgs[id]
+	if !ok {
+		return core.Organization{}, core.ErrNotFound
+	}
+	return org, nil
+}
+func (s *InMemoryOrganizationStore) AddMember(ctx context.Context, membership core.Membership) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := [2]uuid.UUID{membership.OrganizationID, membership.UserID}
+	if _, exists := s.memberships[key]; exists {
+		return core.ErrAlreadyMember
+	}
+	s.memberships[key] = membership
+	return nil
+}
+func (s *InMemoryOrganizationStore) UpdateMember(ctx context.Context, membership core.Membership) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := [2]uuid.UUID{membership.OrganizationID, membership.UserID}
+	if _, exists := s.memberships[key]; !exists {
+		return core.ErrNotFound
+	}
+	s.memberships[key] = membership
+	return nil
+}
+func (s *InMemoryOrganizationStore) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.memberships, [2]uuid.UUID{orgID, userID})
+	return nil
+}
+func (s *InMemoryOrganizationStore) GetMembership(ctx context.Context, orgID, userID uuid.UUID) (core.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	membership, ok := s.memberships[[2]uuid.UUID{orgID, userID}]
+	if !ok {
+		return core.Membership{}, core.ErrNotFound
+	}
+	return membership, nil
+}
+func (s *InMemoryOrganizationStore) ListMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]core.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var memberships []core.Membership
+	for _, membership := range s.memberships {
+		if membership.UserID == userID {
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships, nil
+}
+func (s *InMemoryOrganizationStore) ListMembers(ctx context.Context, orgID uuid.UUID) ([]core.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var memberships []core.Membership
+	for _, membership := range s.memberships {
+		if membership.OrganizationID == orgID {
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships, nil
+}
+
+// --- Minimal Mock InvitationStorer ---
+type InMemoryInvitationStore struct {
+	mu          sync.Mutex
+	invitations map[uuid.UUID]core.Invitation
+}
+
+func NewInMemoryInvitationStore() *InMemoryInvitationStore {
+	return &InMemoryInvitationStore{invitations: make(map[uuid.UUID]core.Invitation)}
+}
+
+func (s *InMemoryInvitationStore) CreateInvitation(ctx context.Context, invitation core.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invitations[invitation.ID] = invitation
+	return nil
+}
+func (s *InMemoryInvitationStore) GetInvitation(ctx context.Context, id uuid.UUID) (core.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invitation, ok := s.invitations[id]
+	if !ok {
+		return core.Invitation{}, core.ErrNotFound
+	}
+	return invitation, nil
+}
+func (s *InMemoryInvitationStore) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (core.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, invitation := range s.invitations {
+		if invitation.TokenHash == tokenHash {
+			return invitation, nil
+		}
+	}
+	return core.Invitation{}, core.ErrNotFound
+}
+func (s *InMemoryInvitationStore) UpdateInvitation(ctx context.Context, invitation core.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.invitations[invitation.ID]; !ok {
+		return core.ErrNotFound
+	}
+	s.invitations[invitation.ID] = invitation
+	return nil
+}
+func (s *InMemoryInvitationStore) ListInvitations(ctx context.Context, orgID uuid.UUID, status core.InvitationStatus) ([]core.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var invitations []core.Invitation
+	for _, invitation := range s.invitations {
+		if invitation.OrganizationID == orgID && invitation.Status == status {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+// --- Minimal Mock APIKeyStorer ---
+type InMemoryAPIKeyStore struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]core.APIKey
+}
+
+func NewInMemoryAPIKeyStore() *InMemoryAPIKeyStore {
+	return &InMemoryAPIKeyStore{keys: make(map[uuid.UUID]core.APIKey)}
+}
+
+func (s *InMemoryAPIKeyStore) CreateAPIKey(ctx context.Context, key core.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+	return nil
+}
+func (s *InMemoryAPIKeyStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (core.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.keys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+	return core.APIKey{}, core.ErrNotFound
+}
+func (s *InMemoryAPIKeyStore) ListAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]core.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []core.APIKey
+	for _, key := range s.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+func (s *InMemoryAPIKeyStore) DeleteAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.keys[keyID]; !ok || key.UserID != userID {
+		return core.ErrNotFound
+	}
+	delete(s.keys, keyID)
+	return nil
+}
+func (s *InMemoryAPIKeyStore) UpdateAPIKeyLastUsed(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.keys[keyID]; ok {
+		key.LastUsedAt = usedAt
+		s.keys[keyID] = key
+	}
+	return nil
+}
+
+// --- Minimal Mock signed request storage ---
+type InMemorySigningKeyStore struct {
+	keys map[string]core.SigningKey
+}
+
+func (s *InMemorySigningKeyStore) GetSigningKey(ctx context.Context, keyID string) (core.SigningKey, error) {
+	key, ok := s.keys[keyID]
+	if !ok {
+		return core.SigningKey{}, core.ErrNotFound
+	}
+	return key, nil
+}
+
+type InMemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+func NewInMemoryNonceStore() *InMemoryNonceStore {
+	return &InMemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (s *InMemoryNonceStore) UseNonce(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for n, exp := range s.nonces { // Forget expired nonces
+		if now.After(exp) {
+			delete(s.nonces, n)
+		}
+	}
+	if _, used := s.nonces[nonce]; used {
+		return false, nil
+	}
+	s.nonces[nonce] = expiresAt
+	return true, nil
+}
+
+// --- Minimal Mock audit log ---
+type LogAuditLogger struct{}
+
+func (l *LogAuditLogger) LogAuditEvent(ctx context.Context, event core.AuditEvent) error {
+	log.Printf("--- AUDIT: %s actor=%s subject=%s client=%q reason=%q ip=%s ---", event.Type, event.ActorID, event.SubjectID, event.ClientID, event.Reason, event.IPAddress)
+	return nil
+}
+
 // --- Minimal Mock EmailSender ---
 type MockEmailSender struct{}
 
@@ -161,6 +826,22 @@ func (m *MockEmailSender) SendPasswordResetEmail(ctx context.Context, toEmail, u
 	log.Printf("MOCK EMAIL: To: %s, User: %s, Reset Link: %s\n", toEmail, username, resetLink)
 	return nil
 }
+func (m *MockEmailSender) SendInvitationEmail(ctx context.Context, toEmail, organizationName, inviterName, invitationLink string) error {
+	log.Printf("MOCK EMAIL: To: %s, %s invited you to %s: %s\n", toEmail, inviterName, organizationName, invitationLink)
+	return nil
+}
+
+// --- Minimal Mock OTP transports ---
+type MockMessageTransport struct{}
+
+func (m *MockMessageTransport) SendEmail(ctx context.Context, toEmail, subject, body string) error {
+	log.Printf("MOCK EMAIL: To: %s, Subject: %s\n%s", toEmail, subject, body)
+	return nil
+}
+func (m *MockMessageTransport) SendSMS(ctx context.Context, toPhoneNumber, message string) error {
+	log.Printf("MOCK SMS: To: %s, Message: %s\n", toPhoneNumber, message)
+	return nil
+}
 
 func main() {
 	log.Println("Starting go-authkit example...")
@@ -173,6 +854,14 @@ func main() {
 		sdkConfig.TokenSymmetricKey = "12345678901234567890123456789012" // 32 bytes
 	}
 	sdkConfig.AppBaseURL = "http://localhost:8080" // For email links
+	sdkConfig.TokenIssuer = sdkConfig.AppBaseURL
+	sdkConfig.TokenAudience = []string{"example-api"}
+	sdkConfig.CookieInsecure = true // The example is served over plain HTTP
+	sdkConfig.Roles = []core.Role{
+		{Name: "user", Permissions: []string{"invoices:read"}},
+		{Name: "editor", Permissions: []string{"invoices:write"}, Inherits: []string{"user"}},
+		{Name: "admin", Permissions: []string{"*"}, Inherits: []string{"editor"}},
+	}
 
 	// 2. SDK Components
 	tokenMaker, err := token.NewPasetoMaker(sdkConfig.TokenSymmetricKey)
@@ -180,28 +869,208 @@ func main() {
 		log.Fatalf("TokenMaker error: %v", err)
 	}
 	passwordHasher := hash.NewBcryptHasher(0)
+	rolePolicy, err := rbac.NewPolicy(sdkConfig.Roles)
+	if err != nil {
+		log.Fatalf("Role definitions error: %v", err)
+	}
+	documentPolicies, err := authz.NewEngine(
+		authz.Policy{Name: "admins-manage-documents", Effect: authz.Allow, Actions: []string{authz.Any}, Resources: []string{"document"}, Roles: []string{"admin"}},
+		authz.Policy{Name: "owners-edit-documents", Effect: authz.Allow, Actions: []string{"read", "edit"}, Resources: []string{"document"},
+			Condition: authz.MustParseCondition("resource.owner_id == subject.id")},
+	)
+	if err != nil {
+		log.Fatalf("Document policies error: %v", err)
+	}
+	var documentOwners sync.Map // Document ID -> owner, for the authz example routes
 
 	// 3. Mock Implementations
 	userStore := NewInMemoryUserStore()
 	emailSender := &MockEmailSender{}
+	otpStore := NewInMemoryOTPStore()
+	messageTransport := &MockMessageTransport{}
+	otpSenders := map[core.OTPChannel]core.OTPSender{
+		core.OTPChannelEmail: otp.NewEmailSender(messageTransport, "go-authkit example"),
+		core.OTPChannelSMS:   otp.NewSMSSender(messageTransport, "go-authkit example"),
+	}
+
+	// Social login providers are only registered when their credentials are set
+	oauthProviders := oauth.NewRegistry()
+	if clientID := os.Getenv("GOOGLE_CLIENT_ID"); clientID != "" {
+		oauthProviders.Register(oauth.NewGoogle(oauth.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+			RedirectURL:  sdkConfig.AppBaseURL + "/auth/oauth/google/callback",
+		}))
+	}
+	if clientID := os.Getenv("GITHUB_CLIENT_ID"); clientID != "" {
+		oauthProviders.Register(oauth.NewGitHub(oauth.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
+			RedirectURL:  sdkConfig.AppBaseURL + "/auth/oauth/github/callback",
+		}))
+	}
+
+	// Enterprise IdPs (Okta, Keycloak, Azure AD, ...) through OpenID Connect discovery
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		err := oidc.Register(context.Background(), oauthProviders, oidc.Config{
+			Name:         "sso",
+			Issuer:       issuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  sdkConfig.AppBaseURL + "/auth/oauth/sso/callback",
+		})
+		if err != nil {
+			log.Fatalf("OIDC provider error: %v", err)
+		}
+	}
+
+	oauthServerStore := NewInMemoryOAuthServerStore()
+	revocationStore := NewInMemoryRevocationStore()
+	organizationStore := NewInMemoryOrganizationStore()
+	apiKeyStore := NewInMemoryAPIKeyStore()
+	nonceStore := NewInMemoryNonceStore()
+	// Callers sign with signing.Transport{KeyID: "billing", Secret: ...}
+	signingKeyStore := &InMemorySigningKeyStore{keys: map[string]core.SigningKey{
+		"billing": {ID: "billing", Secret: []byte(os.Getenv("BILLING_WEBHOOK_SECRET")), CreatedAt: time.Now()},
+	}}
+
+	// OpenID provider signing key. A real deployment loads a persistent key instead.
+	idTokenKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatalf("ID token key error: %v", err)
+	}
+	idTokenSigner, err := oauthserver.NewIDTokenSigner(idTokenKey, "example-key-1")
+	if err != nil {
+		log.Fatalf("ID token signer error: %v", err)
+	}
+	// Client certificates are forwarded by a TLS-terminating proxy on the same host.
+	// Behind a real proxy, list its addresses and set Roots to the private CA.
+	certSource := mtls.Source{Header: "X-Client-Cert", TrustedProxies: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}}
+
+	openIDConfig := oauthserver.OpenIDConfig{
+		Issuer:                sdkConfig.AppBaseURL,
+		AuthorizationEndpoint: sdkConfig.AppBaseURL + "/consent", // Frontend page calling /api/oauth2/authorize
+		// The other endpoints are the routes mounted by RegisterRoutes
+
+		CertificateBoundAccessTokens: true,
+		DPoP:                         true,
+	}
 
 	// 4. SDK Gin Handler
-	authAPI := ginhandler.NewAuthGinHandler(userStore, tokenMaker, passwordHasher, emailSender, sdkConfig)
+	authAPI := ginhandler.NewAuthGinHandler(userStore, tokenMaker, passwordHasher, emailSender, sdkConfig,
+		ginhandler.WithOTP(otpStore, otpSenders),
+		ginhandler.WithOAuth(oauthProviders, NewInMemoryOAuthStateStore()),
+		ginhandler.WithIdentities(NewInMemoryIdentityStore()),
+		ginhandler.WithAuthorizationServer(oauthServerStore, oauthServerStore, oauthServerStore),
+		ginhandler.WithOpenIDProvider(idTokenSigner, openIDConfig),
+		ginhandler.WithDeviceAuthorization(oauthServerStore),
+		ginhandler.WithTokenRevocation(revocationStore),
+		ginhandler.WithAuditLog(&LogAuditLogger{}),
+		ginhandler.WithOrganizations(organizationStore),
+		ginhandler.WithInvitations(NewInMemoryInvitationStore()),
+		ginhandler.WithAPIKeys(apiKeyStore),
+		ginhandler.WithServiceAccounts(userStore),
+		ginhandler.WithClientCertificates(certSource),
+		ginhandler.WithDPoP(nonceStore),
+		ginhandler.WithSessionCookies(oauthServerStore),
+		ginhandler.WithClaimsEnricher(func(ctx context.Context, user core.User) (map[string]interface{}, error) {
+			// App-specific claims, read in handlers with e.g. ginhandler.GetClaimBool(c, "beta")
+			return map[string]interface{}{"beta": strings.HasSuffix(user.Email, "@example.com")}, nil
+		}),
+	)
 
-	// 5. Gin Router
+	// 5. Gin Router. RegisterRoutes mounts the handlers of every feature enabled above at
+	// ginhandler.DefaultRoutePaths(), behind an AuthMiddleware with the matching checks.
 	router := gin.Default()
-	authRoutes := router.Group("/auth")
+	authAPI.RegisterRoutes(&router.RouterGroup, ginhandler.RouteOptions{})
+
+	// Application routes
+	protectedRoutes := router.Group("/api")
+	protectedRoutes.Use(ginhandler.AuthMiddleware(tokenMaker, userStore, sdkConfig, ginhandler.CheckRevocation(revocationStore), ginhandler.CheckMembership(organizationStore), ginhandler.CheckClients(oauthServerStore), ginhandler.AcceptAPIKeys(apiKeyStore), ginhandler.ClientCertificates(certSource), ginhandler.AcceptDPoP(nonceStore)))
 	{
-		// Using SDK's provided handlers
-		authRoutes.POST("/register", authAPI.RegisterUser)
-		authRoutes.POST("/login", authAPI.LoginUser)
-		authRoutes.GET("/verify-email", authAPI.VerifyEmailHandler) // ?token=...
+		protectedRoutes.GET("/reports", ginhandler.ScopeMiddleware("reports:read"), func(c *gin.Context) {
+			ginhandler.RespondWithSuccess(c, http.StatusOK, gin.H{"reports": []string{}}) // API keys need the "reports:read" scope
+		})
+		protectedRoutes.PUT("/organization/settings", ginhandler.RoleMiddleware(sdkConfig.OrganizationOwnerRole), func(c *gin.Context) {
+			payload, _ := ginhandler.GetAuthPayload(c) // Scoped to the owner's current organization
+			ginhandler.RespondWithSuccess(c, http.StatusOK, gin.H{"organization_id": payload.TenantID})
+		})
+		protectedRoutes.POST("/invoices", ginhandler.RequirePermission(rolePolicy, "invoices:write"), func(c *gin.Context) {
+			ginhandler.RespondWithSuccess(c, http.StatusCreated, gin.H{"created": true})
+		})
 	}
 
-	protectedRoutes := router.Group("/api")
-	protectedRoutes.Use(ginhandler.AuthMiddleware(tokenMaker, userStore, sdkConfig))
+	documentRoutes := protectedRoutes.Group("/documents", ginhandler.AuthzMiddleware(documentPolicies, nil))
+	{
+		documentRoutes.POST("", func(c *gin.Context) {
+			payload, _ := ginhandler.GetAuthPayload(c)
+			id := uuid.NewString()
+			documentOwners.Store(id, payload.UserID)
+			ginhandler.RespondWithSuccess(c, http.StatusCreated, gin.H{"id": id})
+		})
+		documentRoutes.PUT("/:id", func(c *gin.Context) {
+			owner, ok := documentOwners.Load(c.Param("id"))
+			if !ok {
+				ginhandler.MapSDKErrorToHTTP(c, core.ErrNotFound)
+				return
+			}
+			document := authz.Resource{Type: "document", ID: c.Param("id"), Attributes: map[string]interface{}{"owner_id": owner}}
+			if _, err := ginhandler.Authorize(c, "edit", document); err != nil {
+				ginhandler.MapSDKErrorToHTTP(c, err)
+				return
+			}
+			ginhandler.RespondWithSuccess(c, http.StatusOK, gin.H{"updated": true})
+		})
+	}
+
+	// The same flows for net/http services (a plain ServeMux here; chi works the same way)
+	authService := service.New(userStore, tokenMaker, passwordHasher, emailSender, sdkConfig)
+	authenticator := service.NewAuthenticator(tokenMaker, userStore, sdkConfig)
+	authenticator.Revocations = revocationStore
+	stdHandler := httphandler.NewHandler(authService)
+	stdMux := http.NewServeMux()
+	stdMux.HandleFunc("POST /register", stdHandler.Register)
+	stdMux.HandleFunc("POST /login", stdHandler.Login)
+	stdMux.HandleFunc("GET /verify-email", stdHandler.VerifyEmail)
+	stdMux.Handle("GET /me", httphandler.Middleware(authenticator)(http.HandlerFunc(stdHandler.UserInfo)))
+	stdMux.Handle("GET /admin/ping", httphandler.Middleware(authenticator)(httphandler.RequireRole(sdkConfig.AdminRole)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httphandler.RespondWithSuccess(w, http.StatusOK, gin.H{"pong": true})
+	}))))
+	router.Any("/std/*path", gin.WrapH(http.StripPrefix("/std", stdMux)))
+
+	// Browser frontend: tokens travel in HttpOnly cookies instead of the Authorization header
+	// (RegisterRoutes mounts /web/auth/login, /web/auth/refresh and /web/auth/logout, and
+	// the user's routes such as /api/me accept the session cookie too)
+	webRoutes := router.Group("/web", ginhandler.CookieTransport())
+	webAPIRoutes := webRoutes.Group("/api", ginhandler.AuthMiddleware(tokenMaker, userStore, sdkConfig, ginhandler.CheckRevocation(revocationStore), ginhandler.CheckMembership(organizationStore)))
+	{
+		webAPIRoutes.POST("/invoices", ginhandler.RequirePermission(rolePolicy, "invoices:write"), func(c *gin.Context) {
+			ginhandler.RespondWithSuccess(c, http.StatusCreated, gin.H{"created": true})
+		})
+	}
+
+	// Internal callers present certificates whose SAN or common name is a service account name
+	internalRoutes := router.Group("/internal")
+	internalRoutes.Use(ginhandler.ClientCertMiddleware(certSource, mtls.IdentityMapper(userStore.GetUserByUsername)), ginhandler.RoleMiddleware(sdkConfig.ServiceAccountRole))
+	{
+		internalRoutes.GET("/whoami", func(c *gin.Context) {
+			payload, _ := ginhandler.GetAuthPayload(c)
+			ginhandler.RespondWithSuccess(c, http.StatusOK, gin.H{"user_id": payload.UserID, "username": payload.Username, "principal_type": payload.PrincipalType})
+		})
+	}
+
+	webhookRoutes := router.Group("/webhooks")
+	webhookRoutes.Use(ginhandler.SignatureMiddleware(signingKeyStore, nonceStore, sdkConfig))
 	{
-		protectedRoutes.GET("/me", authAPI.UserInfoHandler)
+		webhookRoutes.POST("/billing", func(c *gin.Context) {
+			key, _ := ginhandler.GetSigningKey(c)
+			var event map[string]interface{}
+			if err := c.ShouldBindJSON(&event); err != nil {
+				ginhandler.RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+				return
+			}
+			ginhandler.RespondWithSuccess(c, http.StatusOK, gin.H{"received_from": key.ID, "event": event})
+		})
 	}
 
 	log.Println("Example server running on :8080")
@@ -0,0 +1,26 @@
+package fiberhandler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"github.com/shawgichan/go-authkit/authtest"
+	"github.com/shawgichan/go-authkit/fiberhandler"
+)
+
+func TestAdapter(t *testing.T) {
+	authtest.Run(t, func(t *testing.T, deps authtest.Deps) http.Handler {
+		h := fiberhandler.NewAuthFiberHandler(deps.Auth)
+		auth := fiberhandler.AuthMiddleware(deps.Authenticator)
+		app := fiber.New()
+		app.Post(authtest.RegisterPath, h.RegisterUser)
+		app.Post(authtest.LoginPath, h.LoginUser)
+		app.Get(authtest.VerifyEmailPath, h.VerifyEmailHandler)
+		app.Get(authtest.UserInfoPath, auth, h.UserInfoHandler)
+		app.Get(authtest.AdminPath, auth, fiberhandler.RoleMiddleware("admin"), h.UserInfoHandler)
+		return adaptor.FiberApp(app) // The suite drives net/http handlers
+	})
+}
@@ -0,0 +1,117 @@
+// Package fiberhandler serves the authentication service over Fiber. Handlers and
+// middleware have the same routes, error codes and JSON envelope as ginhandler and
+// httphandler; the verified token payload is read with GetAuthPayload.
+//
+// Fiber reuses its contexts between requests, so values read from them must not be
+// kept after the handler returns.
+package fiberhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/shawgichan/go-authkit/httphandler"
+	"github.com/shawgichan/go-authkit/service"
+)
+
+// Request and response bodies shared with the other adapters.
+type (
+	RegisterRequest = httphandler.RegisterRequest
+	LoginRequest    = httphandler.LoginRequest
+	UserResponse    = httphandler.UserResponse
+	TokenResponse   = httphandler.TokenResponse
+	MessageResponse = httphandler.MessageResponse
+)
+
+// AuthFiberHandler serves the register, login, email verification and user info routes.
+type AuthFiberHandler struct {
+	auth *service.Auth
+}
+
+// NewAuthFiberHandler creates the handlers for auth.
+func NewAuthFiberHandler(auth *service.Auth) *AuthFiberHandler {
+	return &AuthFiberHandler{auth: auth}
+}
+
+// RegisterUser handles user registration and sends the verification link.
+func (h *AuthFiberHandler) RegisterUser(c *fiber.Ctx) error {
+	var req RegisterRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+	}
+
+	ctx := c.UserContext()
+	user, err := h.auth.Register(ctx, service.RegisterParams{
+		Email:       req.Email,
+		Password:    req.Password,
+		FullName:    req.FullName,
+		PhoneNumber: req.PhoneNumber,
+	})
+	if err != nil {
+		return MapSDKErrorToHTTP(c, err)
+	}
+	if err := h.auth.SendVerificationLink(ctx, user); err != nil {
+		// Registration succeeds even if the link could not be sent
+		fmt.Printf("Warning: Failed to send verification link to %s: %v\n", user.Email, err)
+	}
+	return RespondWithSuccess(c, http.StatusCreated, httphandler.NewSDKUserResponse(user))
+}
+
+// LoginUser handles password login and responds with an access token.
+func (h *AuthFiberHandler) LoginUser(c *fiber.Ctx) error {
+	var req LoginRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+	}
+
+	ctx := c.UserContext()
+	user, err := h.auth.Login(ctx, req.Email, req.Password)
+	if err != nil {
+		return MapSDKErrorToHTTP(c, err)
+	}
+	accessToken, payload, err := h.auth.IssueLoginToken(ctx, user)
+	if err != nil {
+		return MapSDKErrorToHTTP(c, err)
+	}
+	return RespondWithSuccess(c, http.StatusOK, TokenResponse{
+		AccessToken:    accessToken,
+		User:           httphandler.NewSDKUserResponse(user),
+		ExpiresAt:      payload.ExpiredAt,
+		OrganizationID: payload.TenantID,
+	})
+}
+
+// VerifyEmailHandler handles the email verification link (?token=...).
+func (h *AuthFiberHandler) VerifyEmailHandler(c *fiber.Ctx) error {
+	verificationToken := c.Query("token")
+	if verificationToken == "" {
+		return RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_QUERY", "Invalid query parameters", "token is required")
+	}
+	user, err := h.auth.VerifyEmail(c.UserContext(), verificationToken)
+	if err != nil {
+		return MapSDKErrorToHTTP(c, err) // Handles ErrVerificationNotFound
+	}
+	return RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: fmt.Sprintf("Email for %s successfully verified.", user.Email)})
+}
+
+// UserInfoHandler responds with the authenticated user. It must run behind AuthMiddleware.
+func (h *AuthFiberHandler) UserInfoHandler(c *fiber.Ctx) error {
+	payload, exists := GetAuthPayload(c)
+	if !exists {
+		return RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization payload not found", nil)
+	}
+	user, err := h.auth.GetUser(c.UserContext(), payload.UserID)
+	if err != nil {
+		return MapSDKErrorToHTTP(c, err)
+	}
+	return RespondWithSuccess(c, http.StatusOK, httphandler.NewSDKUserResponse(user))
+}
@@ -0,0 +1,72 @@
+package fiberhandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// AuthorizationPayloadKey is the Fiber Locals key of the verified token payload.
+const AuthorizationPayloadKey = "authorization_payload"
+
+// AuthMiddleware authenticates requests with "Authorization: Bearer <token>", and the
+// ApiKey and DPoP schemes if authenticator accepts them. It verifies the credential,
+// checks sender-constrained tokens and the user's status and active session (see
+// service.Authenticator.AuthenticateRequest), then stores the payload in Locals and the
+// user context (see token.FromContext).
+func AuthMiddleware(authenticator *service.Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		r, err := adaptor.ConvertRequest(c, false)
+		if err != nil {
+			return MapSDKErrorToHTTP(c, err)
+		}
+		payload, err := authenticator.AuthenticateRequest(r.WithContext(c.UserContext()), false)
+		if err != nil {
+			if errors.Is(err, core.ErrDPoPProofInvalid) {
+				c.Set(fiber.HeaderWWWAuthenticate, `DPoP error="invalid_dpop_proof"`)
+			}
+			return MapSDKErrorToHTTP(c, err)
+		}
+		c.Locals(AuthorizationPayloadKey, payload)
+		c.SetUserContext(token.NewContext(c.UserContext(), payload))
+		return c.Next()
+	}
+}
+
+// RoleMiddleware passes requests whose user holds any of the allowed roles, in the
+// token's organization if it is scoped to one. It must run behind AuthMiddleware.
+func RoleMiddleware(allowedRoles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		payload, exists := GetAuthPayload(c)
+		if !exists {
+			return RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization payload not found for role check", nil)
+		}
+		roles := payload.EffectiveRoles()
+		for _, allowed := range allowedRoles {
+			for _, role := range roles {
+				if strings.EqualFold(role, allowed) {
+					return c.Next()
+				}
+			}
+		}
+		errDetails := fmt.Sprintf("Access denied. Your roles %v are not in allowed roles: %v", roles, allowedRoles)
+		return RespondWithError(c, http.StatusForbidden, "FORBIDDEN", "You do not have permission to access this resource", errDetails)
+	}
+}
+
+// GetAuthPayload retrieves the token payload set by AuthMiddleware.
+func GetAuthPayload(c *fiber.Ctx) (*token.Payload, bool) {
+	payload, ok := c.Locals(AuthorizationPayloadKey).(*token.Payload)
+	if !ok {
+		return token.FromContext(c.UserContext())
+	}
+	return payload, true
+}
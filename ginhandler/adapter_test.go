@@ -0,0 +1,23 @@
+package ginhandler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shawgichan/go-authkit/authtest"
+	"github.com/shawgichan/go-authkit/ginhandler"
+)
+
+func TestAdapter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authtest.Run(t, func(t *testing.T, deps authtest.Deps) http.Handler {
+		h := ginhandler.NewAuthGinHandler(deps.Store, deps.TokenMaker, deps.Hasher, deps.Mailer, deps.Config)
+		router := gin.New()
+		h.RegisterRoutes(&router.RouterGroup, ginhandler.RouteOptions{}) // Default paths match the suite's
+		router.GET(authtest.AdminPath,
+			ginhandler.AuthMiddleware(deps.TokenMaker, deps.Store, deps.Config), ginhandler.RoleMiddleware("admin"), h.UserInfoHandler)
+		return router
+	})
+}
@@ -0,0 +1,173 @@
+package ginhandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// apiKeyHintLength is the number of secret characters kept in an API key's hint.
+const apiKeyHintLength = 6
+
+// CreateAPIKeyHandler creates an API key for the authenticated user. The key is only
+// returned in this response. A key created while the token is scoped to an organization
+// is scoped to it too. Scopes must be within AuthConfig.APIKeyScopes, if set, and the
+// expiry within AuthConfig.APIKeyMaxDuration. A key has none of the user's roles unless a
+// scope grants them (see token.RoleScope). Only login sessions may create keys.
+func (h *AuthGinHandler) CreateAPIKeyHandler(c *gin.Context) {
+	if h.apiKeys == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "API keys are not enabled", nil)
+		return
+	}
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	authPayload, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	h.createAPIKey(c, req, authPayload.UserID, authPayload.TenantID)
+}
+
+// createAPIKey creates an API key for ownerID, scoped to tenantID if set, and writes the response.
+func (h *AuthGinHandler) createAPIKey(c *gin.Context, req CreateAPIKeyRequest, ownerID uuid.UUID, tenantID string) {
+	if len(h.config.APIKeyScopes) > 0 && !oauthserver.ContainsAll(h.config.APIKeyScopes, req.Scopes) {
+		RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Unknown API key scope", h.config.APIKeyScopes)
+		return
+	}
+	now := time.Now()
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+		if !expiresAt.After(now) {
+			RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Expiry must be in the future", nil)
+			return
+		}
+	}
+	if maxDuration := h.config.APIKeyMaxDuration; maxDuration > 0 && (expiresAt.IsZero() || expiresAt.After(now.Add(maxDuration))) {
+		details := fmt.Sprintf("API keys must expire within %s", maxDuration)
+		RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "API key expiry is too far in the future", details)
+		return
+	}
+
+	if h.config.MaxAPIKeysPerUser > 0 {
+		keys, err := h.apiKeys.ListAPIKeysByUserID(c.Request.Context(), ownerID)
+		if err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		if len(keys) >= h.config.MaxAPIKeysPerUser {
+			MapSDKErrorToHTTP(c, core.ErrAPIKeyLimit)
+			return
+		}
+	}
+
+	secret, err := oauthserver.GenerateToken()
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	rawKey := h.config.APIKeyPrefix + "_" + secret
+	key := core.APIKey{
+		ID:        uuid.New(),
+		UserID:    ownerID,
+		TenantID:  tenantID,
+		Name:      req.Name,
+		Hint:      h.config.APIKeyPrefix + "_" + secret[:apiKeyHintLength],
+		KeyHash:   oauthserver.HashToken(rawKey),
+		Scopes:    req.Scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := h.apiKeys.CreateAPIKey(c.Request.Context(), key); err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to create api key: %w", err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	RespondWithSuccess(c, http.StatusCreated, CreateAPIKeyResponse{Key: rawKey, APIKeyResponse: NewAPIKeyResponse(key)})
+}
+
+// ListAPIKeysHandler lists the authenticated user's API keys, without the keys themselves.
+func (h *AuthGinHandler) ListAPIKeysHandler(c *gin.Context) {
+	if h.apiKeys == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "API keys are not enabled", nil)
+		return
+	}
+	authPayload, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	h.listAPIKeys(c, authPayload.UserID)
+}
+
+// listAPIKeys writes the API keys of ownerID.
+func (h *AuthGinHandler) listAPIKeys(c *gin.Context, ownerID uuid.UUID) {
+	keys, err := h.apiKeys.ListAPIKeysByUserID(c.Request.Context(), ownerID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	resp := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, NewAPIKeyResponse(key))
+	}
+	RespondWithSuccess(c, http.StatusOK, resp)
+}
+
+// RevokeAPIKeyHandler deletes one of the authenticated user's API keys (route parameter "id").
+// Requests made with it are rejected immediately.
+func (h *AuthGinHandler) RevokeAPIKeyHandler(c *gin.Context) {
+	if h.apiKeys == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "API keys are not enabled", nil)
+		return
+	}
+	authPayload, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	h.revokeAPIKey(c, authPayload.UserID, c.Param("id"))
+}
+
+// revokeAPIKey deletes the API key rawKeyID of ownerID.
+func (h *AuthGinHandler) revokeAPIKey(c *gin.Context, ownerID uuid.UUID, rawKeyID string) {
+	keyID, err := uuid.Parse(rawKeyID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, core.ErrNotFound)
+		return
+	}
+	if err := h.apiKeys.DeleteAPIKey(c.Request.Context(), ownerID, keyID); err != nil {
+		if !errors.Is(err, core.ErrNotFound) {
+			err = fmt.Errorf("failed to delete api key: %w", err)
+		}
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: "API key revoked."})
+}
+
+// loginSession returns the payload of the user's own login session, for handlers that
+// manage credentials or grant access. It responds with an error for API keys, client
+// certificates, tokens issued to OAuth2 clients and impersonated tokens.
+func loginSession(c *gin.Context) (*token.Payload, bool) {
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || !authPayload.IsLoginToken() {
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return nil, false
+	}
+	if authPayload.IsImpersonated() {
+		MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+		return nil, false
+	}
+	return authPayload, true
+}
@@ -1,10 +1,6 @@
 package ginhandler
 
 import (
-	"context"
-	"crypto/rand"
-	"encoding/hex"
-	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -12,20 +8,196 @@ import (
 	// "log" // For debugging, consider using a passed-in logger interface instead
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"github.com/shawgichan/go-authkit/config"
 	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/dpop"
 	"github.com/shawgichan/go-authkit/hash"
+	"github.com/shawgichan/go-authkit/mtls"
+	"github.com/shawgichan/go-authkit/oauth"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
 	"github.com/shawgichan/go-authkit/token"
 )
 
-// AuthGinHandler provides HTTP handlers for authentication routes.
+// AuthGinHandler provides HTTP handlers for authentication routes. The
+// framework-independent logic lives in the service package.
 type AuthGinHandler struct {
+	auth *service.Auth
+
 	store      core.UserStorer
 	tokenMaker token.Maker
 	hasher     hash.PasswordHasher
 	mailer     core.EmailSender // Can be nil if email features aren't used for certain endpoints
 	config     *config.AuthConfig
+
+	// Optional features, enabled through HandlerOption
+	otpStore   core.OTPStorer
+	otpSenders map[core.OTPChannel]core.OTPSender
+
+	oauthProviders *oauth.Registry
+	oauthStates    core.OAuthStateStorer
+	identities     core.IdentityStorer
+
+	oauthClients  core.OAuthClientStorer
+	oauthServer   core.OAuthServerStorer
+	refreshTokens core.RefreshTokenStorer
+
+	idTokenSigner *oauthserver.IDTokenSigner
+	openIDConfig  oauthserver.OpenIDConfig
+
+	deviceAuths core.DeviceAuthorizationStorer
+	// Unknown user codes entered per user, against guessing pending requests
+	// URLs of mounted routes, set by RegisterRoutes for the config fields left empty
+	deviceVerificationURI string
+	invitationURI         string
+	sessionCookiePath     string
+	revocations           core.TokenRevocationStorer
+	auditLog              core.AuditLogger
+
+	claimsEnricher ClaimsEnricher
+
+	organizations core.OrganizationStorer
+	invitations   core.InvitationStorer
+
+	apiKeys core.APIKeyStorer
+
+	serviceAccounts core.ServiceAccountStorer
+
+	clientCerts *mtls.Source
+	dpop        *dpop.Verifier
+	dpopNonces  core.NonceStorer
+
+	sessionTokens core.RefreshTokenStorer
+}
+
+// HandlerOption configures optional features of an AuthGinHandler.
+type HandlerOption func(*AuthGinHandler)
+
+// WithOTP enables one-time passcode login and verification.
+// senders maps each supported delivery channel to its sender.
+func WithOTP(store core.OTPStorer, senders map[core.OTPChannel]core.OTPSender) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.otpStore = store
+		h.otpSenders = senders
+	}
+}
+
+// WithOAuth enables social login through the given OAuth2 providers.
+func WithOAuth(providers *oauth.Registry, states core.OAuthStateStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.oauthProviders = providers
+		h.oauthStates = states
+	}
+}
+
+// WithIdentities enables linking several login identities to one user.
+// Social logins then match users by linked identity before falling back to verified email.
+func WithIdentities(store core.IdentityStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.identities = store
+	}
+}
+
+// WithAuthorizationServer enables the OAuth2 authorization server endpoints.
+// refreshTokens may be nil to disable the refresh_token grant.
+func WithAuthorizationServer(clients core.OAuthClientStorer, store core.OAuthServerStorer, refreshTokens core.RefreshTokenStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.oauthClients = clients
+		h.oauthServer = store
+		h.refreshTokens = refreshTokens
+	}
+}
+
+// WithOpenIDProvider turns the authorization server into an OpenID Connect provider:
+// ID tokens are issued for the "openid" scope and the discovery, JWKS, userinfo and
+// end-session handlers are enabled. It requires WithAuthorizationServer.
+func WithOpenIDProvider(signer *oauthserver.IDTokenSigner, cfg oauthserver.OpenIDConfig) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.idTokenSigner = signer
+		h.openIDConfig = cfg
+	}
+}
+
+// WithDeviceAuthorization enables the OAuth2 device authorization grant (RFC 8628)
+// for CLIs and input-constrained devices. It requires WithAuthorizationServer.
+func WithDeviceAuthorization(store core.DeviceAuthorizationStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.deviceAuths = store
+	}
+}
+
+// WithTokenRevocation enables revoking access tokens through RevokeHandler and makes
+// IntrospectHandler report revoked tokens as inactive. Pair it with the CheckRevocation
+// middleware option so revoked tokens are also rejected by AuthMiddleware.
+func WithTokenRevocation(store core.TokenRevocationStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.revocations = store
+	}
+}
+
+// WithAuditLog records security-relevant actions such as impersonation and token exchange.
+// Impersonation is only enabled with an audit log.
+func WithAuditLog(logger core.AuditLogger) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.auditLog = logger
+	}
+}
+
+// ClaimsEnricher returns application-specific claims for a user's access token, such as
+// a tenant ID or feature flags. It is called on every login and impersonation.
+// Returning an error fails the login.
+type ClaimsEnricher = service.ClaimsEnricher
+
+// WithClaimsEnricher sets the hook that adds application-specific claims to login tokens.
+// Handlers read them back with GetClaim and its typed variants.
+func WithClaimsEnricher(enricher ClaimsEnricher) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.claimsEnricher = enricher
+	}
+}
+
+// WithOrganizations enables multi-tenancy: users list the organizations they belong
+// to and switch their token to one of them, which scopes role checks to their roles
+// in that organization.
+func WithOrganizations(store core.OrganizationStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.organizations = store
+	}
+}
+
+// WithInvitations enables inviting people to organizations by email. It requires
+// WithOrganizations and a mailer.
+func WithInvitations(store core.InvitationStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.invitations = store
+	}
+}
+
+// WithAPIKeys enables API key management. Pair it with the AcceptAPIKeys middleware
+// option so AuthMiddleware accepts the keys.
+func WithAPIKeys(store core.APIKeyStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.apiKeys = store
+	}
+}
+
+// WithServiceAccounts enables the service account admin handlers. Service accounts
+// authenticate with API keys (see WithAPIKeys) or OAuth2 clients linked to them.
+func WithServiceAccounts(store core.ServiceAccountStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.serviceAccounts = store
+	}
+}
+
+// WithClientCertificates makes the token endpoint bind the access tokens it issues to the
+// client certificate the client connected with, if any (RFC 8705). AuthMiddleware then
+// only accepts them together with that certificate; pass it the same source with ClientCertificates.
+func WithClientCertificates(source mtls.Source) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.clientCerts = &source
+	}
 }
 
 // NewAuthGinHandler creates a new AuthGinHandler.
@@ -36,8 +208,9 @@ func NewAuthGinHandler(
 	mailer core.EmailSender, // mailer can be nil
 	cfg *config.AuthConfig,
 	// logger your_logger_interface,
+	opts ...HandlerOption,
 ) *AuthGinHandler {
-	return &AuthGinHandler{
+	h := &AuthGinHandler{
 		store:      store,
 		tokenMaker: tokenMaker,
 		hasher:     hasher,
@@ -45,16 +218,11 @@ func NewAuthGinHandler(
 		config:     cfg,
 		// logger:  logger,
 	}
-}
-
-// generateSecureToken is a helper for creating random tokens (e.g., for email verification)
-// It's good to have this internal to the handlers or in a util package within the SDK.
-func generateSecureTokenInternal(length int) (string, error) {
-	b := make([]byte, length)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+	for _, opt := range opts {
+		opt(h)
 	}
-	return hex.EncodeToString(b), nil
+	h.auth = service.New(store, tokenMaker, hasher, mailer, cfg, service.WithClaimsEnricher(h.claimsEnricher))
+	return h
 }
 
 // RegisterUser handles user registration.
@@ -65,81 +233,27 @@ func (h *AuthGinHandler) RegisterUser(c *gin.Context) {
 		return
 	}
 
-	// Basic validation (more can be added)
-	if len(req.Password) < 8 { // Example: Password length check (could be from h.config)
-		RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Password must be at least 8 characters long", nil)
-		return
-	}
-
-	// Check if user already exists
-	_, err := h.store.GetUserByEmail(c.Request.Context(), req.Email)
-	if err == nil { // User found, means email is taken
-		MapSDKErrorToHTTP(c, core.ErrDuplicateEmail)
-		return
-	}
-	if !errors.Is(err, core.ErrNotFound) { // Unexpected error during check
-		MapSDKErrorToHTTP(c, err)
-		return
-	}
-	// If core.ErrNotFound, proceed with registration
-
-	hashedPassword, err := h.hasher.Hash(req.Password)
-	if err != nil {
-		// h.logger.Error("Failed to hash password", "error", err)
-		MapSDKErrorToHTTP(c, fmt.Errorf("failed to hash password: %w", err)) // Wrap for more context
-		return
-	}
-
-	createUserParams := core.CreateUserParams{
-		Email:        req.Email,
-		Username:     req.Email, // Default username to email, or make configurable
-		PasswordHash: hashedPassword,
-		FullName:     req.FullName,
-		Role:         h.config.DefaultUserRole, // Assign default role from config
-		Status:       core.StatusPending,       // New users start as pending verification
-	}
-
-	createdUser, err := h.store.CreateUser(c.Request.Context(), createUserParams)
+	createdUser, err := h.auth.Register(c.Request.Context(), service.RegisterParams{
+		Email:       req.Email,
+		Password:    req.Password,
+		FullName:    req.FullName,
+		PhoneNumber: req.PhoneNumber,
+	})
 	if err != nil {
 		// h.logger.Error("Failed to create user", "error", err, "email", req.Email)
 		MapSDKErrorToHTTP(c, err) // Maps ErrDuplicateEmail etc.
 		return
 	}
 
-	// Send verification email
-	if h.mailer != nil {
-		verificationToken, err := generateSecureTokenInternal(16) // 16 bytes -> 32 hex chars
-		if err != nil {
-			// h.logger.Error("Failed to generate verification token", "error", err)
-			// Decide if registration fails or proceeds without email. For now, let it proceed but log.
-			fmt.Printf("Warning: Failed to generate verification token for %s: %v\n", createdUser.Email, err)
-		} else {
-			expiresAt := time.Now().Add(h.config.EmailVerificationTokenDuration)
-			err = h.store.StoreVerificationData(c.Request.Context(), createdUser.ID, createdUser.Email, verificationToken, expiresAt)
-			if err != nil {
-				// h.logger.Error("Failed to store verification data", "error", err, "user_id", createdUser.ID)
-				fmt.Printf("Warning: Failed to store verification data for %s: %v\n", createdUser.Email, err)
-				// Don't fail registration, but email verification might not work
-			} else {
-				// Construct verification link (using AppBaseURL from config)
-				// The token itself is what's important for the /verify-email handler.
-				// The link structure depends on how the frontend/API is set up.
-				// Common pattern: frontend URL with token, frontend calls backend /verify-email?token=...
-				// Or direct backend link:
-				verificationLink := fmt.Sprintf("%s/auth/verify-email?token=%s", h.config.AppBaseURL, verificationToken)
-
-				// Send email asynchronously to avoid blocking the request
-				go func(mailTo, userNm, link string) {
-					// Create a new context for the goroutine or pass one that's appropriate
-					bgCtx := context.Background() // Or context.TODO() if unsure
-					err := h.mailer.SendVerificationEmail(bgCtx, mailTo, userNm, link)
-					if err != nil {
-						// h.logger.Error("Failed to send verification email", "error", err, "email", mailTo)
-						fmt.Printf("Error sending verification email to %s: %v\n", mailTo, err)
-					}
-				}(createdUser.Email, createdUser.FullName, verificationLink)
-			}
+	// Send verification code or email. Registration succeeds even if this fails.
+	if h.config.UseOTPForEmailVerification && h.otpStore != nil {
+		if err := h.sendOTP(c.Request.Context(), createdUser, core.OTPChannelEmail, core.OTPPurposeEmailVerification); err != nil {
+			// h.logger.Error("Failed to send verification code", "error", err, "user_id", createdUser.ID)
+			fmt.Printf("Warning: Failed to send verification code to %s: %v\n", createdUser.Email, err)
 		}
+	} else if err := h.auth.SendVerificationLink(c.Request.Context(), createdUser); err != nil {
+		// h.logger.Error("Failed to send verification link", "error", err, "user_id", createdUser.ID)
+		fmt.Printf("Warning: Failed to send verification link to %s: %v\n", createdUser.Email, err)
 	}
 
 	RespondWithSuccess(c, http.StatusCreated, NewSDKUserResponse(createdUser))
@@ -153,60 +267,75 @@ func (h *AuthGinHandler) LoginUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.store.GetUserByEmail(c.Request.Context(), req.Email)
+	user, err := h.auth.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
-			MapSDKErrorToHTTP(c, core.ErrInvalidCredentials) // Generic error for non-existent user
-			return
-		}
 		MapSDKErrorToHTTP(c, err)
 		return
 	}
+	h.respondWithLoginToken(c, user)
+}
 
-	// Check user status before checking password
-	if user.Status == core.StatusPending {
-		MapSDKErrorToHTTP(c, core.ErrUserNotVerified)
-		return
-	}
-	if user.Status != core.StatusActive { // e.g. suspended
-		RespondWithError(c, http.StatusForbidden, "ACCOUNT_INACTIVE", "User account is not active", nil)
-		return
+// checkLoginStatus verifies that the user's account status allows logging in.
+// It writes the error response and returns false if it does not.
+func (h *AuthGinHandler) checkLoginStatus(c *gin.Context, user core.User) bool {
+	if err := service.CheckLoginStatus(user); err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return false
 	}
+	return true
+}
 
-	err = h.hasher.Check(user.PasswordHash, req.Password)
-	if err != nil { // Password mismatch
-		MapSDKErrorToHTTP(c, core.ErrInvalidCredentials)
+// respondWithLoginToken issues an access token for an authenticated user
+// and writes the TokenResponse. It is shared by all login methods.
+func (h *AuthGinHandler) respondWithLoginToken(c *gin.Context, user core.User, opts ...token.PayloadOption) {
+	binding, err := h.loginBinding(c)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
 		return
 	}
-
-	// Password is correct, generate token
-	accessToken, payload, err := h.tokenMaker.CreateToken(user.ID, user.Username, user.Role, h.config.AccessTokenDuration)
+	authTime := time.Now()
+	sessionID := uuid.NewString()
+	if current, exists := GetAuthPayload(c); exists && current.IsLoginToken() { // e.g. switching organizations
+		authTime = current.AuthenticatedAt()
+		if current.SessionID != "" {
+			sessionID = current.SessionID
+		}
+	}
+	opts = append([]token.PayloadOption{token.WithAuthTime(authTime)}, opts...)
+	if h.usesCookieSession(c) {
+		opts = append([]token.PayloadOption{token.WithSessionID(sessionID)}, opts...)
+	}
+	opts = append(opts, binding...)
+	accessToken, payload, err := h.auth.IssueLoginToken(c.Request.Context(), user, opts...)
 	if err != nil {
 		// h.logger.Error("Failed to create access token", "error", err, "user_id", user.ID)
-		MapSDKErrorToHTTP(c, fmt.Errorf("failed to create access token: %w", err))
+		MapSDKErrorToHTTP(c, err)
 		return
 	}
 
-	// If enforcing single device login, store the new access token as the active one
-	if h.config.EnforceSingleDeviceLogin {
-		updateParams := core.UpdateUserParams{ActiveToken: &accessToken}
-		_, updateErr := h.store.UpdateUser(c.Request.Context(), user.ID, updateParams)
-		if updateErr != nil {
-			// h.logger.Error("Failed to update active token for user", "error", updateErr, "user_id", user.ID)
-			// Log error but proceed with login, as token creation was successful.
-			// Or, decide if this is a critical failure for login.
-			fmt.Printf("Warning: Failed to update active token for %s: %v\n", user.Email, updateErr)
-		}
-	}
-
 	tokenResponse := TokenResponse{
-		AccessToken: accessToken,
-		User:        NewSDKUserResponse(user),
-		ExpiresAt:   payload.ExpiredAt,
+		AccessToken:    accessToken,
+		User:           NewSDKUserResponse(user),
+		ExpiresAt:      payload.ExpiredAt,
+		OrganizationID: payload.TenantID,
+	}
+	if payload.DPoPThumbprint() != "" {
+		tokenResponse.TokenType = dpopTokenType
+	}
+	if h.usesCookieSession(c) {
+		if err := h.startCookieSession(c, &tokenResponse, accessToken, payload); err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
 	}
 	RespondWithSuccess(c, http.StatusOK, tokenResponse)
 }
 
+// verifyToken verifies a token with the configured issuer, audience and leeway.
+func (h *AuthGinHandler) verifyToken(raw string) (*token.Payload, error) {
+	return h.tokenMaker.VerifyToken(raw, service.TokenVerifyOptions(h.config)...)
+}
+
 // VerifyEmailHandler handles the email verification link.
 func (h *AuthGinHandler) VerifyEmailHandler(c *gin.Context) {
 	var req VerifyEmailRequest                      // From request_response.go, expects token in query
@@ -215,39 +344,17 @@ func (h *AuthGinHandler) VerifyEmailHandler(c *gin.Context) {
 		return
 	}
 
-	userID, userEmail, err := h.store.GetVerificationData(c.Request.Context(), req.Token)
+	user, err := h.auth.VerifyEmail(c.Request.Context(), req.Token)
 	if err != nil {
 		MapSDKErrorToHTTP(c, err) // Handles ErrVerificationNotFound
 		return
 	}
 
-	// User found, token is valid (not expired, exists)
-	// Mark user as active
-	activeStatus := core.StatusActive
-	updateParams := core.UpdateUserParams{Status: &activeStatus}
-	updatedUser, err := h.store.UpdateUser(c.Request.Context(), userID, updateParams)
-	if err != nil {
-		// h.logger.Error("Failed to update user status to active", "error", err, "user_id", userID)
-		MapSDKErrorToHTTP(c, fmt.Errorf("failed to activate user: %w", err))
-		return
-	}
-
-	// Delete the verification token now that it's used
-	err = h.store.DeleteVerificationData(c.Request.Context(), req.Token)
-	if err != nil {
-		// h.logger.Error("Failed to delete verification data", "error", err, "token", req.Token)
-		// Log error, but verification was successful.
-		fmt.Printf("Warning: Failed to delete verification token %s: %v\n", req.Token, err)
-	}
-
 	// Optional: Redirect to a success page on the frontend
-	// Or return a success message
-	// For an API, a JSON response is usually better.
 	// frontendSuccessURL := h.config.AppBaseURL + "/email-verified?status=success" // Example
 	// c.Redirect(http.StatusFound, frontendSuccessURL)
 
-	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: fmt.Sprintf("Email for %s successfully verified.", userEmail)})
-	fmt.Printf("User %s (ID: %s) successfully verified. Status: %s\n", updatedUser.Email, updatedUser.ID, updatedUser.Status)
+	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: fmt.Sprintf("Email for %s successfully verified.", user.Email)})
 }
 
 // UserInfoHandler retrieves information for the authenticated user.
@@ -260,7 +367,7 @@ func (h *AuthGinHandler) UserInfoHandler(c *gin.Context) {
 	}
 
 	// The payload contains UserID. Fetch the full user details.
-	user, err := h.store.GetUserByID(c.Request.Context(), authPayload.UserID)
+	user, err := h.auth.GetUser(c.Request.Context(), authPayload.UserID)
 	if err != nil {
 		MapSDKErrorToHTTP(c, err) // Handles ErrNotFound etc.
 		return
@@ -270,7 +377,6 @@ func (h *AuthGinHandler) UserInfoHandler(c *gin.Context) {
 }
 
 // --- TODO: Implement other handlers  ---
-// - LogoutUserHandler
 // - ForgotPasswordHandler
 // - ResetPasswordHandler
 // - ChangePasswordHandler
@@ -0,0 +1,618 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/jose"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// AuthorizeHandler is the OAuth2 authorization endpoint for the authorization-code grant.
+// It must run behind AuthMiddleware: the authenticated user is the resource owner.
+// If the client is first-party or the user already consented to the requested scopes,
+// it responds with an AuthorizationRedirectResponse carrying the code; otherwise with a
+// ConsentPromptResponse for the frontend to render. PKCE (S256) is required.
+func (h *AuthGinHandler) AuthorizeHandler(c *gin.Context) {
+	var req AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_QUERY", "Invalid query parameters", err.Error())
+		return
+	}
+	h.handleAuthorize(c, req, nil)
+}
+
+// AuthorizeConsentHandler records the user's decision on a consent prompt and responds
+// with an AuthorizationRedirectResponse carrying either the code or an access_denied error.
+// It must run behind AuthMiddleware.
+func (h *AuthGinHandler) AuthorizeConsentHandler(c *gin.Context) {
+	var req AuthorizeConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	h.handleAuthorize(c, req.AuthorizeRequest, &req.Approve)
+}
+
+// handleAuthorize implements both authorization endpoints. approve is nil for the initial request.
+func (h *AuthGinHandler) handleAuthorize(c *gin.Context, req AuthorizeRequest, approve *bool) {
+	if h.oauthServer == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "The authorization server is not enabled", nil)
+		return
+	}
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || !authPayload.IsLoginToken() { // Only a first-party login session can grant access
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return
+	}
+	if authPayload.IsImpersonated() {
+		MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+		return
+	}
+
+	// Errors about the client or redirect URI must not redirect (RFC 6749 section 4.1.2.1)
+	client, err := h.oauthClients.GetOAuthClient(c.Request.Context(), req.ClientID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			RespondWithError(c, http.StatusBadRequest, "INVALID_CLIENT", "Unknown client", nil)
+			return
+		}
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if !oauthserver.ValidRedirectURI(client, req.RedirectURI) {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REDIRECT_URI", "redirect_uri is not registered for this client", nil)
+		return
+	}
+
+	scopes, oauthErr := validateAuthorizeRequest(client, req)
+	if oauthErr != nil {
+		h.respondAuthorizeRedirect(c, req, nil, oauthErr)
+		return
+	}
+
+	if approve != nil {
+		if !*approve {
+			h.respondAuthorizeRedirect(c, req, nil, oauthserver.ErrAccessDenied("the user denied the request"))
+			return
+		}
+		err = h.oauthServer.SaveConsent(c.Request.Context(), core.OAuthConsent{
+			UserID:    authPayload.UserID,
+			ClientID:  client.ClientID,
+			Scopes:    h.mergeConsentScopes(c.Request.Context(), authPayload.UserID, client.ClientID, scopes),
+			GrantedAt: time.Now(),
+		})
+		if err != nil {
+			MapSDKErrorToHTTP(c, fmt.Errorf("failed to save consent: %w", err))
+			return
+		}
+	} else if !client.FirstParty {
+		consent, err := h.oauthServer.GetConsent(c.Request.Context(), authPayload.UserID, client.ClientID)
+		if err != nil && !errors.Is(err, core.ErrNotFound) {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		if err != nil || !oauthserver.ContainsAll(consent.Scopes, scopes) {
+			RespondWithSuccess(c, http.StatusOK, ConsentPromptResponse{
+				ConsentRequired: true,
+				ClientID:        client.ClientID,
+				ClientName:      client.Name,
+				Scopes:          scopes,
+				Request:         req,
+			})
+			return
+		}
+	}
+
+	code, err := oauthserver.GenerateToken()
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	err = h.oauthServer.StoreAuthorizationCode(c.Request.Context(), core.AuthorizationCode{
+		CodeHash:      oauthserver.HashToken(code),
+		ClientID:      client.ClientID,
+		UserID:        authPayload.UserID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         oauthserver.JoinScope(scopes),
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		AuthTime:      authPayload.AuthenticatedAt(),
+		ExpiresAt:     time.Now().Add(h.config.AuthorizationCodeDuration),
+	})
+	if err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to store authorization code: %w", err))
+		return
+	}
+	h.respondAuthorizeRedirect(c, req, url.Values{"code": {code}}, nil)
+}
+
+// validateAuthorizeRequest checks the parameters that are reported back to the client
+// by redirect, and returns the requested scopes (the client's scopes if none were requested).
+func validateAuthorizeRequest(client core.OAuthClient, req AuthorizeRequest) ([]string, *oauthserver.Error) {
+	if req.ResponseType != "code" {
+		return nil, oauthserver.ErrUnsupportedResponseType("only response_type=code is supported")
+	}
+	if !client.AllowsGrant(core.GrantTypeAuthorizationCode) {
+		return nil, oauthserver.ErrUnauthorizedClient("client may not use the authorization code grant")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return nil, oauthserver.ErrInvalidRequest("PKCE with code_challenge_method=S256 is required")
+	}
+	scopes := oauthserver.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !oauthserver.ContainsAll(client.Scopes, scopes) {
+		return nil, oauthserver.ErrInvalidScope("requested scope exceeds the scopes allowed for this client")
+	}
+	return scopes, nil
+}
+
+// mergeConsentScopes adds newly approved scopes to any existing consent.
+func (h *AuthGinHandler) mergeConsentScopes(ctx context.Context, userID uuid.UUID, clientID string, scopes []string) []string {
+	existing, err := h.oauthServer.GetConsent(ctx, userID, clientID)
+	if err != nil {
+		return scopes
+	}
+	return oauthserver.Union(existing.Scopes, scopes)
+}
+
+// respondAuthorizeRedirect builds the client redirect URI with the result and state.
+func (h *AuthGinHandler) respondAuthorizeRedirect(c *gin.Context, req AuthorizeRequest, params url.Values, oauthErr *oauthserver.Error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	if oauthErr != nil {
+		params.Set("error", oauthErr.Code)
+		if oauthErr.Description != "" {
+			params.Set("error_description", oauthErr.Description)
+		}
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	sep := "?"
+	if strings.Contains(req.RedirectURI, "?") {
+		sep = "&"
+	}
+	RespondWithSuccess(c, http.StatusOK, AuthorizationRedirectResponse{RedirectTo: req.RedirectURI + sep + params.Encode()})
+}
+
+// TokenHandler is the OAuth2 token endpoint. It supports the authorization_code (with PKCE),
+// refresh_token, client_credentials, device_code and token exchange grants. Clients authenticate with HTTP Basic or
+// client_secret_post; public clients only send client_id. Responses use the standard
+// OAuth2 format rather than SuccessResponse/ErrorResponse.
+func (h *AuthGinHandler) TokenHandler(c *gin.Context) {
+	if h.oauthServer == nil {
+		respondOAuthError(c, oauthserver.ErrUnsupportedGrantType("the authorization server is not enabled"))
+		return
+	}
+
+	client, ok := h.authenticateClient(c, "token")
+	if !ok {
+		return
+	}
+
+	grantType := c.PostForm("grant_type")
+	switch grantType {
+	case core.GrantTypeAuthorizationCode, core.GrantTypeRefreshToken, core.GrantTypeClientCredentials,
+		core.GrantTypeDeviceCode, core.GrantTypeTokenExchange:
+	default:
+		respondOAuthError(c, oauthserver.ErrUnsupportedGrantType(fmt.Sprintf("grant_type '%s' is not supported", grantType)))
+		return
+	}
+	if !client.AllowsGrant(grantType) {
+		respondOAuthError(c, oauthserver.ErrUnauthorizedClient(fmt.Sprintf("client may not use the %s grant", grantType)))
+		return
+	}
+	// Check the DPoP proof before a code or refresh token is consumed
+	if _, err := h.tokenBinding(c); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	var (
+		resp OAuthTokenResponse
+		err  error
+	)
+	switch grantType {
+	case core.GrantTypeAuthorizationCode:
+		resp, err = h.authorizationCodeGrant(c, client)
+	case core.GrantTypeRefreshToken:
+		resp, err = h.refreshTokenGrant(c, client)
+	case core.GrantTypeClientCredentials:
+		resp, err = h.clientCredentialsGrant(c, client)
+	case core.GrantTypeDeviceCode:
+		resp, err = h.deviceCodeGrant(c, client)
+	case core.GrantTypeTokenExchange:
+		resp, err = h.tokenExchangeGrant(c, client)
+	}
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *AuthGinHandler) authorizationCodeGrant(c *gin.Context, client core.OAuthClient) (OAuthTokenResponse, error) {
+	code, verifier := c.PostForm("code"), c.PostForm("code_verifier")
+	if code == "" || verifier == "" {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidRequest("code and code_verifier are required")
+	}
+
+	stored, err := h.oauthServer.ConsumeAuthorizationCode(c.Request.Context(), oauthserver.HashToken(code))
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("authorization code is invalid or already used")
+		}
+		return OAuthTokenResponse{}, err
+	}
+	if stored.ClientID != client.ClientID || stored.RedirectURI != c.PostForm("redirect_uri") {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("authorization code was issued to another client or redirect_uri")
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("authorization code has expired")
+	}
+	if !oauthserver.VerifyPKCE(verifier, stored.CodeChallenge) {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("code_verifier does not match the code_challenge")
+	}
+
+	user, err := h.activeOAuthUser(c.Request.Context(), stored.UserID)
+	if err != nil {
+		return OAuthTokenResponse{}, err
+	}
+	return h.issueOAuthTokens(c, client, user, oauthserver.ParseScope(stored.Scope), stored.AuthTime, stored.Nonce)
+}
+
+func (h *AuthGinHandler) refreshTokenGrant(c *gin.Context, client core.OAuthClient) (OAuthTokenResponse, error) {
+	if h.refreshTokens == nil {
+		return OAuthTokenResponse{}, oauthserver.ErrUnsupportedGrantType("refresh tokens are not enabled")
+	}
+	raw := c.PostForm("refresh_token")
+	if raw == "" {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidRequest("refresh_token is required")
+	}
+
+	// Consuming the token rotates it: a replayed refresh token is rejected
+	stored, err := h.refreshTokens.ConsumeRefreshToken(c.Request.Context(), oauthserver.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("refresh token is invalid or already used")
+		}
+		return OAuthTokenResponse{}, err
+	}
+	if stored.ClientID != client.ClientID {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("refresh token was issued to another client")
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("refresh token has expired")
+	}
+	if stored.JKT != "" {
+		// A public client's refresh token is bound to its DPoP key (RFC 9449 section 5)
+		proof, err := h.dpopProof(c)
+		if err != nil || proof == nil || proof.Thumbprint != stored.JKT {
+			return OAuthTokenResponse{}, oauthserver.ErrInvalidDPoPProof("refresh token is bound to another DPoP key")
+		}
+	}
+
+	// The client may narrow, but not widen, the original scope
+	scopes := oauthserver.ParseScope(stored.Scope)
+	if requested := oauthserver.ParseScope(c.PostForm("scope")); len(requested) > 0 {
+		if !oauthserver.ContainsAll(scopes, requested) {
+			return OAuthTokenResponse{}, oauthserver.ErrInvalidScope("requested scope exceeds the original grant")
+		}
+		scopes = requested
+	}
+
+	user, err := h.activeOAuthUser(c.Request.Context(), stored.UserID)
+	if err != nil {
+		return OAuthTokenResponse{}, err
+	}
+	return h.issueOAuthTokens(c, client, user, scopes, stored.AuthTime, "")
+}
+
+func (h *AuthGinHandler) clientCredentialsGrant(c *gin.Context, client core.OAuthClient) (OAuthTokenResponse, error) {
+	if client.IsPublic() {
+		return OAuthTokenResponse{}, oauthserver.ErrUnauthorizedClient("public clients may not use the client_credentials grant")
+	}
+	scopes := oauthserver.ParseScope(c.PostForm("scope"))
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !oauthserver.ContainsAll(client.Scopes, scopes) {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidScope("requested scope exceeds the scopes allowed for this client")
+	}
+
+	opts := []token.PayloadOption{
+		token.WithClientID(client.ClientID),
+		token.WithScope(scopes...),
+		token.WithGrantType(token.GrantTypeClientCredentials),
+	}
+	subjectID, username, role := client.ID, client.ClientID, ""
+	if client.ServiceAccountID != uuid.Nil {
+		// The client authenticates a service account, whose roles the token carries
+		account, err := h.activeOAuthUser(c.Request.Context(), client.ServiceAccountID)
+		if err != nil {
+			return OAuthTokenResponse{}, err
+		}
+		if !account.IsServiceAccount() {
+			return OAuthTokenResponse{}, oauthserver.ErrUnauthorizedClient("client is not linked to a service account")
+		}
+		subjectID, username, role = account.ID, account.Username, account.Role
+		opts = append(opts, token.WithRoles(account.Roles...), token.WithPrincipalType(token.PrincipalService))
+	} else {
+		opts = append(opts, token.WithPrincipalType(token.PrincipalClient))
+	}
+	binding, err := h.tokenBinding(c)
+	if err != nil {
+		return OAuthTokenResponse{}, err
+	}
+	accessToken, payload, err := h.auth.CreateToken(subjectID, username, role, h.config.AccessTokenDuration, append(opts, binding...)...)
+	if err != nil {
+		return OAuthTokenResponse{}, fmt.Errorf("failed to create access token: %w", err)
+	}
+	return OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   accessTokenType(payload),
+		ExpiresIn:   int64(time.Until(payload.ExpiredAt).Seconds()),
+		Scope:       oauthserver.JoinScope(scopes),
+	}, nil
+}
+
+// activeOAuthUser loads the resource owner of a grant, which must still be active.
+func (h *AuthGinHandler) activeOAuthUser(ctx context.Context, userID uuid.UUID) (core.User, error) {
+	user, err := h.store.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
+			return core.User{}, oauthserver.ErrInvalidGrant("user no longer exists")
+		}
+		return core.User{}, err
+	}
+	if user.Status != core.StatusActive {
+		return core.User{}, oauthserver.ErrInvalidGrant("user account is not active")
+	}
+	return user, nil
+}
+
+// issueOAuthTokens mints an access token through the token.Maker, a new refresh token if
+// the client may use the refresh_token grant, and an ID token for the "openid" scope.
+func (h *AuthGinHandler) issueOAuthTokens(c *gin.Context, client core.OAuthClient, user core.User, scopes []string, authTime time.Time, nonce string) (OAuthTokenResponse, error) {
+	ctx := c.Request.Context()
+	// Delegated tokens carry the granted scopes only, never the user's roles
+	opts := []token.PayloadOption{
+		token.WithClientID(client.ClientID),
+		token.WithScope(scopes...),
+		token.WithPrincipalType(service.PrincipalType(user)),
+	}
+	binding, err := h.tokenBinding(c)
+	if err != nil {
+		return OAuthTokenResponse{}, err
+	}
+	accessToken, payload, err := h.auth.CreateToken(user.ID, user.Username, "", h.config.AccessTokenDuration, append(opts, binding...)...)
+	if err != nil {
+		return OAuthTokenResponse{}, fmt.Errorf("failed to create access token: %w", err)
+	}
+	resp := OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   accessTokenType(payload),
+		ExpiresIn:   int64(time.Until(payload.ExpiredAt).Seconds()),
+		Scope:       oauthserver.JoinScope(scopes),
+	}
+
+	if h.refreshTokens != nil && client.AllowsGrant(core.GrantTypeRefreshToken) {
+		refreshToken, err := oauthserver.GenerateToken()
+		if err != nil {
+			return OAuthTokenResponse{}, err
+		}
+		err = h.refreshTokens.StoreRefreshToken(ctx, core.RefreshToken{
+			TokenHash: oauthserver.HashToken(refreshToken),
+			UserID:    user.ID,
+			ClientID:  client.ClientID,
+			Scope:     resp.Scope,
+			AuthTime:  authTime,
+			JKT:       refreshTokenBinding(client, payload),
+			ExpiresAt: time.Now().Add(h.config.RefreshTokenDuration),
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return OAuthTokenResponse{}, fmt.Errorf("failed to store refresh token: %w", err)
+		}
+		resp.RefreshToken = refreshToken
+	}
+
+	if h.idTokenSigner != nil && oauthserver.ContainsAll(scopes, []string{oauthserver.ScopeOpenID}) {
+		idToken, err := h.idTokenSigner.Sign(oauthserver.IDTokenClaims{
+			Issuer:         h.openIDConfig.Issuer,
+			Subject:        user.ID.String(),
+			Audience:       []string{client.ClientID},
+			Expiry:         jose.NewNumericDate(payload.ExpiredAt),
+			IssuedAt:       jose.NewNumericDate(payload.IssuedAt),
+			AuthTime:       jose.NewNumericDate(authTime),
+			Nonce:          nonce,
+			AtHash:         h.idTokenSigner.AccessTokenHash(accessToken),
+			Azp:            client.ClientID,
+			UserInfoClaims: oauthserver.UserInfoClaimsFor(user, scopes),
+		})
+		if err != nil {
+			return OAuthTokenResponse{}, fmt.Errorf("failed to sign id token: %w", err)
+		}
+		resp.IDToken = idToken
+	}
+	return resp, nil
+}
+
+// authenticateClient authenticates the calling OAuth2 client. It writes the error
+// response and returns false on failure.
+func (h *AuthGinHandler) authenticateClient(c *gin.Context, realm string) (core.OAuthClient, bool) {
+	clientID, clientSecret, usedBasic := clientCredentials(c)
+	client, err := oauthserver.AuthenticateClient(c.Request.Context(), h.oauthClients, clientID, clientSecret)
+	if err != nil {
+		if usedBasic {
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
+		}
+		respondOAuthError(c, err)
+		return core.OAuthClient{}, false
+	}
+	return client, true
+}
+
+// clientCredentials extracts client authentication from HTTP Basic or the form body.
+func clientCredentials(c *gin.Context) (clientID, clientSecret string, usedBasic bool) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		// Basic credentials are form-urlencoded (RFC 6749 section 2.3.1)
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			id = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			secret = unescaped
+		}
+		return id, secret, true
+	}
+	return c.PostForm("client_id"), c.PostForm("client_secret"), false
+}
+
+// respondOAuthError writes a standard OAuth2 error response.
+func respondOAuthError(c *gin.Context, err error) {
+	var oauthErr *oauthserver.Error
+	if !errors.As(err, &oauthErr) {
+		// h.logger.Error("Token endpoint error", "error", err)
+		fmt.Printf("Error: OAuth2 endpoint %s failed: %v\n", c.FullPath(), err)
+		oauthErr = oauthserver.ErrServerError("an unexpected error occurred")
+	}
+	c.Header("Cache-Control", "no-store")
+	c.AbortWithStatusJSON(oauthErr.Status, OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+}
+
+// --- Client registry (admin) ---
+
+// CreateOAuthClientHandler registers a new OAuth2 client. The client secret is only
+// returned in this response. Protect it with GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) CreateOAuthClientHandler(c *gin.Context) {
+	if h.oauthClients == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "The authorization server is not enabled", nil)
+		return
+	}
+	if _, ok := loginSession(c); !ok { // Delegated tokens and API keys cannot register clients
+		return
+	}
+	var req CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+
+	newClient := core.OAuthClient{
+		ID:                     uuid.New(),
+		Name:                   req.Name,
+		RedirectURIs:           req.RedirectURIs,
+		PostLogoutRedirectURIs: req.PostLogoutRedirectURIs,
+		GrantTypes:             req.GrantTypes,
+		Scopes:                 req.Scopes,
+		FirstParty:             req.FirstParty,
+		ResourceServer:         req.ResourceServer,
+		CreatedAt:              time.Now(),
+	}
+	if newClient.AllowsGrant(core.GrantTypeAuthorizationCode) && len(req.RedirectURIs) == 0 {
+		RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "redirect_uris are required for the authorization_code grant", nil)
+		return
+	}
+	if req.Public && newClient.AllowsGrant(core.GrantTypeClientCredentials) {
+		RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Public clients cannot use the client_credentials grant", nil)
+		return
+	}
+
+	if req.ServiceAccountID != "" {
+		if !newClient.AllowsGrant(core.GrantTypeClientCredentials) || req.Public {
+			RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "A service account requires a confidential client with the client_credentials grant", nil)
+			return
+		}
+		newClient.ServiceAccountID, _ = uuid.Parse(req.ServiceAccountID) // Validated by binding
+		account, err := h.store.GetUserByID(c.Request.Context(), newClient.ServiceAccountID)
+		if err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		if !account.IsServiceAccount() {
+			RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "service_account_id does not name a service account", nil)
+			return
+		}
+	}
+
+	clientID, err := oauthserver.GenerateToken()
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	newClient.ClientID = clientID[:22]
+	var secret string
+	if !req.Public {
+		if secret, err = oauthserver.GenerateToken(); err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		newClient.SecretHash = oauthserver.HashToken(secret)
+	}
+
+	created, err := h.oauthClients.CreateOAuthClient(c.Request.Context(), newClient)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	resp := NewOAuthClientResponse(created)
+	resp.ClientSecret = secret
+	RespondWithSuccess(c, http.StatusCreated, resp)
+}
+
+// ListOAuthClientsHandler lists the registered OAuth2 clients. Protect it with GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) ListOAuthClientsHandler(c *gin.Context) {
+	if h.oauthClients == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "The authorization server is not enabled", nil)
+		return
+	}
+	if _, ok := loginSession(c); !ok {
+		return
+	}
+	clients, err := h.oauthClients.ListOAuthClients(c.Request.Context())
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	resp := make([]OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		resp = append(resp, NewOAuthClientResponse(client))
+	}
+	RespondWithSuccess(c, http.StatusOK, resp)
+}
+
+// DeleteOAuthClientHandler removes the client in the ":client_id" route parameter.
+// Tokens issued to the client stop working where AuthMiddleware runs with CheckClients.
+// Protect it with GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) DeleteOAuthClientHandler(c *gin.Context) {
+	if h.oauthClients == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "The authorization server is not enabled", nil)
+		return
+	}
+	if _, ok := loginSession(c); !ok {
+		return
+	}
+	if err := h.oauthClients.DeleteOAuthClient(c.Request.Context(), c.Param("client_id")); err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: "Client deleted."})
+}
@@ -0,0 +1,93 @@
+package ginhandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shawgichan/go-authkit/authz"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// AuthzDecisionKey stores the last authorization decision in the Gin context, for debugging.
+const AuthzDecisionKey = "authz_decision"
+
+// SubjectAttributesFunc loads attributes of the authenticated subject that are not in
+// the token, e.g. the user's department.
+type SubjectAttributesFunc func(ctx context.Context, payload *token.Payload) (map[string]interface{}, error)
+
+// ResourceFunc identifies the resource a request acts on, e.g. by loading the
+// document named in the route.
+type ResourceFunc func(c *gin.Context) (authz.Resource, error)
+
+// AuthzMiddleware makes the policy engine and the authenticated subject available to
+// RequireAuthorization, Authorize and authz.Authorize. attributes may be nil. It must
+// run after AuthMiddleware.
+func AuthzMiddleware(engine *authz.Engine, attributes SubjectAttributesFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, exists := GetAuthPayload(c)
+		if !exists {
+			RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization payload not found for policy check", nil)
+			return
+		}
+		var attrs map[string]interface{}
+		if attributes != nil {
+			var err error
+			attrs, err = attributes(c.Request.Context(), payload)
+			if err != nil {
+				MapSDKErrorToHTTP(c, fmt.Errorf("failed to load subject attributes: %w", err))
+				return
+			}
+		}
+		subject := authz.SubjectFromPayload(payload, attrs)
+		c.Request = c.Request.WithContext(authz.NewContext(c.Request.Context(), engine, subject))
+		c.Next()
+	}
+}
+
+// RequireAuthorization allows the request only if the policies allow action on the
+// resource returned by resource. Use it where the resource can be identified before
+// the handler runs; otherwise call Authorize from the handler. It must run after
+// AuthzMiddleware.
+func RequireAuthorization(action string, resource ResourceFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res, err := resource(c)
+		if err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		if _, err := Authorize(c, action, res); err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Authorize decides whether the authenticated subject may perform action on resource.
+// A denial is returned as core.ErrAccessDenied, explained by the decision's reason.
+// The decision is also stored under AuthzDecisionKey.
+func Authorize(c *gin.Context, action string, resource authz.Resource) (authz.Decision, error) {
+	decision, err := authz.Authorize(c.Request.Context(), action, resource)
+	if err != nil {
+		return decision, err
+	}
+	c.Set(AuthzDecisionKey, decision)
+	if !decision.Allowed {
+		return decision, fmt.Errorf("%w: %s", core.ErrAccessDenied, decision.Reason)
+	}
+	return decision, nil
+}
+
+// GetAuthzDecision returns the last decision made by Authorize for this request.
+func GetAuthzDecision(c *gin.Context) (authz.Decision, bool) {
+	value, exists := c.Get(AuthzDecisionKey)
+	if !exists {
+		return authz.Decision{}, false
+	}
+	decision, ok := value.(authz.Decision)
+	return decision, ok
+}
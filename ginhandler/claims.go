@@ -0,0 +1,39 @@
+package ginhandler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// GetClaim decodes an application-specific claim of the request's token into T
+// (see token.ClaimAs). It returns false if there is no payload or claim, or if the
+// claim does not decode into T.
+func GetClaim[T any](c *gin.Context, key string) (T, bool) {
+	payload, exists := GetAuthPayload(c)
+	if !exists {
+		var zero T
+		return zero, false
+	}
+	return token.ClaimAs[T](payload, key)
+}
+
+// GetClaimString returns a string claim of the request's token.
+func GetClaimString(c *gin.Context, key string) (string, bool) {
+	return GetClaim[string](c, key)
+}
+
+// GetClaimStrings returns a string array claim of the request's token.
+func GetClaimStrings(c *gin.Context, key string) ([]string, bool) {
+	return GetClaim[[]string](c, key)
+}
+
+// GetClaimBool returns a boolean claim of the request's token.
+func GetClaimBool(c *gin.Context, key string) (bool, bool) {
+	return GetClaim[bool](c, key)
+}
+
+// GetClaimInt returns an integer claim of the request's token.
+func GetClaimInt(c *gin.Context, key string) (int64, bool) {
+	return GetClaim[int64](c, key)
+}
@@ -0,0 +1,263 @@
+package ginhandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+)
+
+// slowDownIncrement is added to the polling interval each time a device polls too fast (RFC 8628 section 3.5).
+const slowDownIncrement = 5 * time.Second
+
+// DeviceAuthorizationHandler is the device authorization endpoint (RFC 8628 section 3.1).
+// A CLI or input-constrained device calls it to obtain a device code to poll the token
+// endpoint with and a short user code for the user to enter on another device.
+func (h *AuthGinHandler) DeviceAuthorizationHandler(c *gin.Context) {
+	if h.oauthServer == nil || h.deviceAuths == nil {
+		respondOAuthError(c, oauthserver.ErrUnsupportedGrantType("the device authorization grant is not enabled"))
+		return
+	}
+
+	client, ok := h.authenticateClient(c, "device")
+	if !ok {
+		return
+	}
+	if !client.AllowsGrant(core.GrantTypeDeviceCode) {
+		respondOAuthError(c, oauthserver.ErrUnauthorizedClient(fmt.Sprintf("client may not use the %s grant", core.GrantTypeDeviceCode)))
+		return
+	}
+
+	scopes := oauthserver.ParseScope(c.PostForm("scope"))
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !oauthserver.ContainsAll(client.Scopes, scopes) {
+		respondOAuthError(c, oauthserver.ErrInvalidScope("requested scope exceeds the scopes allowed for this client"))
+		return
+	}
+
+	deviceCode, err := oauthserver.GenerateToken()
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	userCode, err := oauthserver.GenerateUserCode()
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	err = h.deviceAuths.StoreDeviceAuthorization(c.Request.Context(), core.DeviceAuthorization{
+		DeviceCodeHash: oauthserver.HashToken(deviceCode),
+		UserCode:       userCode,
+		ClientID:       client.ClientID,
+		Scope:          oauthserver.JoinScope(scopes),
+		Status:         core.DeviceAuthorizationPending,
+		Interval:       h.config.DeviceCodeInterval,
+		ExpiresAt:      time.Now().Add(h.config.DeviceCodeDuration),
+	})
+	if err != nil {
+		respondOAuthError(c, fmt.Errorf("failed to store device authorization: %w", err))
+		return
+	}
+
+	verificationURI := h.config.DeviceVerificationURI
+	if verificationURI == "" {
+		verificationURI = h.deviceVerificationURI
+	}
+	if verificationURI == "" {
+		verificationURI = strings.TrimSuffix(h.config.AppBaseURL, "/") + DefaultRoutePaths().Device
+	}
+	formatted := oauthserver.FormatUserCode(userCode)
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                formatted,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(formatted),
+		ExpiresIn:               int64(h.config.DeviceCodeDuration.Seconds()),
+		Interval:                int64(h.config.DeviceCodeInterval.Seconds()),
+	})
+}
+
+// DeviceVerificationHandler looks up a pending device request by user code so the
+// frontend can show the user which client is asking for which scopes.
+// It must run behind AuthMiddleware.
+func (h *AuthGinHandler) DeviceVerificationHandler(c *gin.Context) {
+	var req DeviceVerificationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_QUERY", "Invalid query parameters", err.Error())
+		return
+	}
+	auth, client, ok := h.pendingDeviceAuthorization(c, req.UserCode)
+	if !ok {
+		return
+	}
+
+	RespondWithSuccess(c, http.StatusOK, DeviceVerificationResponse{
+		UserCode:   oauthserver.FormatUserCode(auth.UserCode),
+		ClientID:   client.ClientID,
+		ClientName: client.Name,
+		Scopes:     oauthserver.ParseScope(auth.Scope),
+	})
+}
+
+// DeviceApprovalHandler records the user's decision on a device request. The polling
+// device receives its tokens (or access_denied) on its next token request.
+// It must run behind AuthMiddleware.
+func (h *AuthGinHandler) DeviceApprovalHandler(c *gin.Context) {
+	var req DeviceApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	auth, _, ok := h.pendingDeviceAuthorization(c, req.UserCode)
+	if !ok {
+		return
+	}
+	authPayload, _ := GetAuthPayload(c)
+
+	if req.Approve {
+		auth.Status = core.DeviceAuthorizationApproved
+		auth.UserID = authPayload.UserID
+		auth.AuthTime = authPayload.AuthenticatedAt()
+	} else {
+		auth.Status = core.DeviceAuthorizationDenied
+	}
+	if err := h.deviceAuths.UpdateDeviceAuthorization(c.Request.Context(), auth); err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to update device authorization: %w", err))
+		return
+	}
+
+	message := "Device approved. You can return to your device."
+	if !req.Approve {
+		message = "Device request denied."
+	}
+	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: message})
+}
+
+// pendingDeviceAuthorization loads an unexpired, undecided device request for the logged-in
+// user to act on. It writes the error response and returns false on failure.
+func (h *AuthGinHandler) pendingDeviceAuthorization(c *gin.Context, userCode string) (core.DeviceAuthorization, core.OAuthClient, bool) {
+	if h.oauthServer == nil || h.deviceAuths == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "The device authorization grant is not enabled", nil)
+		return core.DeviceAuthorization{}, core.OAuthClient{}, false
+	}
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || !authPayload.IsLoginToken() { // Only a first-party login session can approve a device
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return core.DeviceAuthorization{}, core.OAuthClient{}, false
+	}
+	if authPayload.IsImpersonated() {
+		MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+		return core.DeviceAuthorization{}, core.OAuthClient{}, false
+	}
+
+	// User codes are short, so limit how many codes one user may try. The attempt is
+	// counted before the lookup, so that parallel requests cannot try more
+	if h.config.DeviceUserCodeAttempts > 0 {
+		attempts, err := h.deviceAuths.CountUserCodeAttempt(c.Request.Context(), authPayload.UserID, h.config.DeviceCodeDuration)
+		if err != nil {
+			MapSDKErrorToHTTP(c, fmt.Errorf("failed to count user code attempt: %w", err))
+			return core.DeviceAuthorization{}, core.OAuthClient{}, false
+		}
+		if attempts > h.config.DeviceUserCodeAttempts {
+			MapSDKErrorToHTTP(c, core.ErrTooManyAttempts)
+			return core.DeviceAuthorization{}, core.OAuthClient{}, false
+		}
+	}
+	auth, err := h.deviceAuths.GetDeviceAuthorizationByUserCode(c.Request.Context(), oauthserver.NormalizeUserCode(userCode))
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			RespondWithError(c, http.StatusNotFound, "INVALID_USER_CODE", "Unknown or expired code", nil)
+			return core.DeviceAuthorization{}, core.OAuthClient{}, false
+		}
+		MapSDKErrorToHTTP(c, err)
+		return core.DeviceAuthorization{}, core.OAuthClient{}, false
+	}
+	if auth.Status != core.DeviceAuthorizationPending || time.Now().After(auth.ExpiresAt) {
+		RespondWithError(c, http.StatusNotFound, "INVALID_USER_CODE", "Unknown or expired code", nil)
+		return core.DeviceAuthorization{}, core.OAuthClient{}, false
+	}
+
+	client, err := h.oauthClients.GetOAuthClient(c.Request.Context(), auth.ClientID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return core.DeviceAuthorization{}, core.OAuthClient{}, false
+	}
+	return auth, client, true
+}
+
+// deviceCodeGrant exchanges an approved device code for tokens (RFC 8628 section 3.4).
+// Until the user decides it returns authorization_pending, or slow_down if the device
+// polls faster than its interval.
+func (h *AuthGinHandler) deviceCodeGrant(c *gin.Context, client core.OAuthClient) (OAuthTokenResponse, error) {
+	if h.deviceAuths == nil {
+		return OAuthTokenResponse{}, oauthserver.ErrUnsupportedGrantType("the device authorization grant is not enabled")
+	}
+	deviceCode := c.PostForm("device_code")
+	if deviceCode == "" {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidRequest("device_code is required")
+	}
+
+	ctx := c.Request.Context()
+	deviceCodeHash := oauthserver.HashToken(deviceCode)
+	auth, err := h.deviceAuths.GetDeviceAuthorizationByDeviceCode(ctx, deviceCodeHash)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("device code is invalid")
+		}
+		return OAuthTokenResponse{}, err
+	}
+	if auth.ClientID != client.ClientID {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("device code was issued to another client")
+	}
+
+	now := time.Now()
+	if now.After(auth.ExpiresAt) {
+		_ = h.deviceAuths.DeleteDeviceAuthorization(ctx, deviceCodeHash)
+		return OAuthTokenResponse{}, oauthserver.ErrExpiredToken("device code has expired")
+	}
+
+	switch auth.Status {
+	case core.DeviceAuthorizationDenied:
+		_ = h.deviceAuths.DeleteDeviceAuthorization(ctx, deviceCodeHash)
+		return OAuthTokenResponse{}, oauthserver.ErrAccessDenied("the user denied the request")
+	case core.DeviceAuthorizationApproved:
+		// Consuming makes the device code single-use even if the device polls concurrently
+		auth, err = h.deviceAuths.ConsumeDeviceAuthorization(ctx, deviceCodeHash)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("device code has already been used")
+			}
+			return OAuthTokenResponse{}, fmt.Errorf("failed to consume device authorization: %w", err)
+		}
+		user, err := h.activeOAuthUser(ctx, auth.UserID)
+		if err != nil {
+			return OAuthTokenResponse{}, err
+		}
+		return h.issueOAuthTokens(c, client, user, oauthserver.ParseScope(auth.Scope), auth.AuthTime, "")
+	}
+
+	tooFast := !auth.LastPolledAt.IsZero() && now.Sub(auth.LastPolledAt) < auth.Interval
+	if tooFast {
+		auth.Interval += slowDownIncrement
+	}
+	// Only the polling fields are written: the user may have decided since the read
+	if err := h.deviceAuths.TouchDeviceAuthorization(ctx, deviceCodeHash, now, auth.Interval); err != nil {
+		return OAuthTokenResponse{}, fmt.Errorf("failed to update device authorization: %w", err)
+	}
+	if tooFast {
+		return OAuthTokenResponse{}, oauthserver.ErrSlowDown("polling too frequently")
+	}
+	return OAuthTokenResponse{}, oauthserver.ErrAuthorizationPending("the user has not yet approved the request")
+}
@@ -0,0 +1,159 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+type memoryDeviceStore struct {
+	core.DeviceAuthorizationStorer
+	mu       sync.Mutex
+	devices  map[string]core.DeviceAuthorization
+	afterGet func() // Runs after a read by device code, e.g. to decide meanwhile
+	attempts map[uuid.UUID]int
+}
+
+func (s *memoryDeviceStore) GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (core.DeviceAuthorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, auth := range s.devices {
+		if auth.UserCode == userCode {
+			return auth, nil
+		}
+	}
+	return core.DeviceAuthorization{}, core.ErrNotFound
+}
+
+func (s *memoryDeviceStore) CountUserCodeAttempt(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attempts == nil {
+		s.attempts = make(map[uuid.UUID]int)
+	}
+	s.attempts[userID]++
+	return s.attempts[userID], nil
+}
+
+func (s *memoryDeviceStore) GetDeviceAuthorizationByDeviceCode(ctx context.Context, deviceCodeHash string) (core.DeviceAuthorization, error) {
+	s.mu.Lock()
+	auth, ok := s.devices[deviceCodeHash]
+	s.mu.Unlock()
+	if !ok {
+		return core.DeviceAuthorization{}, core.ErrNotFound
+	}
+	if s.afterGet != nil {
+		s.afterGet()
+	}
+	return auth, nil
+}
+
+func (s *memoryDeviceStore) UpdateDeviceAuthorization(ctx context.Context, auth core.DeviceAuthorization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.devices[auth.DeviceCodeHash] = auth
+	return nil
+}
+
+func (s *memoryDeviceStore) TouchDeviceAuthorization(ctx context.Context, deviceCodeHash string, polledAt time.Time, interval time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	auth, ok := s.devices[deviceCodeHash]
+	if !ok {
+		return core.ErrNotFound
+	}
+	auth.LastPolledAt = polledAt
+	auth.Interval = interval
+	s.devices[deviceCodeHash] = auth
+	return nil
+}
+
+func TestDevicePollKeepsApproval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const deviceCode = "device-code"
+	hash := oauthserver.HashToken(deviceCode)
+	store := &memoryDeviceStore{devices: map[string]core.DeviceAuthorization{hash: {
+		DeviceCodeHash: hash,
+		ClientID:       "tv",
+		Status:         core.DeviceAuthorizationPending,
+		Interval:       5 * time.Second,
+		ExpiresAt:      time.Now().Add(time.Minute),
+	}}}
+	userID := uuid.New()
+	// The user approves between the poll's read and its write
+	store.afterGet = func() {
+		store.afterGet = nil
+		auth := store.devices[hash]
+		auth.Status = core.DeviceAuthorizationApproved
+		auth.UserID = userID
+		_ = store.UpdateDeviceAuthorization(context.Background(), auth)
+	}
+	h := &AuthGinHandler{config: config.DefaultAuthConfig(), deviceAuths: store}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(url.Values{"device_code": {deviceCode}}.Encode()))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err := h.deviceCodeGrant(c, core.OAuthClient{ClientID: "tv"})
+	var oauthErr *oauthserver.Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "authorization_pending" {
+		t.Fatalf("deviceCodeGrant() = %v, want authorization_pending", err)
+	}
+
+	auth := store.devices[hash]
+	if auth.Status != core.DeviceAuthorizationApproved || auth.UserID != userID {
+		t.Errorf("status = %s, user = %s after the poll, want the approval kept", auth.Status, auth.UserID)
+	}
+	if auth.LastPolledAt.IsZero() {
+		t.Error("the poll was not recorded")
+	}
+}
+
+func TestDeviceUserCodeAttempts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.DeviceUserCodeAttempts = 3
+	store := &memoryDeviceStore{devices: map[string]core.DeviceAuthorization{"hash": {
+		DeviceCodeHash: "hash",
+		UserCode:       "BCDFGHJK",
+		ClientID:       "tv",
+		Status:         core.DeviceAuthorizationPending,
+		ExpiresAt:      time.Now().Add(time.Minute),
+	}}}
+	h := &AuthGinHandler{config: cfg, oauthServer: struct{ core.OAuthServerStorer }{}, deviceAuths: store}
+	guesser, other := uuid.New(), uuid.New()
+	verify := func(userID uuid.UUID, userCode string) int {
+		r := gin.New()
+		r.GET("/device", func(c *gin.Context) { setAuthPayload(c, &token.Payload{UserID: userID}) }, h.DeviceVerificationHandler)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/device?user_code="+userCode, nil))
+		return w.Code
+	}
+
+	for range cfg.DeviceUserCodeAttempts {
+		if code := verify(guesser, "ZZZZZZZZ"); code != http.StatusNotFound {
+			t.Fatalf("wrong user code: status = %d, want %d", code, http.StatusNotFound)
+		}
+	}
+	// Once the limit is used up even the right code is refused, so guessing cannot find it
+	if code := verify(guesser, "BCDF-GHJK"); code != http.StatusTooManyRequests {
+		t.Errorf("right user code after the limit: status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := verify(other, "ZZZZZZZZ"); code != http.StatusNotFound {
+		t.Errorf("another user: status = %d, want %d", code, http.StatusNotFound)
+	}
+}
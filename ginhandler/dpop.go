@@ -0,0 +1,127 @@
+package ginhandler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/dpop"
+	"github.com/shawgichan/go-authkit/mtls"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// dpopProofKey caches the verified DPoP proof of a request in the Gin context, since a
+// proof can only be verified once.
+const dpopProofKey = "dpop_proof"
+
+// dpopTokenType is the token type of DPoP-bound tokens (RFC 9449 section 5).
+const dpopTokenType = "DPoP"
+
+// AcceptDPoP makes AuthMiddleware accept "Authorization: DPoP <token>" and verify the
+// request's DPoP proof against the token's key. Proof IDs are remembered in nonces to
+// reject replays. DPoP-bound tokens sent with the Bearer scheme are always rejected.
+func AcceptDPoP(nonces core.NonceStorer) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.dpopNonces = nonces
+	}
+}
+
+// WithDPoP makes the login handlers and the token endpoint bind the access tokens they
+// issue to the client's DPoP key when the request carries a DPoP proof (RFC 9449).
+// Refresh tokens issued to public clients are bound to the key too. Pair it with the
+// AcceptDPoP middleware option.
+func WithDPoP(nonces core.NonceStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.dpop = dpop.NewVerifier(nonces, h.config.DPoPProofMaxAge, h.config.TokenLeeway)
+		h.dpopNonces = nonces
+	}
+}
+
+// dpopProof verifies the DPoP proof sent to a token-issuing endpoint. It returns nil if
+// DPoP is not enabled or the request has no proof.
+func (h *AuthGinHandler) dpopProof(c *gin.Context) (*dpop.Proof, error) {
+	if h.dpop == nil {
+		return nil, nil
+	}
+	if cached, exists := c.Get(dpopProofKey); exists {
+		return cached.(*dpop.Proof), nil
+	}
+	proofs := c.Request.Header.Values(dpop.HeaderName)
+	if len(proofs) == 0 {
+		return nil, nil
+	}
+	if len(proofs) > 1 {
+		return nil, fmt.Errorf("%w: exactly one DPoP header is required", core.ErrDPoPProofInvalid)
+	}
+	proof, err := h.dpop.Verify(c.Request.Context(), proofs[0], c.Request.Method, service.DPoPRequestURL(c.Request, h.config), "")
+	if err != nil {
+		return nil, err
+	}
+	c.Set(dpopProofKey, &proof)
+	return &proof, nil
+}
+
+// loginBinding returns the option binding a new login token to the caller's DPoP key:
+// the key of the token the request was authenticated with, or of the request's proof.
+func (h *AuthGinHandler) loginBinding(c *gin.Context) ([]token.PayloadOption, error) {
+	if current, exists := GetAuthPayload(c); exists && current.DPoPThumbprint() != "" {
+		return []token.PayloadOption{token.WithConfirmation(token.Confirmation{JKT: current.DPoPThumbprint()})}, nil
+	}
+	proof, err := h.dpopProof(c)
+	if err != nil || proof == nil {
+		return nil, err
+	}
+	return []token.PayloadOption{token.WithConfirmation(token.Confirmation{JKT: proof.Thumbprint})}, nil
+}
+
+// tokenBinding returns the option binding tokens issued at the token endpoint to the
+// calling client's certificate (RFC 8705) and DPoP key (RFC 9449), as far as it presented
+// them. An invalid proof is returned as an OAuth2 invalid_dpop_proof error.
+func (h *AuthGinHandler) tokenBinding(c *gin.Context) ([]token.PayloadOption, error) {
+	cnf, err := h.requestConfirmation(c)
+	if err != nil || cnf == (token.Confirmation{}) {
+		return nil, err
+	}
+	return []token.PayloadOption{token.WithConfirmation(cnf)}, nil
+}
+
+// requestConfirmation returns the certificate and DPoP key the request presented.
+func (h *AuthGinHandler) requestConfirmation(c *gin.Context) (token.Confirmation, error) {
+	var cnf token.Confirmation
+	if h.clientCerts != nil {
+		if cert, err := h.clientCerts.Certificate(c.Request); err == nil {
+			cnf.X5tS256 = mtls.Thumbprint(cert)
+		}
+	}
+	proof, err := h.dpopProof(c)
+	if err != nil {
+		if dpop.IsInvalidProof(err) {
+			return token.Confirmation{}, oauthserver.ErrInvalidDPoPProof(err.Error())
+		}
+		return token.Confirmation{}, err
+	}
+	if proof != nil {
+		cnf.JKT = proof.Thumbprint
+	}
+	return cnf, nil
+}
+
+// accessTokenType returns the token_type for an issued access token.
+func accessTokenType(payload *token.Payload) string {
+	if payload.DPoPThumbprint() != "" {
+		return dpopTokenType
+	}
+	return "Bearer"
+}
+
+// refreshTokenBinding returns the DPoP key a refresh token is bound to. Confidential
+// clients authenticate at the token endpoint, so only public clients' tokens are bound.
+func refreshTokenBinding(client core.OAuthClient, payload *token.Payload) string {
+	if !client.IsPublic() {
+		return ""
+	}
+	return payload.DPoPThumbprint()
+}
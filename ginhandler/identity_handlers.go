@@ -0,0 +1,221 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauth"
+)
+
+// ListIdentitiesHandler lists the login methods of the authenticated user,
+// including the email/password method if the user has a password.
+// This handler relies on AuthMiddleware to have run and set the payload.
+func (h *AuthGinHandler) ListIdentitiesHandler(c *gin.Context) {
+	user, identities, ok := h.currentUserIdentities(c)
+	if !ok {
+		return
+	}
+
+	resp := make([]IdentityResponse, 0, len(identities)+1)
+	if user.PasswordHash != "" {
+		resp = append(resp, IdentityResponse{
+			ID:       core.IdentityProviderPassword,
+			Provider: core.IdentityProviderPassword,
+			Email:    user.Email,
+			LinkedAt: user.CreatedAt,
+		})
+	}
+	for _, identity := range identities {
+		resp = append(resp, NewIdentityResponse(identity))
+	}
+	RespondWithSuccess(c, http.StatusOK, resp)
+}
+
+// LinkIdentityHandler starts linking an external identity to the authenticated user.
+// It requires re-authentication: the current password, or for users without one, a login
+// within AuthConfig.ReauthenticationWindow. It responds with the provider URL to redirect
+// the user to; the link is completed by OAuthCallbackHandler in the same browser, which
+// receives the AuthConfig.OAuthBindingCookie cookie with this response.
+func (h *AuthGinHandler) LinkIdentityHandler(c *gin.Context) {
+	if h.identities == nil || h.oauthProviders == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Identity linking is not enabled", nil)
+		return
+	}
+
+	var req LinkIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+
+	authPayload, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	user, err := h.store.GetUserByID(c.Request.Context(), authPayload.UserID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	// Re-authentication
+	if user.PasswordHash != "" {
+		if req.Password == "" {
+			MapSDKErrorToHTTP(c, core.ErrReauthRequired)
+			return
+		}
+		if err := h.hasher.Check(user.PasswordHash, req.Password); err != nil {
+			MapSDKErrorToHTTP(c, core.ErrInvalidCredentials)
+			return
+		}
+	} else if time.Since(authPayload.AuthenticatedAt()) > h.config.ReauthenticationWindow {
+		MapSDKErrorToHTTP(c, core.ErrReauthRequired)
+		return
+	}
+
+	provider, ok := h.oauthProviders.Get(req.Provider)
+	if !ok {
+		MapSDKErrorToHTTP(c, core.ErrOAuthProviderNotFound)
+		return
+	}
+
+	authURL, err := h.startOAuth(c, provider, user.ID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, AuthorizationURLResponse{AuthorizationURL: authURL})
+}
+
+// UnlinkIdentityHandler removes the login method in the ":id" route parameter from the
+// authenticated user. Use "password" as the id to remove the password. The last remaining
+// login method cannot be removed. Like linking, it requires a login within
+// AuthConfig.ReauthenticationWindow, so a stolen session cannot lock the owner out.
+func (h *AuthGinHandler) UnlinkIdentityHandler(c *gin.Context) {
+	user, identities, ok := h.currentUserIdentities(c)
+	if !ok {
+		return
+	}
+	authPayload, _ := GetAuthPayload(c)
+	if time.Since(authPayload.AuthenticatedAt()) > h.config.ReauthenticationWindow {
+		MapSDKErrorToHTTP(c, core.ErrReauthRequired)
+		return
+	}
+
+	loginMethods := len(identities)
+	if user.PasswordHash != "" {
+		loginMethods++
+	}
+
+	idParam := c.Param("id")
+	if idParam == core.IdentityProviderPassword {
+		if user.PasswordHash == "" {
+			MapSDKErrorToHTTP(c, core.ErrNotFound)
+			return
+		}
+		if loginMethods <= 1 {
+			MapSDKErrorToHTTP(c, core.ErrLastLoginMethod)
+			return
+		}
+		noPassword := ""
+		if _, err := h.store.UpdateUser(c.Request.Context(), user.ID, core.UpdateUserParams{PasswordHash: &noPassword}); err != nil {
+			MapSDKErrorToHTTP(c, fmt.Errorf("failed to remove password: %w", err))
+			return
+		}
+		RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: "Password login removed."})
+		return
+	}
+
+	identityID, err := uuid.Parse(idParam)
+	if err != nil {
+		RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid identity ID", err.Error())
+		return
+	}
+	var target *core.Identity
+	for i := range identities {
+		if identities[i].ID == identityID {
+			target = &identities[i]
+			break
+		}
+	}
+	if target == nil {
+		MapSDKErrorToHTTP(c, core.ErrNotFound)
+		return
+	}
+	if loginMethods <= 1 {
+		MapSDKErrorToHTTP(c, core.ErrLastLoginMethod)
+		return
+	}
+
+	if err := h.identities.DeleteIdentity(c.Request.Context(), user.ID, target.ID); err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: fmt.Sprintf("%s login removed.", target.Provider)})
+}
+
+// completeIdentityLink is called from OAuthCallbackHandler when the state was created by LinkIdentityHandler.
+func (h *AuthGinHandler) completeIdentityLink(c *gin.Context, userID uuid.UUID, info oauth.UserInfo) {
+	existing, err := h.identities.GetIdentity(c.Request.Context(), info.Provider, info.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			MapSDKErrorToHTTP(c, core.ErrIdentityAlreadyLinked)
+			return
+		}
+		RespondWithSuccess(c, http.StatusOK, NewIdentityResponse(existing)) // Already linked to this user
+		return
+	}
+	if !errors.Is(err, core.ErrNotFound) {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	identity, err := h.createIdentity(c.Request.Context(), userID, info)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	RespondWithSuccess(c, http.StatusCreated, NewIdentityResponse(identity))
+}
+
+func (h *AuthGinHandler) createIdentity(ctx context.Context, userID uuid.UUID, info oauth.UserInfo) (core.Identity, error) {
+	return h.identities.CreateIdentity(ctx, core.Identity{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Provider: info.Provider,
+		Subject:  info.Subject,
+		Email:    info.Email,
+		LinkedAt: time.Now(),
+	})
+}
+
+// currentUserIdentities loads the user of the login session (see loginSession) and their
+// linked identities, writing the error response and returning false on failure.
+func (h *AuthGinHandler) currentUserIdentities(c *gin.Context) (core.User, []core.Identity, bool) {
+	if h.identities == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Identity linking is not enabled", nil)
+		return core.User{}, nil, false
+	}
+	authPayload, ok := loginSession(c)
+	if !ok {
+		return core.User{}, nil, false
+	}
+	user, err := h.store.GetUserByID(c.Request.Context(), authPayload.UserID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return core.User{}, nil, false
+	}
+	identities, err := h.identities.ListIdentitiesByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return core.User{}, nil, false
+	}
+	return user, identities, true
+}
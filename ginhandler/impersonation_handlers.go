@@ -0,0 +1,256 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// ImpersonateUserHandler lets an administrator act as another user, e.g. to debug a
+// support issue. It mints a token for the user that lasts AuthConfig.ImpersonationDuration
+// and names the administrator as its actor ("act" claim), so handlers can tell it apart
+// (see IsImpersonated and BlockImpersonation). Every impersonation is written to the audit
+// log with its reason. Administrators and users holding a role the administrator does not
+// hold cannot be impersonated. It must run behind AuthMiddleware and GlobalRoleMiddleware(AdminRole).
+func (h *AuthGinHandler) ImpersonateUserHandler(c *gin.Context) {
+	if h.auditLog == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Impersonation requires an audit log", nil)
+		return
+	}
+
+	var req ImpersonateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || !authPayload.IsLoginToken() {
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return
+	}
+	if authPayload.IsImpersonated() { // No impersonation chains
+		MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+		return
+	}
+
+	targetID, _ := uuid.Parse(req.UserID) // Validated by binding
+	if targetID == authPayload.UserID {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_TARGET", "You cannot impersonate yourself", nil)
+		return
+	}
+	target, err := h.store.GetUserByID(c.Request.Context(), targetID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if containsRoleFold(target.AllRoles(), h.config.AdminRole) {
+		RespondWithError(c, http.StatusForbidden, "FORBIDDEN", "Administrators cannot be impersonated", nil)
+		return
+	}
+	// Roles can inherit the admin role under other names, so the token may not carry
+	// any role the administrator does not hold
+	if role := roleNotHeld(authPayload, target.AllRoles()); role != "" {
+		RespondWithError(c, http.StatusForbidden, "FORBIDDEN", fmt.Sprintf("You cannot impersonate users with the role '%s'", role), nil)
+		return
+	}
+	if target.IsServiceAccount() {
+		RespondWithError(c, http.StatusForbidden, "FORBIDDEN", "Service accounts cannot be impersonated", nil)
+		return
+	}
+	if err := service.UserStatusError(target); err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	claims, err := h.auth.EnrichClaims(c.Request.Context(), target)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	accessToken, payload, err := h.auth.CreateToken(target.ID, target.Username, target.Role, h.config.ImpersonationDuration,
+		token.WithRoles(target.Roles...),
+		token.WithClaims(claims),
+		token.WithGrantType(token.GrantTypeImpersonation),
+		token.WithActor(token.Actor{Subject: authPayload.UserID, Username: authPayload.Username}),
+		token.WithPrincipalType(token.PrincipalUser),
+	)
+	if err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to create impersonation token: %w", err))
+		return
+	}
+
+	// The audit record is a precondition: no trail, no token
+	err = h.recordAudit(c, core.AuditEvent{
+		Type:      core.AuditImpersonationStarted,
+		ActorID:   authPayload.UserID,
+		SubjectID: target.ID,
+		Reason:    req.Reason,
+		Metadata:  map[string]string{"token_id": payload.TokenID.String(), "expires_at": payload.ExpiredAt.Format(time.RFC3339)},
+	})
+	if err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to record audit event: %w", err))
+		return
+	}
+
+	RespondWithSuccess(c, http.StatusOK, ImpersonationResponse{
+		AccessToken:    accessToken,
+		User:           NewSDKUserResponse(target),
+		ImpersonatorID: authPayload.UserID.String(),
+		ExpiresAt:      payload.ExpiredAt,
+	})
+}
+
+// tokenExchangeGrant implements RFC 8693 token exchange for access tokens. A service
+// trades the token it received for one issued to itself, optionally with fewer scopes,
+// to call other services on the subject's behalf. The service (or the party of the
+// actor_token) becomes the new token's actor; an existing actor chain is preserved.
+// Sender-constrained subject tokens are only exchanged with their certificate or DPoP
+// key, and the new token carries the requested scopes but none of the subject's roles.
+func (h *AuthGinHandler) tokenExchangeGrant(c *gin.Context, client core.OAuthClient) (OAuthTokenResponse, error) {
+	if client.IsPublic() {
+		return OAuthTokenResponse{}, oauthserver.ErrUnauthorizedClient("public clients may not exchange tokens")
+	}
+	subjectToken := c.PostForm("subject_token")
+	if subjectToken == "" {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidRequest("subject_token is required")
+	}
+	if c.PostForm("subject_token_type") != core.TokenTypeAccessToken {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidRequest("subject_token_type must be " + core.TokenTypeAccessToken)
+	}
+	if requested := c.PostForm("requested_token_type"); requested != "" && requested != core.TokenTypeAccessToken {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidRequest("only access tokens can be issued")
+	}
+
+	ctx := c.Request.Context()
+	subject, active, err := h.activeAccessToken(ctx, subjectToken)
+	if err != nil {
+		return OAuthTokenResponse{}, err
+	}
+	if !active {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("subject_token is invalid or inactive")
+	}
+
+	actor := token.Actor{Subject: client.ID, Username: client.ClientID, ClientID: client.ClientID}
+	if actorToken := c.PostForm("actor_token"); actorToken != "" {
+		if c.PostForm("actor_token_type") != core.TokenTypeAccessToken {
+			return OAuthTokenResponse{}, oauthserver.ErrInvalidRequest("actor_token_type must be " + core.TokenTypeAccessToken)
+		}
+		actorPayload, active, err := h.activeAccessToken(ctx, actorToken)
+		if err != nil {
+			return OAuthTokenResponse{}, err
+		}
+		if !active {
+			return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("actor_token is invalid or inactive")
+		}
+		actor = token.Actor{Subject: actorPayload.UserID, Username: actorPayload.Username, ClientID: actorPayload.ClientID}
+	}
+	actor.Actor = subject.Actor
+
+	// Exchanged tokens can only narrow what the subject token allows.
+	// A first-party login token carries the user's full authority.
+	allowed := subject.Scopes()
+	if subject.ClientID == "" {
+		allowed = client.Scopes
+	}
+	scopes := oauthserver.ParseScope(c.PostForm("scope"))
+	if len(scopes) == 0 {
+		scopes = allowed
+	}
+	if !oauthserver.ContainsAll(allowed, scopes) || !oauthserver.ContainsAll(client.Scopes, scopes) {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidScope("requested scope exceeds the subject token or the client's scopes")
+	}
+
+	// A sender-constrained subject token is only exchanged by its holder
+	cnf, err := h.requestConfirmation(c)
+	if err != nil {
+		return OAuthTokenResponse{}, err
+	}
+	if thumbprint := subject.CertificateThumbprint(); thumbprint != "" && cnf.X5tS256 != thumbprint {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("subject_token is bound to a client certificate the request did not present")
+	}
+	if thumbprint := subject.DPoPThumbprint(); thumbprint != "" && cnf.JKT != thumbprint {
+		return OAuthTokenResponse{}, oauthserver.ErrInvalidGrant("subject_token is bound to a DPoP key the request did not prove")
+	}
+
+	grantType := token.GrantTypeTokenExchange
+	if subject.IsClientCredentials() {
+		grantType = token.GrantTypeClientCredentials // The subject is still a client, not a user
+	}
+	duration := h.config.AccessTokenDuration
+	if remaining := time.Until(subject.ExpiredAt); remaining < duration {
+		duration = remaining
+	}
+	opts := []token.PayloadOption{
+		token.WithClientID(client.ClientID),
+		token.WithScope(scopes...),
+		token.WithGrantType(grantType),
+		token.WithActor(actor),
+		token.WithClaims(subject.Claims),
+		token.WithPrincipalType(subject.PrincipalType),
+	}
+	if subject.HasTenant() {
+		opts = append(opts, token.WithTenant(subject.TenantID))
+	}
+	if cnf != (token.Confirmation{}) { // Bound to the exchanging client's certificate and key
+		opts = append(opts, token.WithConfirmation(cnf))
+	}
+
+	// The audience parameter retargets the token at other services this server issues tokens for
+	if audience := c.PostFormArray("audience"); len(audience) > 0 {
+		if len(h.config.TokenAudience) > 0 && !oauthserver.ContainsAll(h.config.TokenAudience, audience) {
+			return OAuthTokenResponse{}, oauthserver.ErrInvalidTarget("requested audience is not served by this issuer")
+		}
+		opts = append(opts, token.WithAudience(audience...))
+	}
+
+	// The new token's authority is its scope alone: roles cannot be narrowed by scope, so
+	// none are carried over, not even for resource servers that read them from the claims
+	accessToken, payload, err := h.auth.CreateToken(subject.UserID, subject.Username, "", duration, opts...)
+	if err != nil {
+		return OAuthTokenResponse{}, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	if h.auditLog != nil {
+		err := h.recordAudit(c, core.AuditEvent{
+			Type:      core.AuditTokenExchanged,
+			ActorID:   actor.Subject,
+			SubjectID: subject.UserID,
+			ClientID:  client.ClientID,
+			Metadata:  map[string]string{"token_id": payload.TokenID.String(), "subject_token_id": subject.TokenID.String(), "scope": payload.Scope},
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to record token exchange audit event: %v\n", err)
+			// h.logger.Error("Failed to record audit event", "error", err)
+		}
+	}
+
+	return OAuthTokenResponse{
+		AccessToken:     accessToken,
+		TokenType:       accessTokenType(payload),
+		ExpiresIn:       int64(time.Until(payload.ExpiredAt).Seconds()),
+		Scope:           payload.Scope,
+		IssuedTokenType: core.TokenTypeAccessToken,
+	}, nil
+}
+
+// recordAudit writes an audit event, filling in the request metadata.
+func (h *AuthGinHandler) recordAudit(c *gin.Context, event core.AuditEvent) error {
+	if h.auditLog == nil {
+		return errors.New("audit log is not configured")
+	}
+	event.IPAddress = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+	event.CreatedAt = time.Now()
+	return h.auditLog.LogAuditEvent(context.WithoutCancel(c.Request.Context()), event)
+}
@@ -0,0 +1,162 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+type memoryUserStore struct {
+	core.UserStorer
+	users map[uuid.UUID]core.User
+}
+
+func (s memoryUserStore) GetUserByID(ctx context.Context, id uuid.UUID) (core.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return core.User{}, core.ErrNotFound
+	}
+	return user, nil
+}
+
+func (s memoryUserStore) GetUserByEmail(ctx context.Context, email string) (core.User, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return core.User{}, core.ErrNotFound
+}
+
+func TestTokenExchange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	maker, err := token.NewPasetoMaker(cfg.TokenSymmetricKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serviceAccount := core.User{ID: uuid.New(), Username: "reports", Role: "auditor", Roles: []string{"admin"}, Status: core.StatusActive}
+	users := memoryUserStore{users: map[uuid.UUID]core.User{serviceAccount.ID: serviceAccount}}
+	gateway := core.OAuthClient{ID: uuid.New(), ClientID: "gateway", SecretHash: "hash", Scopes: []string{"reports:read", "reports:write"}}
+	h := &AuthGinHandler{
+		config:       cfg,
+		tokenMaker:   maker,
+		store:        users,
+		oauthClients: memoryClientStore{clients: map[string]core.OAuthClient{"reports": {ClientID: "reports"}}},
+		auth:         service.New(users, maker, nil, nil, cfg),
+	}
+
+	subjectToken := func(opts ...token.PayloadOption) string {
+		opts = append([]token.PayloadOption{
+			token.WithClientID("reports"),
+			token.WithGrantType(token.GrantTypeClientCredentials),
+			token.WithPrincipalType(token.PrincipalService),
+			token.WithScope("reports:read", "reports:write"),
+		}, opts...)
+		opts = append(opts, token.WithRoles(serviceAccount.Roles...))
+		raw, _, err := maker.CreateToken(serviceAccount.ID, serviceAccount.Username, serviceAccount.Role, time.Minute, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	exchange := func(subject, scope string) (*token.Payload, error) {
+		form := url.Values{
+			"subject_token":      {subject},
+			"subject_token_type": {core.TokenTypeAccessToken},
+			"scope":              {scope},
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader(form.Encode()))
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := h.tokenExchangeGrant(c, gateway)
+		if err != nil {
+			return nil, err
+		}
+		return maker.VerifyToken(resp.AccessToken)
+	}
+
+	t.Run("roles are not carried over", func(t *testing.T) {
+		for _, scope := range []string{"", "reports:read"} {
+			payload, err := exchange(subjectToken(), scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payload.Role != "" || len(payload.Roles) != 0 || len(payload.TenantRoles) != 0 {
+				t.Errorf("scope %q: roles %q %v %v, want none", scope, payload.Role, payload.Roles, payload.TenantRoles)
+			}
+		}
+	})
+	t.Run("bound subject token needs its key", func(t *testing.T) {
+		_, err := exchange(subjectToken(token.WithConfirmation(token.Confirmation{JKT: "key-thumbprint"})), "")
+		var oauthErr *oauthserver.Error
+		if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" {
+			t.Fatalf("tokenExchangeGrant() = %v, want invalid_grant", err)
+		}
+	})
+}
+
+type discardAuditLog struct{}
+
+func (discardAuditLog) LogAuditEvent(ctx context.Context, event core.AuditEvent) error { return nil }
+
+func TestImpersonateUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	maker, err := token.NewPasetoMaker(cfg.TokenSymmetricKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := &token.Payload{UserID: uuid.New(), Username: "root", Role: cfg.AdminRole, Roles: []string{"support", "editor"}}
+
+	tests := []struct {
+		name  string
+		roles []string
+		want  int
+	}{
+		{"user with roles the admin holds", []string{"Editor"}, http.StatusOK},
+		{"administrator", []string{"ADMIN"}, http.StatusForbidden},
+		{"role inheriting admin under another name", []string{"superadmin"}, http.StatusForbidden},
+		{"role the admin does not hold", []string{"editor", "billing"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := core.User{ID: uuid.New(), Username: "bob", Role: tt.roles[0], Roles: tt.roles[1:], Status: core.StatusActive}
+			users := memoryUserStore{users: map[uuid.UUID]core.User{target.ID: target}}
+			h := &AuthGinHandler{
+				config:     cfg,
+				tokenMaker: maker,
+				store:      users,
+				auditLog:   discardAuditLog{},
+				auth:       service.New(users, maker, nil, nil, cfg),
+			}
+			r := gin.New()
+			r.POST("/impersonate", func(c *gin.Context) { setAuthPayload(c, admin) }, h.ImpersonateUserHandler)
+
+			body := `{"user_id":"` + target.ID.String() + `","reason":"support ticket"}`
+			req := httptest.NewRequest(http.MethodPost, "/impersonate", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
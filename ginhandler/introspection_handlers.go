@@ -0,0 +1,164 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/httphandler"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// IntrospectHandler is the token introspection endpoint (RFC 7662). Resource servers that
+// cannot verify tokens themselves post a token and learn whether it is active. Only
+// confidential clients may introspect, and only their own tokens unless they are
+// registered as resource servers; other tokens are reported inactive, like refresh tokens.
+func (h *AuthGinHandler) IntrospectHandler(c *gin.Context) {
+	if h.oauthServer == nil {
+		respondOAuthError(c, oauthserver.ErrInvalidRequest("the authorization server is not enabled"))
+		return
+	}
+	client, ok := h.authenticateClient(c, "introspect")
+	if !ok {
+		return
+	}
+	if client.IsPublic() {
+		respondOAuthError(c, oauthserver.ErrUnauthorizedClient("public clients may not introspect tokens"))
+		return
+	}
+	raw := c.PostForm("token")
+	if raw == "" {
+		respondOAuthError(c, oauthserver.ErrInvalidRequest("token is required"))
+		return
+	}
+
+	payload, active, err := h.activeAccessToken(c.Request.Context(), raw)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	if active && payload.ClientID != client.ClientID && !client.ResourceServer {
+		active = false
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if !active {
+		c.JSON(http.StatusOK, IntrospectionResponse{Active: false})
+		return
+	}
+	c.JSON(http.StatusOK, IntrospectionResponse{
+		Active:    true,
+		Scope:     payload.Scope,
+		ClientID:  payload.ClientID,
+		Username:  payload.Username,
+		TokenType: "Bearer",
+		Exp:       payload.ExpiredAt.Unix(),
+		Iat:       payload.IssuedAt.Unix(),
+		Nbf:       payload.NotBefore.Unix(),
+		Sub:       payload.UserID.String(),
+		Jti:       payload.TokenID.String(),
+		Role:      payload.Role,
+		Iss:       payload.Issuer,
+		Aud:       payload.Audience,
+		Act:       payload.Actor,
+		Tid:       payload.TenantID,
+		Pty:       payload.PrincipalType,
+		Cnf:       payload.Confirmation,
+	})
+}
+
+// RevokeHandler is the token revocation endpoint (RFC 7009). A client may revoke the
+// access and refresh tokens issued to it; first-party clients may also revoke login
+// tokens. Unknown or already invalid tokens are not an error.
+func (h *AuthGinHandler) RevokeHandler(c *gin.Context) {
+	if h.oauthServer == nil {
+		respondOAuthError(c, oauthserver.ErrInvalidRequest("the authorization server is not enabled"))
+		return
+	}
+	client, ok := h.authenticateClient(c, "revoke")
+	if !ok {
+		return
+	}
+	raw := c.PostForm("token")
+	if raw == "" {
+		respondOAuthError(c, oauthserver.ErrInvalidRequest("token is required"))
+		return
+	}
+
+	// Access tokens are self-describing, so the token_type_hint is not needed to tell them apart
+	var err error
+	if payload, verifyErr := h.verifyToken(raw); verifyErr == nil {
+		err = h.revokeAccessToken(c.Request.Context(), client, payload)
+	} else if !errors.Is(verifyErr, token.ErrExpiredToken) {
+		err = h.revokeRefreshToken(c.Request.Context(), client, raw)
+	}
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+func (h *AuthGinHandler) revokeAccessToken(ctx context.Context, client core.OAuthClient, payload *token.Payload) error {
+	if h.revocations == nil {
+		return oauthserver.ErrUnsupportedTokenType("access token revocation is not enabled")
+	}
+	if payload.ClientID != client.ClientID && !(payload.ClientID == "" && client.FirstParty) {
+		return oauthserver.ErrUnauthorizedClient("token was issued to another client")
+	}
+	if err := h.revocations.RevokeToken(ctx, payload.TokenID, payload.ExpiredAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (h *AuthGinHandler) revokeRefreshToken(ctx context.Context, client core.OAuthClient, raw string) error {
+	if h.refreshTokens == nil {
+		return nil
+	}
+	tokenHash := oauthserver.HashToken(raw)
+	stored, err := h.refreshTokens.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if stored.ClientID != client.ClientID {
+		return oauthserver.ErrUnauthorizedClient("token was issued to another client")
+	}
+	if _, err := h.refreshTokens.ConsumeRefreshToken(ctx, tokenHash); err != nil && !errors.Is(err, core.ErrNotFound) {
+		return fmt.Errorf("failed to delete refresh token: %w", err)
+	}
+	return nil
+}
+
+// activeAccessToken applies the same checks as AuthMiddleware (see
+// service.Authenticator.VerifyToken and CheckSession). Rejected tokens are inactive;
+// only storage failures are returned as errors.
+func (h *AuthGinHandler) activeAccessToken(ctx context.Context, raw string) (*token.Payload, bool, error) {
+	authenticator := service.NewAuthenticator(h.tokenMaker, h.store, h.config)
+	authenticator.Revocations = h.revocations
+	authenticator.Organizations = h.organizations
+	authenticator.OAuthClients = h.oauthClients
+
+	payload, err := authenticator.VerifyToken(ctx, raw)
+	if err == nil {
+		err = authenticator.CheckSession(ctx, payload, raw)
+	}
+	if err != nil {
+		if status, _ := httphandler.ErrorStatus(err); status >= http.StatusInternalServerError {
+			return nil, false, err
+		}
+		return nil, false, nil
+	}
+	return payload, true, nil
+}
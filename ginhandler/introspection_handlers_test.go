@@ -0,0 +1,88 @@
+package ginhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+type memoryClientStore struct {
+	core.OAuthClientStorer
+	clients map[string]core.OAuthClient
+}
+
+func (s memoryClientStore) GetOAuthClient(ctx context.Context, clientID string) (core.OAuthClient, error) {
+	client, ok := s.clients[clientID]
+	if !ok {
+		return core.OAuthClient{}, core.ErrNotFound
+	}
+	return client, nil
+}
+
+func TestIntrospectOtherClientsTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	maker, err := token.NewPasetoMaker(cfg.TokenSymmetricKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretHash := oauthserver.HashToken("secret")
+	clients := memoryClientStore{clients: map[string]core.OAuthClient{
+		"reports": {ClientID: "reports", SecretHash: secretHash},
+		"billing": {ClientID: "billing", SecretHash: secretHash},
+		"api":     {ClientID: "api", SecretHash: secretHash, ResourceServer: true},
+	}}
+	h := &AuthGinHandler{config: cfg, tokenMaker: maker, oauthServer: struct{ core.OAuthServerStorer }{}, oauthClients: clients}
+	r := gin.New()
+	r.POST("/introspect", h.IntrospectHandler)
+
+	reportsToken, _, err := maker.CreateToken(uuid.New(), "reports", "", time.Minute,
+		token.WithClientID("reports"), token.WithGrantType(token.GrantTypeClientCredentials))
+	if err != nil {
+		t.Fatal(err)
+	}
+	introspect := func(clientID, raw string) bool {
+		req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(url.Values{"token": {raw}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(clientID, "secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("introspection by %s: status %d: %s", clientID, w.Code, w.Body)
+		}
+		var resp IntrospectionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Active
+	}
+
+	if !introspect("reports", reportsToken) {
+		t.Error("the token's own client: inactive, want active")
+	}
+	if introspect("billing", reportsToken) {
+		t.Error("another client: active, want inactive")
+	}
+	if !introspect("api", reportsToken) {
+		t.Error("a resource server: inactive, want active")
+	}
+
+	delete(clients.clients, "reports")
+	if introspect("api", reportsToken) {
+		t.Error("token of a deleted client: active, want inactive")
+	}
+}
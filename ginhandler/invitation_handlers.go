@@ -0,0 +1,455 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// CreateInvitationHandler invites someone by email to the organization the request's
+// token is scoped to. The invitation lasts AuthConfig.InvitationDuration, and an
+// organization may have at most AuthConfig.MaxPendingInvitations pending. Protect it
+// with RoleMiddleware, e.g. RoleMiddleware(cfg.OrganizationOwnerRole).
+func (h *AuthGinHandler) CreateInvitationHandler(c *gin.Context) {
+	if !h.invitationsEnabled(c) {
+		return
+	}
+	var req CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	authPayload, org, ok := h.currentOrganization(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if existing, err := h.store.GetUserByEmail(ctx, email); err == nil {
+		if _, err := h.organizations.GetMembership(ctx, org.ID, existing.ID); err == nil {
+			MapSDKErrorToHTTP(c, core.ErrAlreadyMember)
+			return
+		} else if !errors.Is(err, core.ErrNotFound) {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+	} else if !errors.Is(err, core.ErrNotFound) {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	pending, err := h.invitations.ListInvitations(ctx, org.ID, core.InvitationPending)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	now := time.Now()
+	count := 0
+	for _, invitation := range pending {
+		if !invitation.IsPending(now) {
+			continue
+		}
+		if strings.EqualFold(invitation.Email, email) {
+			MapSDKErrorToHTTP(c, core.ErrInvitationPending) // Resend it instead
+			return
+		}
+		count++
+	}
+	if h.config.MaxPendingInvitations > 0 && count >= h.config.MaxPendingInvitations {
+		MapSDKErrorToHTTP(c, core.ErrInvitationLimit)
+		return
+	}
+
+	role := req.Role
+	if role == "" {
+		role = h.config.OrganizationMemberRole
+	}
+	rawToken, err := oauthserver.GenerateToken()
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	invitation := core.Invitation{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Email:          email,
+		Role:           role,
+		InvitedBy:      authPayload.UserID,
+		TokenHash:      oauthserver.HashToken(rawToken),
+		Status:         core.InvitationPending,
+		ExpiresAt:      now.Add(h.config.InvitationDuration),
+		CreatedAt:      now,
+	}
+	if err := h.invitations.CreateInvitation(ctx, invitation); err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to create invitation: %w", err))
+		return
+	}
+
+	h.sendInvitationEmail(invitation, org.Name, authPayload.Username, rawToken)
+	RespondWithSuccess(c, http.StatusCreated, NewInvitationResponse(invitation))
+}
+
+// ListInvitationsHandler lists the pending invitations of the current organization.
+// Protect it like CreateInvitationHandler.
+func (h *AuthGinHandler) ListInvitationsHandler(c *gin.Context) {
+	if !h.invitationsEnabled(c) {
+		return
+	}
+	_, org, ok := h.currentOrganization(c)
+	if !ok {
+		return
+	}
+	invitations, err := h.invitations.ListInvitations(c.Request.Context(), org.ID, core.InvitationPending)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	now := time.Now()
+	resp := make([]InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		if invitation.IsPending(now) {
+			resp = append(resp, NewInvitationResponse(invitation))
+		}
+	}
+	RespondWithSuccess(c, http.StatusOK, resp)
+}
+
+// RevokeInvitationHandler revokes a pending invitation of the current organization
+// (route parameter "id"). Protect it like CreateInvitationHandler.
+func (h *AuthGinHandler) RevokeInvitationHandler(c *gin.Context) {
+	if !h.invitationsEnabled(c) {
+		return
+	}
+	_, org, ok := h.currentOrganization(c)
+	if !ok {
+		return
+	}
+	invitation, err := h.organizationInvitation(c.Request.Context(), org.ID, c.Param("id"))
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if err := h.respondToInvitation(c.Request.Context(), invitation, core.InvitationRevoked); err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: "Invitation revoked."})
+}
+
+// ResendInvitationHandler sends a pending invitation of the current organization again
+// (route parameter "id"), with a new link and a renewed expiry. The previous link stops
+// working. Protect it like CreateInvitationHandler.
+func (h *AuthGinHandler) ResendInvitationHandler(c *gin.Context) {
+	if !h.invitationsEnabled(c) {
+		return
+	}
+	authPayload, org, ok := h.currentOrganization(c)
+	if !ok {
+		return
+	}
+	invitation, err := h.organizationInvitation(c.Request.Context(), org.ID, c.Param("id"))
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	rawToken, err := oauthserver.GenerateToken()
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	invitation.TokenHash = oauthserver.HashToken(rawToken)
+	invitation.ExpiresAt = time.Now().Add(h.config.InvitationDuration)
+	if err := h.invitations.UpdateInvitation(c.Request.Context(), invitation); err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to update invitation: %w", err))
+		return
+	}
+
+	h.sendInvitationEmail(invitation, org.Name, authPayload.Username, rawToken)
+	RespondWithSuccess(c, http.StatusOK, NewInvitationResponse(invitation))
+}
+
+// AcceptInvitationHandler adds the authenticated user to the organization they were
+// invited to. The invitation must have been sent to the user's email address.
+// This handler relies on AuthMiddleware to have run and set the payload.
+func (h *AuthGinHandler) AcceptInvitationHandler(c *gin.Context) {
+	if !h.invitationsEnabled(c) {
+		return
+	}
+	var req InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || !authPayload.IsLoginToken() {
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return
+	}
+	if authPayload.IsImpersonated() {
+		MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+		return
+	}
+
+	ctx := c.Request.Context()
+	invitation, err := h.pendingInvitation(ctx, req.Token)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	user, err := h.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		MapSDKErrorToHTTP(c, core.ErrInvitationEmail)
+		return
+	}
+
+	org, membership, err := h.acceptInvitation(ctx, invitation, user)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, NewOrganizationResponse(org, membership, authPayload.TenantID))
+}
+
+// InvitationSignupHandler creates an account for the invited email address and accepts
+// the invitation. The account is active right away, since the invitation link proves
+// the email address. It responds with a login token scoped to the organization.
+// Invitees who already have an account log in and use AcceptInvitationHandler instead.
+func (h *AuthGinHandler) InvitationSignupHandler(c *gin.Context) {
+	if !h.invitationsEnabled(c) {
+		return
+	}
+	var req InvitationSignupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	invitation, err := h.pendingInvitation(ctx, req.Token)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if _, err := h.store.GetUserByEmail(ctx, invitation.Email); err == nil {
+		MapSDKErrorToHTTP(c, core.ErrDuplicateEmail)
+		return
+	} else if !errors.Is(err, core.ErrNotFound) {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	hashedPassword, err := h.hasher.Hash(req.Password)
+	if err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to hash password: %w", err))
+		return
+	}
+	user, err := h.store.CreateUser(ctx, core.CreateUserParams{
+		Email:        invitation.Email,
+		Username:     invitation.Email,
+		PasswordHash: hashedPassword,
+		PhoneNumber:  req.PhoneNumber,
+		FullName:     req.FullName,
+		Role:         h.config.DefaultUserRole,
+		Status:       core.StatusActive, // The invitation link proves the email address
+	})
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	_, membership, err := h.acceptInvitation(ctx, invitation, user)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	h.respondWithLoginToken(c, user, token.WithTenant(membership.OrganizationID.String(), membership.AllRoles()...))
+}
+
+// InvitationDetailsHandler describes a pending invitation for the token from an invitation
+// email, so the invitee can decide to accept, sign up or decline. It needs no login.
+func (h *AuthGinHandler) InvitationDetailsHandler(c *gin.Context) {
+	if !h.invitationsEnabled(c) {
+		return
+	}
+	var req InvitationTokenRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_QUERY", "Invalid query parameters", err.Error())
+		return
+	}
+	ctx := c.Request.Context()
+	invitation, err := h.pendingInvitation(ctx, req.Token)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	org, err := h.organizations.GetOrganization(ctx, invitation.OrganizationID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to get organization: %w", err))
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, InvitationDetailsResponse{
+		OrganizationName: org.Name,
+		Email:            invitation.Email,
+		Role:             invitation.Role,
+		ExpiresAt:        invitation.ExpiresAt,
+	})
+}
+
+// DeclineInvitationHandler declines an invitation. It needs no login: the token
+// from the invitation email is enough.
+func (h *AuthGinHandler) DeclineInvitationHandler(c *gin.Context) {
+	if !h.invitationsEnabled(c) {
+		return
+	}
+	var req InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	invitation, err := h.pendingInvitation(c.Request.Context(), req.Token)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if err := h.respondToInvitation(c.Request.Context(), invitation, core.InvitationDeclined); err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: "Invitation declined."})
+}
+
+func (h *AuthGinHandler) invitationsEnabled(c *gin.Context) bool {
+	if h.invitations == nil || h.organizations == nil || h.mailer == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Invitations are not enabled", nil)
+		return false
+	}
+	return true
+}
+
+// currentOrganization returns the organization the request's login token is scoped to.
+func (h *AuthGinHandler) currentOrganization(c *gin.Context) (*token.Payload, core.Organization, bool) {
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || !authPayload.IsLoginToken() {
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return nil, core.Organization{}, false
+	}
+	if authPayload.IsImpersonated() {
+		MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+		return nil, core.Organization{}, false
+	}
+	orgID, err := uuid.Parse(authPayload.TenantID)
+	if err != nil {
+		RespondWithError(c, http.StatusBadRequest, "NO_ORGANIZATION", "Switch to an organization first", nil)
+		return nil, core.Organization{}, false
+	}
+	org, err := h.organizations.GetOrganization(c.Request.Context(), orgID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return nil, core.Organization{}, false
+	}
+	return authPayload, org, true
+}
+
+// organizationInvitation loads a pending invitation of orgID by its ID.
+func (h *AuthGinHandler) organizationInvitation(ctx context.Context, orgID uuid.UUID, rawID string) (core.Invitation, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return core.Invitation{}, core.ErrInvitationNotFound
+	}
+	invitation, err := h.invitations.GetInvitation(ctx, id)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return core.Invitation{}, core.ErrInvitationNotFound
+		}
+		return core.Invitation{}, err
+	}
+	if invitation.OrganizationID != orgID || invitation.Status != core.InvitationPending {
+		return core.Invitation{}, core.ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+// pendingInvitation loads the invitation for a token from an invitation email.
+func (h *AuthGinHandler) pendingInvitation(ctx context.Context, rawToken string) (core.Invitation, error) {
+	invitation, err := h.invitations.GetInvitationByTokenHash(ctx, oauthserver.HashToken(rawToken))
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return core.Invitation{}, core.ErrInvitationNotFound
+		}
+		return core.Invitation{}, err
+	}
+	if !invitation.IsPending(time.Now()) {
+		return core.Invitation{}, core.ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+// acceptInvitation adds user to the invitation's organization and marks it accepted.
+func (h *AuthGinHandler) acceptInvitation(ctx context.Context, invitation core.Invitation, user core.User) (core.Organization, core.Membership, error) {
+	org, err := h.organizations.GetOrganization(ctx, invitation.OrganizationID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return core.Organization{}, core.Membership{}, core.ErrInvitationNotFound
+		}
+		return core.Organization{}, core.Membership{}, err
+	}
+	membership := core.Membership{
+		OrganizationID: org.ID,
+		UserID:         user.ID,
+		Role:           invitation.Role,
+		JoinedAt:       time.Now(),
+	}
+	if err := h.organizations.AddMember(ctx, membership); err != nil {
+		return core.Organization{}, core.Membership{}, err
+	}
+	if err := h.respondToInvitation(ctx, invitation, core.InvitationAccepted); err != nil {
+		return core.Organization{}, core.Membership{}, err
+	}
+	return org, membership, nil
+}
+
+func (h *AuthGinHandler) respondToInvitation(ctx context.Context, invitation core.Invitation, status core.InvitationStatus) error {
+	invitation.Status = status
+	invitation.RespondedAt = time.Now()
+	if err := h.invitations.UpdateInvitation(ctx, invitation); err != nil {
+		return fmt.Errorf("failed to update invitation: %w", err)
+	}
+	return nil
+}
+
+// sendInvitationEmail sends the invitation link in the background.
+func (h *AuthGinHandler) sendInvitationEmail(invitation core.Invitation, orgName, inviterName, rawToken string) {
+	invitationURI := h.config.InvitationURI
+	if invitationURI == "" {
+		invitationURI = h.invitationURI
+	}
+	if invitationURI == "" {
+		invitationURI = strings.TrimSuffix(h.config.AppBaseURL, "/") + DefaultRoutePaths().Invitation
+	}
+	link := fmt.Sprintf("%s?token=%s", invitationURI, url.QueryEscape(rawToken))
+
+	go func() {
+		err := h.mailer.SendInvitationEmail(context.Background(), invitation.Email, orgName, inviterName, link)
+		if err != nil {
+			// h.logger.Error("Failed to send invitation email", "error", err, "email", invitation.Email)
+			fmt.Printf("Error sending invitation email to %s: %v\n", invitation.Email, err)
+		}
+	}()
+}
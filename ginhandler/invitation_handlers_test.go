@@ -0,0 +1,178 @@
+package ginhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+type memoryInvitationStore struct {
+	core.InvitationStorer
+	invitations []core.Invitation
+}
+
+func (s *memoryInvitationStore) CreateInvitation(ctx context.Context, invitation core.Invitation) error {
+	s.invitations = append(s.invitations, invitation)
+	return nil
+}
+
+func (s *memoryInvitationStore) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (core.Invitation, error) {
+	for _, invitation := range s.invitations {
+		if invitation.TokenHash == tokenHash {
+			return invitation, nil
+		}
+	}
+	return core.Invitation{}, core.ErrNotFound
+}
+
+func (s *memoryInvitationStore) UpdateInvitation(ctx context.Context, invitation core.Invitation) error {
+	for i := range s.invitations {
+		if s.invitations[i].ID == invitation.ID {
+			s.invitations[i] = invitation
+			return nil
+		}
+	}
+	return core.ErrNotFound
+}
+
+func (s *memoryInvitationStore) ListInvitations(ctx context.Context, orgID uuid.UUID, status core.InvitationStatus) ([]core.Invitation, error) {
+	var invitations []core.Invitation
+	for _, invitation := range s.invitations {
+		if invitation.OrganizationID == orgID && invitation.Status == status {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+// sentInvitations records invitation emails, which are sent in the background.
+type sentInvitations struct {
+	core.EmailSender
+	to chan string
+}
+
+func (m sentInvitations) SendInvitationEmail(ctx context.Context, toEmail, organizationName, inviterName, invitationLink string) error {
+	m.to <- toEmail
+	return nil
+}
+
+func TestCreateInvitationLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.MaxPendingInvitations = 2
+	org := core.Organization{ID: uuid.New(), Name: "Acme"}
+	owner := &token.Payload{UserID: uuid.New(), Username: "owner", TenantID: org.ID.String()}
+	now := time.Now()
+	invitations := &memoryInvitationStore{invitations: []core.Invitation{
+		{ID: uuid.New(), OrganizationID: org.ID, Email: "pending@example.com", Status: core.InvitationPending, ExpiresAt: now.Add(time.Hour)},
+		{ID: uuid.New(), OrganizationID: org.ID, Email: "expired@example.com", Status: core.InvitationPending, ExpiresAt: now.Add(-time.Hour)},
+		{ID: uuid.New(), OrganizationID: org.ID, Email: "declined@example.com", Status: core.InvitationDeclined, ExpiresAt: now.Add(time.Hour)},
+		{ID: uuid.New(), OrganizationID: uuid.New(), Email: "elsewhere@example.com", Status: core.InvitationPending, ExpiresAt: now.Add(time.Hour)},
+	}}
+	mailer := sentInvitations{to: make(chan string, 10)}
+	h := &AuthGinHandler{
+		config:        cfg,
+		store:         memoryUserStore{users: map[uuid.UUID]core.User{}},
+		organizations: &memoryOrganizationStore{orgs: map[uuid.UUID]core.Organization{org.ID: org}},
+		invitations:   invitations,
+		mailer:        mailer,
+	}
+	r := gin.New()
+	r.POST("/invitations", func(c *gin.Context) { setAuthPayload(c, owner) }, h.CreateInvitationHandler)
+
+	steps := []struct {
+		email string
+		want  int
+		code  string
+	}{
+		{"Pending@Example.com", http.StatusConflict, "INVITATION_PENDING"},
+		{"first@example.com", http.StatusCreated, ""}, // Expired, declined and other organizations' invitations do not count
+		{"second@example.com", http.StatusTooManyRequests, "INVITATION_LIMIT_REACHED"},
+	}
+	for _, step := range steps {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/invitations", strings.NewReader(`{"email":"`+step.email+`"}`)))
+		if w.Code != step.want || !strings.Contains(w.Body.String(), step.code) {
+			t.Fatalf("invite %s: status = %d, want %d %s: %s", step.email, w.Code, step.want, step.code, w.Body)
+		}
+	}
+	if to := <-mailer.to; to != "first@example.com" {
+		t.Errorf("invitation sent to %q, want first@example.com", to)
+	}
+	if len(invitations.invitations) != 5 {
+		t.Errorf("%d invitations stored, want 5", len(invitations.invitations))
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	org := core.Organization{ID: uuid.New(), Name: "Acme"}
+	alice := core.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Status: core.StatusActive}
+	mallory := core.User{ID: uuid.New(), Username: "mallory", Email: "mallory@example.com", Status: core.StatusActive}
+	users := memoryUserStore{users: map[uuid.UUID]core.User{alice.ID: alice, mallory.ID: mallory}}
+	const rawToken = "invitation-token"
+
+	tests := []struct {
+		name string
+		user core.User
+		want int
+		code string
+	}{
+		{"invited user", alice, http.StatusOK, ""},
+		{"another user with the link", mallory, http.StatusForbidden, "INVITATION_EMAIL_MISMATCH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invitations := &memoryInvitationStore{invitations: []core.Invitation{{
+				ID:             uuid.New(),
+				OrganizationID: org.ID,
+				Email:          "Alice@Example.com",
+				Role:           "billing",
+				TokenHash:      oauthserver.HashToken(rawToken),
+				Status:         core.InvitationPending,
+				ExpiresAt:      time.Now().Add(time.Hour),
+			}}}
+			orgs := &memoryOrganizationStore{orgs: map[uuid.UUID]core.Organization{org.ID: org}}
+			h := &AuthGinHandler{
+				config:        config.DefaultAuthConfig(),
+				store:         users,
+				organizations: orgs,
+				invitations:   invitations,
+				mailer:        sentInvitations{},
+			}
+			r := gin.New()
+			r.POST("/invitations/accept", func(c *gin.Context) {
+				setAuthPayload(c, &token.Payload{UserID: tt.user.ID, Username: tt.user.Username})
+			}, h.AcceptInvitationHandler)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/invitations/accept", strings.NewReader(`{"token":"`+rawToken+`"}`)))
+			if w.Code != tt.want || !strings.Contains(w.Body.String(), tt.code) {
+				t.Fatalf("status = %d, want %d %s: %s", w.Code, tt.want, tt.code, w.Body)
+			}
+
+			accepted := tt.want == http.StatusOK
+			membership, err := orgs.GetMembership(context.Background(), org.ID, tt.user.ID)
+			if (err == nil) != accepted || (accepted && membership.Role != "billing") {
+				t.Errorf("membership = %+v, %v", membership, err)
+			}
+			wantStatus := core.InvitationPending
+			if accepted {
+				wantStatus = core.InvitationAccepted
+			}
+			if status := invitations.invitations[0].Status; status != wantStatus {
+				t.Errorf("invitation status = %s, want %s", status, wantStatus)
+			}
+		})
+	}
+}
@@ -10,96 +10,153 @@ import (
 
 	"github.com/shawgichan/go-authkit/config" // Adjust import path
 	"github.com/shawgichan/go-authkit/core"   // Adjust import path
-	"github.com/shawgichan/go-authkit/token"  // Adjust import path
+	"github.com/shawgichan/go-authkit/dpop"
+	"github.com/shawgichan/go-authkit/mtls"
+	"github.com/shawgichan/go-authkit/rbac"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token" // Adjust import path
 )
 
 const (
 	AuthorizationHeaderKey  = "authorization"
 	AuthorizationTypeBearer = "bearer"
+	AuthorizationTypeAPIKey = "apikey"
+	AuthorizationTypeDPoP   = "dpop"
 	AuthorizationPayloadKey = "authorization_payload" // Key for storing payload in Gin context
 )
 
-// AuthMiddleware creates a Gin middleware for request authorization.
-// It verifies the access token and checks the user's status and active session.
-func AuthMiddleware(tokenMaker token.Maker, userStorer core.UserStorer, cfg *config.AuthConfig) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authorizationHeader := c.GetHeader(AuthorizationHeaderKey)
-		if len(authorizationHeader) == 0 {
-			RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization header is not provided", nil)
-			return
-		}
+// MiddlewareOption configures optional checks of AuthMiddleware.
+type MiddlewareOption func(*middlewareOptions)
 
-		fields := strings.Fields(authorizationHeader)
-		if len(fields) < 2 {
-			RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid authorization header format", nil)
-			return
-		}
+type middlewareOptions struct {
+	requiredScopes []string
+	revocations    core.TokenRevocationStorer
+	verifyOptions  []token.VerifyOption
+	organizations  core.OrganizationStorer
+	oauthClients   core.OAuthClientStorer
+	apiKeys        core.APIKeyStorer
+	clientCerts    mtls.Source
+	dpopNonces     core.NonceStorer
+}
 
-		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != AuthorizationTypeBearer {
-			errDetails := fmt.Sprintf("Unsupported authorization type: %s", authorizationType)
-			RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unsupported authorization type", errDetails)
-			return
-		}
+// RequireScopes makes AuthMiddleware reject tokens issued to OAuth2 clients that
+// were not granted all of the given scopes. First-party login tokens carry the
+// user's full authority and are not scope restricted.
+func RequireScopes(scopes ...string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.requiredScopes = append(o.requiredScopes, scopes...)
+	}
+}
 
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
-		if err != nil {
-			sdkErr := core.ErrTokenInvalid             // Default to invalid
-			if errors.Is(err, token.ErrExpiredToken) { // Assuming your token.Maker returns a specific error for expiration
-				sdkErr = core.ErrTokenExpired
-			}
-			MapSDKErrorToHTTP(c, sdkErr) // Use the mapper for consistent error responses
-			return
-		}
+// CheckRevocation makes AuthMiddleware reject access tokens on the revocation list.
+func CheckRevocation(store core.TokenRevocationStorer) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.revocations = store
+	}
+}
 
-		// Fetch user from UserStorer using UserID from token payload
-		user, err := userStorer.GetUserByID(c.Request.Context(), payload.UserID)
+// CheckClients makes AuthMiddleware reject tokens issued to an OAuth2 client, on its own
+// behalf or a user's, once the client is deleted.
+func CheckClients(store core.OAuthClientStorer) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.oauthClients = store
+	}
+}
+
+// VerifyWith adds token verification options on top of the issuer, audience and leeway
+// from AuthConfig, e.g. token.ExpectAudience for a route group serving another audience.
+func VerifyWith(opts ...token.VerifyOption) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.verifyOptions = append(o.verifyOptions, opts...)
+	}
+}
+
+// CheckMembership makes AuthMiddleware reject tokens scoped to an organization the user
+// no longer belongs to, and evaluate the user's current roles there rather than the
+// roles recorded in the token.
+func CheckMembership(store core.OrganizationStorer) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.organizations = store
+	}
+}
+
+// AcceptAPIKeys makes AuthMiddleware also accept "Authorization: ApiKey <key>". The
+// request then acts as the key's user, restricted to the key's scopes (see RequireScopes
+// and ScopeMiddleware). Keys created in an organization are scoped to it; add
+// CheckMembership so they carry the user's roles there.
+func AcceptAPIKeys(store core.APIKeyStorer) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.apiKeys = store
+	}
+}
+
+// ClientCertificates sets where AuthMiddleware finds the client certificate that
+// certificate-bound tokens (RFC 8705 "cnf" claim) must be presented with. By default only
+// certificates verified by the server's own TLS listener are seen. Bound tokens are
+// always checked; a request without the matching certificate is rejected.
+func ClientCertificates(source mtls.Source) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.clientCerts = source
+	}
+}
+
+// AuthMiddleware creates a Gin middleware for request authorization.
+// It verifies the access token and checks the user's status and active session (see
+// service.Authenticator.AuthenticateRequest). In route groups using CookieTransport, the
+// access token may come from the session cookie.
+func AuthMiddleware(tokenMaker token.Maker, userStorer core.UserStorer, cfg *config.AuthConfig, opts ...MiddlewareOption) gin.HandlerFunc {
+	options := &middlewareOptions{verifyOptions: service.TokenVerifyOptions(cfg)}
+	for _, opt := range opts {
+		opt(options)
+	}
+	authenticator := service.NewAuthenticator(tokenMaker, userStorer, cfg)
+	authenticator.Revocations = options.revocations
+	authenticator.Organizations = options.organizations
+	authenticator.OAuthClients = options.oauthClients
+	authenticator.VerifyOptions = options.verifyOptions
+	authenticator.APIKeys = options.apiKeys
+	authenticator.ClientCerts = options.clientCerts
+	if options.dpopNonces != nil {
+		authenticator.DPoP = dpop.NewVerifier(options.dpopNonces, cfg.DPoPProofMaxAge, cfg.TokenLeeway)
+	}
+
+	return func(c *gin.Context) {
+		payload, err := authenticator.AuthenticateRequest(c.Request, c.GetBool(cookieTransportKey))
 		if err != nil {
-			if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
-				RespondWithError(c, http.StatusUnauthorized, "USER_NOT_FOUND", "Authenticated user not found or has been deleted", nil)
-				return
+			if errors.Is(err, core.ErrDPoPProofInvalid) {
+				c.Header("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
 			}
-			MapSDKErrorToHTTP(c, err) // Handle other potential DB errors
+			MapSDKErrorToHTTP(c, err) // Use the mapper for consistent error responses
 			return
 		}
-
-		// Check user status
-		if user.Status != core.StatusActive {
-			var sdkErr error
-			switch user.Status {
-			case core.StatusPending:
-				sdkErr = core.ErrUserNotVerified
-			case core.StatusSuspended:
-				sdkErr = core.ErrUserSuspended
-			default: // Other non-active statuses
-				sdkErr = errors.New("user account is not active") // Generic non-active
-			}
-			MapSDKErrorToHTTP(c, sdkErr)
+		if err := checkScopes(payload, options.requiredScopes); err != nil {
+			MapSDKErrorToHTTP(c, err)
 			return
 		}
 
-		// Enforce single-device login if configured
-		if cfg.EnforceSingleDeviceLogin {
-			if user.ActiveToken == "" { // User has no active token, meaning they've been logged out elsewhere or session expired
-				RespondWithError(c, http.StatusUnauthorized, "SESSION_EXPIRED", "Session expired, please login again (no active token)", nil)
-				return
-			}
-			if user.ActiveToken != accessToken {
-				RespondWithError(c, http.StatusUnauthorized, "MULTI_DEVICE_LOGIN", "User logged in with a different device or session", nil)
-				return
-			}
-		}
-
 		// Set payload in context for downstream handlers
-		c.Set(AuthorizationPayloadKey, payload)
+		setAuthPayload(c, payload)
 		c.Next()
 	}
 }
 
 // RoleMiddleware creates a Gin middleware for role-based access control.
+// It passes if any of the user's roles is allowed; it does not follow role inheritance
+// (use RequirePermission for that). For tokens scoped to an organization it evaluates
+// the user's roles in that organization (see token.Payload.EffectiveRoles).
 // It should be used *after* AuthMiddleware.
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
+	return roleMiddleware((*token.Payload).EffectiveRoles, allowedRoles)
+}
+
+// GlobalRoleMiddleware is like RoleMiddleware but always evaluates the user's global
+// roles, whatever organization the token is scoped to. Use it for platform-wide areas
+// such as administration, so an organization role with the same name does not grant access.
+func GlobalRoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
+	return roleMiddleware((*token.Payload).AllRoles, allowedRoles)
+}
+
+func roleMiddleware(rolesOf func(*token.Payload) []string, allowedRoles []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		payload, exists := GetAuthPayload(c)
 		if !exists {
@@ -108,16 +165,17 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 			return
 		}
 
+		roles := rolesOf(payload)
 		roleAllowed := false
 		for _, allowedRole := range allowedRoles {
-			if strings.EqualFold(payload.Role, allowedRole) {
+			if containsRoleFold(roles, allowedRole) {
 				roleAllowed = true
 				break
 			}
 		}
 
 		if !roleAllowed {
-			errDetails := fmt.Sprintf("Access denied. Your role '%s' is not in allowed roles: %v", payload.Role, allowedRoles)
+			errDetails := fmt.Sprintf("Access denied. Your roles %v are not in allowed roles: %v", roles, allowedRoles)
 			RespondWithError(c, http.StatusForbidden, "FORBIDDEN", "You do not have permission to access this resource", errDetails)
 			return
 		}
@@ -125,12 +183,112 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
-// GetAuthPayload retrieves the authorization payload from the Gin context.
+// RequirePermission creates a Gin middleware that requires the user's roles to grant all
+// permissions under policy, directly or through inheritance. Like RoleMiddleware, it
+// evaluates the roles in the token's organization, if any.
+// It should be used *after* AuthMiddleware.
+func RequirePermission(policy *rbac.Policy, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, exists := GetAuthPayload(c)
+		if !exists {
+			RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization payload not found for permission check", nil)
+			return
+		}
+		for _, permission := range permissions {
+			if !policy.HasPermission(payload.EffectiveRoles(), permission) {
+				MapSDKErrorToHTTP(c, fmt.Errorf("%w: missing '%s'", core.ErrPermissionDenied, permission))
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// HasPermission reports whether the authenticated user's roles grant permission under policy,
+// for checks inside handlers.
+func HasPermission(c *gin.Context, policy *rbac.Policy, permission string) bool {
+	payload, exists := GetAuthPayload(c)
+	return exists && policy.HasPermission(payload.EffectiveRoles(), permission)
+}
+
+func containsRoleFold(roles []string, role string) bool {
+	for _, r := range roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeMiddleware creates a Gin middleware that requires OAuth2 client tokens to have all scopes.
+// It should be used *after* AuthMiddleware. See RequireScopes.
+func ScopeMiddleware(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, exists := GetAuthPayload(c)
+		if !exists {
+			RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization payload not found for scope check", nil)
+			return
+		}
+		if err := checkScopes(payload, scopes); err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// BlockImpersonation creates a Gin middleware that rejects impersonated and delegated
+// tokens (see token.Payload.IsImpersonated), for routes performing dangerous actions.
+// It should be used *after* AuthMiddleware.
+func BlockImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsImpersonated(c) {
+			MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireLogin creates a Gin middleware that only admits tokens of a user's own login
+// session: API keys, OAuth2 client and delegated tokens, and impersonated tokens are
+// rejected. Use it for account and administration routes. It should be used *after*
+// AuthMiddleware.
+func RequireLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := loginSession(c); !ok {
+			return
+		}
+		c.Next()
+	}
+}
+
+func checkScopes(payload *token.Payload, required []string) error {
+	if payload.IsLoginToken() {
+		return nil
+	}
+	for _, scope := range required {
+		if !payload.HasScope(scope) {
+			return fmt.Errorf("%w: missing '%s'", core.ErrInsufficientScope, scope)
+		}
+	}
+	return nil
+}
+
+// setAuthPayload stores the verified payload in the Gin context and in the request's
+// context.Context, where framework-neutral code finds it with token.FromContext.
+func setAuthPayload(c *gin.Context, payload *token.Payload) {
+	c.Set(AuthorizationPayloadKey, payload)
+	c.Request = c.Request.WithContext(token.NewContext(c.Request.Context(), payload))
+}
+
+// GetAuthPayload retrieves the authorization payload from the Gin context, or from the
+// request's context.Context if it was authenticated by net/http middleware.
 // Returns the payload and true if found, otherwise nil and false.
 func GetAuthPayload(c *gin.Context) (*token.Payload, bool) {
 	payloadVal, exists := c.Get(AuthorizationPayloadKey)
 	if !exists {
-		return nil, false
+		return token.FromContext(c.Request.Context())
 	}
 	payload, ok := payloadVal.(*token.Payload) // Ensure type assertion from your token package
 	if !ok {
@@ -139,3 +297,11 @@ func GetAuthPayload(c *gin.Context) (*token.Payload, bool) {
 	}
 	return payload, true
 }
+
+// IsImpersonated reports whether the request was authenticated with a token someone
+// other than its subject is acting with: an administrator's impersonation token or an
+// exchanged token. The acting party is in the payload's Actor.
+func IsImpersonated(c *gin.Context) bool {
+	payload, exists := GetAuthPayload(c)
+	return exists && payload.IsImpersonated()
+}
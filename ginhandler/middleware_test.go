@@ -0,0 +1,72 @@
+package ginhandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shawgichan/go-authkit/token"
+)
+
+func TestRequireLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		payload *token.Payload
+		want    int
+	}{
+		{"login", &token.Payload{Username: "alice", Role: "admin"}, http.StatusOK},
+		{"api key", &token.Payload{Username: "alice", Role: "admin", GrantType: token.GrantTypeAPIKey}, http.StatusUnauthorized},
+		{"client credentials", &token.Payload{ClientID: "svc", GrantType: token.GrantTypeClientCredentials}, http.StatusUnauthorized},
+		{"delegated", &token.Payload{Username: "alice", ClientID: "app"}, http.StatusUnauthorized},
+		{"impersonated", &token.Payload{Username: "bob", GrantType: token.GrantTypeImpersonation, Actor: &token.Actor{Username: "alice"}}, http.StatusForbidden},
+		{"anonymous", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/admin", func(c *gin.Context) {
+				if tt.payload != nil {
+					setAuthPayload(c, tt.payload)
+				}
+			}, RequireLogin(), func(c *gin.Context) { c.Status(http.StatusOK) })
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleMiddlewareTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	global := &token.Payload{Username: "alice", Role: "user", Roles: []string{"support"}}
+	acme := &token.Payload{Username: "alice", Role: "user", Roles: []string{"support"}, TenantID: "acme", TenantRoles: []string{"Admin"}}
+	tests := []struct {
+		name       string
+		payload    *token.Payload
+		middleware gin.HandlerFunc
+		want       int
+	}{
+		{"global role without organization", global, RoleMiddleware("support"), http.StatusOK},
+		{"organization role without organization", global, RoleMiddleware("admin"), http.StatusForbidden},
+		{"organization role in organization", acme, RoleMiddleware("admin"), http.StatusOK},
+		{"global role in organization", acme, RoleMiddleware("support"), http.StatusForbidden},
+		{"global middleware ignores organization roles", acme, GlobalRoleMiddleware("admin"), http.StatusForbidden},
+		{"global middleware in organization", acme, GlobalRoleMiddleware("support"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/billing", func(c *gin.Context) { setAuthPayload(c, tt.payload) }, tt.middleware, func(c *gin.Context) { c.Status(http.StatusOK) })
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/billing", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package ginhandler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/mtls"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// ClientCertMiddleware authenticates requests by TLS client certificate instead of a
+// token. The certificate comes from source, and mapper maps it to a user or service
+// account, whose status is checked. The payload set in the context carries the user's
+// roles, so RoleMiddleware and RequirePermission work as with tokens, and the
+// certificate thumbprint as its confirmation claim. Payloads built from a certificate
+// are not login tokens (see token.Payload.IsClientCertificate).
+func ClientCertMiddleware(source mtls.Source, mapper mtls.Mapper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cert, err := source.Certificate(c.Request)
+		if err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		user, err := mapper.MapCertificate(c.Request.Context(), cert)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
+				err = core.ErrInvalidCredentials
+			}
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		if err := service.UserStatusError(user); err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+
+		payload := &token.Payload{
+			TokenID:       uuid.New(),
+			UserID:        user.ID,
+			Username:      user.Username,
+			Role:          user.Role,
+			Roles:         user.Roles,
+			IssuedAt:      time.Now(),
+			NotBefore:     cert.NotBefore,
+			ExpiredAt:     cert.NotAfter,
+			GrantType:     token.GrantTypeClientCertificate,
+			PrincipalType: service.PrincipalType(user),
+			Confirmation:  &token.Confirmation{X5tS256: mtls.Thumbprint(cert)},
+		}
+		setAuthPayload(c, payload)
+		c.Next()
+	}
+}
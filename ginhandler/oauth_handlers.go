@@ -0,0 +1,258 @@
+package ginhandler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauth"
+	"github.com/shawgichan/go-authkit/oauthserver"
+)
+
+// OAuthLoginHandler starts a social login by redirecting to the provider named in the
+// ":provider" route parameter. It generates and stores the state, nonce and PKCE verifier.
+func (h *AuthGinHandler) OAuthLoginHandler(c *gin.Context) {
+	provider, ok := h.oauthProvider(c)
+	if !ok {
+		return
+	}
+
+	authURL, err := h.startOAuth(c, provider, uuid.Nil)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OAuthCallbackHandler completes a social login. It validates the state, exchanges the
+// code and logs the user in, creating an account on first sign-in or linking to the
+// existing account with the same verified email. It responds with a TokenResponse.
+func (h *AuthGinHandler) OAuthCallbackHandler(c *gin.Context) {
+	provider, ok := h.oauthProvider(c)
+	if !ok {
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		RespondWithError(c, http.StatusUnauthorized, "OAUTH_DENIED", "Login was cancelled or denied by the provider",
+			fmt.Sprintf("%s: %s", providerErr, c.Query("error_description")))
+		return
+	}
+	code, stateParam := c.Query("code"), c.Query("state")
+	if code == "" || stateParam == "" {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_QUERY", "code and state query parameters are required", nil)
+		return
+	}
+
+	state, err := h.consumeOAuthState(c, stateParam, provider.Name())
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if state.LinkUserID != uuid.Nil && !h.sameLinkSession(c, state.LinkUserID) {
+		MapSDKErrorToHTTP(c, core.ErrForbidden)
+		return
+	}
+
+	info, err := provider.Exchange(c.Request.Context(), code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		// h.logger.Error("OAuth exchange failed", "error", err, "provider", provider.Name())
+		RespondWithError(c, http.StatusUnauthorized, "OAUTH_EXCHANGE_FAILED", "Could not complete login with the provider", err.Error())
+		return
+	}
+
+	if state.LinkUserID != uuid.Nil {
+		h.completeIdentityLink(c, state.LinkUserID, info)
+		return
+	}
+
+	user, err := h.findOrCreateOAuthUser(c.Request.Context(), provider, info)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	if !h.checkLoginStatus(c, user) {
+		return
+	}
+	h.respondWithLoginToken(c, user)
+}
+
+// oauthProvider looks up the provider from the route, writing an error response if it is unknown.
+func (h *AuthGinHandler) oauthProvider(c *gin.Context) (oauth.Provider, bool) {
+	if h.oauthProviders == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Social login is not enabled", nil)
+		return nil, false
+	}
+	provider, ok := h.oauthProviders.Get(c.Param("provider"))
+	if !ok {
+		MapSDKErrorToHTTP(c, core.ErrOAuthProviderNotFound)
+		return nil, false
+	}
+	return provider, true
+}
+
+// startOAuth generates and stores the state, nonce and PKCE verifier for a new
+// authorization request and returns the provider URL to redirect the user to. The state
+// is bound to the browser through the AuthConfig.OAuthBindingCookie cookie, so that a
+// provider URL sent to someone else cannot complete the login or link in their browser.
+func (h *AuthGinHandler) startOAuth(c *gin.Context, provider oauth.Provider, linkUserID uuid.UUID) (string, error) {
+	state, nonce, verifier, err := newOAuthSecrets()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate oauth state: %w", err)
+	}
+	// Reuse the browser's binding, so that logins started in several tabs all complete
+	binding, err := c.Cookie(h.config.OAuthBindingCookie)
+	if err != nil || binding == "" {
+		if binding, err = oauthserver.GenerateToken(); err != nil {
+			return "", fmt.Errorf("failed to generate oauth state: %w", err)
+		}
+	}
+
+	err = h.oauthStates.StoreOAuthState(c.Request.Context(), core.OAuthState{
+		State:        state,
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		BindingHash:  oauthserver.HashToken(binding),
+		ExpiresAt:    time.Now().Add(h.config.OAuthStateDuration),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store oauth state: %w", err)
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     h.config.OAuthBindingCookie,
+		Value:    binding,
+		Path:     h.config.CookiePath,
+		Domain:   h.config.CookieDomain,
+		MaxAge:   int(h.config.OAuthStateDuration.Seconds()),
+		Secure:   !h.config.CookieInsecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode, // Sent on the provider's top-level redirect back
+	})
+	return provider.AuthCodeURL(state, nonce, oauth.CodeChallengeS256(verifier)), nil
+}
+
+// consumeOAuthState loads the single-use state and checks it belongs to this provider, has
+// not expired and was started in this browser.
+func (h *AuthGinHandler) consumeOAuthState(c *gin.Context, stateParam, providerName string) (core.OAuthState, error) {
+	state, err := h.oauthStates.ConsumeOAuthState(c.Request.Context(), stateParam)
+	if err != nil {
+		return core.OAuthState{}, err
+	}
+	if state.Provider != providerName || time.Now().After(state.ExpiresAt) {
+		return core.OAuthState{}, core.ErrOAuthStateNotFound
+	}
+	binding, err := c.Cookie(h.config.OAuthBindingCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(oauthserver.HashToken(binding)), []byte(state.BindingHash)) != 1 {
+		return core.OAuthState{}, core.ErrOAuthStateNotFound
+	}
+	return state, nil
+}
+
+// sameLinkSession reports whether the callback of a link started by linkUserID may
+// complete: a browser with a cookie session must be logged in as that user.
+func (h *AuthGinHandler) sameLinkSession(c *gin.Context, linkUserID uuid.UUID) bool {
+	if h.sessionTokens == nil {
+		return true
+	}
+	raw, err := c.Cookie(h.config.AccessTokenCookieName)
+	if err != nil || raw == "" {
+		return true
+	}
+	payload, err := h.verifyToken(raw)
+	return err != nil || payload.UserID == linkUserID
+}
+
+// findOrCreateOAuthUser maps a provider identity to a core.User. A previously linked identity
+// always wins. Otherwise only verified emails are trusted, or anyone could take over an
+// account by registering its email at a provider.
+func (h *AuthGinHandler) findOrCreateOAuthUser(ctx context.Context, provider oauth.Provider, info oauth.UserInfo) (core.User, error) {
+	if h.identities != nil {
+		identity, err := h.identities.GetIdentity(ctx, info.Provider, info.Subject)
+		if err == nil {
+			return h.store.GetUserByID(ctx, identity.UserID)
+		}
+		if !errors.Is(err, core.ErrNotFound) {
+			return core.User{}, err
+		}
+	}
+
+	user, err := h.findOrCreateOAuthUserByEmail(ctx, provider, info)
+	if err != nil {
+		return core.User{}, err
+	}
+
+	if h.identities != nil {
+		if _, err := h.createIdentity(ctx, user.ID, info); err != nil {
+			// h.logger.Error("Failed to link identity", "error", err, "user_id", user.ID)
+			fmt.Printf("Warning: Failed to link %s identity to %s: %v\n", info.Provider, user.Email, err)
+		}
+	}
+	return user, nil
+}
+
+// findOrCreateOAuthUserByEmail signs in to the account with the identity's email, if the
+// provider is allowed to link it (see oauth.Config.CanLinkEmail), or creates a new account.
+func (h *AuthGinHandler) findOrCreateOAuthUserByEmail(ctx context.Context, provider oauth.Provider, info oauth.UserInfo) (core.User, error) {
+	if info.Email == "" || !info.EmailVerified {
+		return core.User{}, core.ErrEmailNotVerified
+	}
+
+	user, err := h.store.GetUserByEmail(ctx, info.Email)
+	if err == nil {
+		if !canLinkEmail(provider, info) {
+			return core.User{}, core.ErrDuplicateEmail // Sign in and link the identity from the account instead
+		}
+		// Existing account. The provider has verified the email, so a pending account can be activated.
+		// Its password was never proven to belong to the email owner, so it is cleared
+		// to prevent pre-registration account takeover.
+		if user.Status == core.StatusPending {
+			activeStatus := core.StatusActive
+			noPassword := ""
+			user, err = h.store.UpdateUser(ctx, user.ID, core.UpdateUserParams{Status: &activeStatus, PasswordHash: &noPassword})
+			if err != nil {
+				return core.User{}, fmt.Errorf("failed to activate user: %w", err)
+			}
+		}
+		return user, nil
+	}
+	if !errors.Is(err, core.ErrNotFound) {
+		return core.User{}, err
+	}
+
+	// First sign-in, create the account. A mapped role never grants administration.
+	if strings.EqualFold(info.Role, h.config.AdminRole) {
+		info.Role = ""
+	}
+	return h.store.CreateUser(ctx, info.CreateUserParams(h.config.DefaultUserRole))
+}
+
+// canLinkEmail applies the linking policy of providers built on oauth.Client. Other
+// providers never link by email.
+func canLinkEmail(provider oauth.Provider, info oauth.UserInfo) bool {
+	configured, ok := provider.(interface{ Config() oauth.Config })
+	return ok && configured.Config().CanLinkEmail(info)
+}
+
+func newOAuthSecrets() (state, nonce, verifier string, err error) {
+	if state, err = oauth.GenerateState(); err != nil {
+		return
+	}
+	if nonce, err = oauth.GenerateState(); err != nil {
+		return
+	}
+	verifier, err = oauth.GenerateCodeVerifier()
+	return
+}
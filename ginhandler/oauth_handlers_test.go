@@ -0,0 +1,140 @@
+package ginhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauth"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// staticProvider logs everyone in as the same provider identity.
+type staticProvider struct{ info oauth.UserInfo }
+
+func (p staticProvider) Name() string { return p.info.Provider }
+
+func (p staticProvider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	return "https://idp.example.com/authorize?" + url.Values{"state": {state}}.Encode()
+}
+
+func (p staticProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (oauth.UserInfo, error) {
+	return p.info, nil
+}
+
+type memoryOAuthStateStore struct{ states map[string]core.OAuthState }
+
+func (s memoryOAuthStateStore) StoreOAuthState(ctx context.Context, state core.OAuthState) error {
+	s.states[state.State] = state
+	return nil
+}
+
+func (s memoryOAuthStateStore) ConsumeOAuthState(ctx context.Context, state string) (core.OAuthState, error) {
+	stored, ok := s.states[state]
+	if !ok {
+		return core.OAuthState{}, core.ErrOAuthStateNotFound
+	}
+	delete(s.states, state)
+	return stored, nil
+}
+
+type memoryIdentityStore struct {
+	core.IdentityStorer
+	identities []core.Identity
+}
+
+func (s *memoryIdentityStore) GetIdentity(ctx context.Context, provider, subject string) (core.Identity, error) {
+	for _, identity := range s.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return core.Identity{}, core.ErrNotFound
+}
+
+func (s *memoryIdentityStore) CreateIdentity(ctx context.Context, identity core.Identity) (core.Identity, error) {
+	s.identities = append(s.identities, identity)
+	return identity, nil
+}
+
+func TestOAuthCallbackBrowserBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	maker, err := token.NewPasetoMaker(cfg.TokenSymmetricKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attacker, victim := uuid.New(), uuid.New()
+	provider := staticProvider{info: oauth.UserInfo{Provider: "idp", Subject: "victim-at-idp", Email: "victim@example.com", EmailVerified: true}}
+
+	tests := []struct {
+		name          string
+		sendBinding   bool
+		sessionUserID uuid.UUID // Cookie session of the browser completing the link
+		want          int
+	}{
+		{"same browser", true, uuid.Nil, http.StatusCreated},
+		{"same browser, logged in as the linking user", true, attacker, http.StatusCreated},
+		{"link URL opened in another browser", false, uuid.Nil, http.StatusBadRequest},
+		{"link URL opened by another logged-in user", false, victim, http.StatusBadRequest},
+		{"binding cookie but logged in as another user", true, victim, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identities := &memoryIdentityStore{}
+			h := &AuthGinHandler{
+				config:         cfg,
+				tokenMaker:     maker,
+				oauthProviders: oauth.NewRegistry(provider),
+				oauthStates:    memoryOAuthStateStore{states: make(map[string]core.OAuthState)},
+				identities:     identities,
+				sessionTokens:  &recordingRefreshTokenStore{},
+			}
+
+			// The attacker starts linking an identity to their own account
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/identities/link", nil)
+			authURL, err := h.startOAuth(c, provider, attacker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, _ := url.Parse(authURL)
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != cfg.OAuthBindingCookie || !cookies[0].HttpOnly {
+				t.Fatalf("cookies = %v, want the HttpOnly binding cookie", cookies)
+			}
+
+			r := gin.New()
+			r.GET("/oauth/:provider/callback", h.OAuthCallbackHandler)
+			req := httptest.NewRequest(http.MethodGet, "/oauth/idp/callback?"+url.Values{"code": {"c"}, "state": {u.Query().Get("state")}}.Encode(), nil)
+			if tt.sendBinding {
+				req.AddCookie(cookies[0])
+			}
+			if tt.sessionUserID != uuid.Nil {
+				accessToken, _, err := maker.CreateToken(tt.sessionUserID, "user", "user", time.Hour)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.AddCookie(&http.Cookie{Name: cfg.AccessTokenCookieName, Value: accessToken})
+			}
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("callback status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if linked := len(identities.identities) == 1; linked != (tt.want == http.StatusCreated) {
+				t.Errorf("identities = %+v", identities.identities)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ginhandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// OIDCUserInfoResponse is the OpenID Connect userinfo response. Like the token endpoint,
+// it uses the standard format so that OIDC client libraries can consume it directly.
+type OIDCUserInfoResponse struct {
+	Subject string `json:"sub"`
+	oauthserver.UserInfoClaims
+}
+
+// OpenIDConfigurationHandler serves the discovery document. Mount it at
+// "/.well-known/openid-configuration" relative to the issuer.
+func (h *AuthGinHandler) OpenIDConfigurationHandler(c *gin.Context) {
+	if h.idTokenSigner == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "The OpenID provider is not enabled", nil)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, h.openIDConfig.Discovery(h.idTokenSigner))
+}
+
+// JWKSHandler serves the public keys used to verify ID tokens, at OpenIDConfig.JWKSURI.
+func (h *AuthGinHandler) JWKSHandler(c *gin.Context) {
+	if h.idTokenSigner == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "The OpenID provider is not enabled", nil)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, h.idTokenSigner.JWKS())
+}
+
+// OIDCUserInfoHandler is the OpenID Connect userinfo endpoint. It returns the claims of the
+// authenticated user allowed by the token's scopes, so client tokens need the "openid" scope.
+// It must run behind AuthMiddleware. First-party login tokens receive all claims.
+func (h *AuthGinHandler) OIDCUserInfoHandler(c *gin.Context) {
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || authPayload.IsClientCredentials() {
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "A user access token is required", nil)
+		return
+	}
+
+	scopes := []string{oauthserver.ScopeOpenID, oauthserver.ScopeProfile, oauthserver.ScopeEmail, oauthserver.ScopePhone}
+	if !authPayload.IsLoginToken() {
+		if !authPayload.HasScope(oauthserver.ScopeOpenID) {
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			MapSDKErrorToHTTP(c, core.ErrInsufficientScope)
+			return
+		}
+		scopes = authPayload.Scopes()
+	}
+
+	user, err := h.store.GetUserByID(c.Request.Context(), authPayload.UserID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, OIDCUserInfoResponse{
+		Subject:        user.ID.String(),
+		UserInfoClaims: oauthserver.UserInfoClaimsFor(user, scopes),
+	})
+}
+
+// EndSessionHandler implements OpenID Connect RP-initiated logout. The id_token_hint
+// identifies the user and the client (RP); the user's login session and the refresh
+// tokens issued to that client are revoked. An expired hint no longer shows that the RP
+// asks now, so the user must confirm through EndSessionConfirmHandler instead: the
+// handler responds 403 LOGOUT_CONFIRMATION_REQUIRED. It responds with an
+// AuthorizationRedirectResponse to the post_logout_redirect_uri when one was given, or a
+// MessageResponse otherwise.
+func (h *AuthGinHandler) EndSessionHandler(c *gin.Context) {
+	var req EndSessionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_QUERY", "Invalid query parameters", err.Error())
+		return
+	}
+	h.endSession(c, req, nil)
+}
+
+// EndSessionConfirmHandler ends the session for an id_token_hint, expired or not, once the
+// user confirmed the logout. It takes the parameters of EndSessionHandler as JSON and
+// must run behind AuthMiddleware, with the hint's user logged in.
+func (h *AuthGinHandler) EndSessionConfirmHandler(c *gin.Context) {
+	authPayload, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	var req EndSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	h.endSession(c, req, authPayload)
+}
+
+// endSession verifies the logout request and revokes the sessions. confirmedBy is the
+// login session of the user who confirmed it, or nil.
+func (h *AuthGinHandler) endSession(c *gin.Context, req EndSessionRequest, confirmedBy *token.Payload) {
+	if h.idTokenSigner == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "The OpenID provider is not enabled", nil)
+		return
+	}
+
+	claims, err := h.idTokenSigner.Verify(req.IDTokenHint, h.openIDConfig.Issuer)
+	if err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_ID_TOKEN_HINT", err.Error(), nil)
+		return
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_ID_TOKEN_HINT", oauthserver.ErrInvalidIDTokenHint.Error(), nil)
+		return
+	}
+	if confirmedBy != nil {
+		if confirmedBy.UserID != userID {
+			MapSDKErrorToHTTP(c, fmt.Errorf("%w: id_token_hint was issued to another user", core.ErrForbidden))
+			return
+		}
+	} else if time.Now().After(claims.Expiry.Time().Add(h.config.TokenLeeway)) {
+		RespondWithError(c, http.StatusForbidden, "LOGOUT_CONFIRMATION_REQUIRED", "The id_token_hint has expired; the user must confirm the logout", nil)
+		return
+	}
+
+	clientID := req.ClientID
+	if clientID == "" && len(claims.Audience) > 0 {
+		clientID = claims.Audience[0]
+	}
+	if !claims.Audience.Contains(clientID) {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_CLIENT", "client_id does not match the id_token_hint audience", nil)
+		return
+	}
+
+	if req.PostLogoutRedirectURI != "" {
+		client, err := h.oauthClients.GetOAuthClient(c.Request.Context(), clientID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				RespondWithError(c, http.StatusBadRequest, "INVALID_CLIENT", "Unknown client", nil)
+				return
+			}
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		if !containsString(client.PostLogoutRedirectURIs, req.PostLogoutRedirectURI) {
+			RespondWithError(c, http.StatusBadRequest, "INVALID_REDIRECT_URI", "post_logout_redirect_uri is not registered for this client", nil)
+			return
+		}
+	}
+
+	// End the user's login session and the client's refresh tokens; other clients keep theirs
+	if h.config.EnforceSingleDeviceLogin {
+		noToken := ""
+		if _, err := h.store.UpdateUser(c.Request.Context(), userID, core.UpdateUserParams{ActiveToken: &noToken}); err != nil && !errors.Is(err, core.ErrNotFound) {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+	}
+	if h.refreshTokens != nil {
+		if err := h.refreshTokens.DeleteRefreshTokensByClient(c.Request.Context(), userID, clientID); err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+	}
+
+	if req.PostLogoutRedirectURI == "" {
+		RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: "Logged out."})
+		return
+	}
+	redirectTo := req.PostLogoutRedirectURI
+	if req.State != "" {
+		sep := "?"
+		if strings.Contains(redirectTo, "?") {
+			sep = "&"
+		}
+		redirectTo += sep + url.Values{"state": {req.State}}.Encode()
+	}
+	RespondWithSuccess(c, http.StatusOK, AuthorizationRedirectResponse{RedirectTo: redirectTo})
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package ginhandler
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/jose"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+type recordingRefreshTokenStore struct {
+	core.RefreshTokenStorer
+	deleted []string // Client IDs whose tokens were deleted
+}
+
+func (s *recordingRefreshTokenStore) DeleteRefreshTokensByClient(ctx context.Context, userID uuid.UUID, clientID string) error {
+	s.deleted = append(s.deleted, clientID)
+	return nil
+}
+
+func newTestIDTokenSigner(t *testing.T) *oauthserver.IDTokenSigner {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := oauthserver.NewIDTokenSigner(key, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestEndSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.EnforceSingleDeviceLogin = false
+	signer := newTestIDTokenSigner(t)
+	userID := uuid.New()
+	hint := func(expiry time.Time) string {
+		raw, err := signer.Sign(oauthserver.IDTokenClaims{
+			Issuer:   "https://auth.example.com",
+			Subject:  userID.String(),
+			Audience: jose.Audience{"rp"},
+			Expiry:   jose.NewNumericDate(expiry),
+			IssuedAt: jose.NewNumericDate(expiry.Add(-time.Hour)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	validHint, expiredHint := hint(time.Now().Add(time.Hour)), hint(time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name        string
+		hint        string
+		confirmedBy *token.Payload
+		want        int
+	}{
+		{"valid hint", validHint, nil, http.StatusOK},
+		{"expired hint", expiredHint, nil, http.StatusForbidden},
+		{"expired hint confirmed by the user", expiredHint, &token.Payload{UserID: userID}, http.StatusOK},
+		{"expired hint confirmed by someone else", expiredHint, &token.Payload{UserID: uuid.New()}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshTokens := &recordingRefreshTokenStore{}
+			h := &AuthGinHandler{
+				config:        cfg,
+				idTokenSigner: signer,
+				openIDConfig:  oauthserver.OpenIDConfig{Issuer: "https://auth.example.com"},
+				refreshTokens: refreshTokens,
+			}
+			r := gin.New()
+			r.GET("/end-session", h.EndSessionHandler)
+			r.POST("/end-session", func(c *gin.Context) { setAuthPayload(c, tt.confirmedBy) }, h.EndSessionConfirmHandler)
+
+			var req *http.Request
+			if tt.confirmedBy == nil {
+				req = httptest.NewRequest(http.MethodGet, "/end-session?"+url.Values{"id_token_hint": {tt.hint}}.Encode(), nil)
+			} else {
+				body, _ := json.Marshal(EndSessionRequest{IDTokenHint: tt.hint})
+				req = httptest.NewRequest(http.MethodPost, "/end-session", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusOK && (len(refreshTokens.deleted) != 1 || refreshTokens.deleted[0] != "rp") {
+				t.Errorf("deleted refresh tokens of %v, want only the hint's client", refreshTokens.deleted)
+			}
+			if tt.want != http.StatusOK && len(refreshTokens.deleted) != 0 {
+				t.Errorf("deleted refresh tokens of %v on a rejected request", refreshTokens.deleted)
+			}
+		})
+	}
+}
+
+type memoryCodeStore struct {
+	core.OAuthServerStorer
+	codes map[string]core.AuthorizationCode
+}
+
+func (s memoryCodeStore) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (core.AuthorizationCode, error) {
+	code, ok := s.codes[codeHash]
+	if !ok {
+		return core.AuthorizationCode{}, core.ErrNotFound
+	}
+	delete(s.codes, codeHash)
+	return code, nil
+}
+
+func TestOpenIDProviderConformance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	maker, err := token.NewPasetoMaker(cfg.TokenSymmetricKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const issuer = "https://auth.example.com"
+	user := core.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Status: core.StatusActive}
+	users := memoryUserStore{users: map[uuid.UUID]core.User{user.ID: user}}
+	client := core.OAuthClient{ClientID: "rp", RedirectURIs: []string{"https://rp.example.com/cb"}, GrantTypes: []string{core.GrantTypeAuthorizationCode}}
+
+	const code, verifier = "the-code", "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	codes := memoryCodeStore{codes: map[string]core.AuthorizationCode{
+		oauthserver.HashToken(code): {
+			ClientID:      "rp",
+			UserID:        user.ID,
+			RedirectURI:   "https://rp.example.com/cb",
+			Scope:         "openid email",
+			CodeChallenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", // RFC 7636, appendix B
+			Nonce:         "n-0S6_WzA2Mj",
+			AuthTime:      authTime,
+			ExpiresAt:     time.Now().Add(time.Minute),
+		},
+	}}
+	h := &AuthGinHandler{
+		config:        cfg,
+		tokenMaker:    maker,
+		store:         users,
+		auth:          service.New(users, maker, nil, nil, cfg),
+		oauthClients:  memoryClientStore{clients: map[string]core.OAuthClient{"rp": client}},
+		oauthServer:   codes,
+		idTokenSigner: newTestIDTokenSigner(t),
+		openIDConfig:  oauthserver.OpenIDConfig{Issuer: issuer, TokenEndpoint: issuer + "/oauth/token", JWKSURI: issuer + "/jwks"},
+	}
+	r := gin.New()
+	r.GET("/.well-known/openid-configuration", h.OpenIDConfigurationHandler)
+	r.GET("/jwks", h.JWKSHandler)
+	r.POST("/oauth/token", h.TokenHandler)
+	get := func(path string, v interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d: %s", path, w.Code, w.Body)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var discovery oauthserver.DiscoveryDocument
+	get("/.well-known/openid-configuration", &discovery)
+	if discovery.Issuer != issuer || discovery.JWKSURI != issuer+"/jwks" {
+		t.Errorf("issuer/jwks_uri = %q/%q", discovery.Issuer, discovery.JWKSURI)
+	}
+	if len(discovery.IDTokenSigningAlgValuesSupported) != 1 || discovery.IDTokenSigningAlgValuesSupported[0] != "EdDSA" {
+		t.Errorf("id_token_signing_alg_values_supported = %v, want [EdDSA]", discovery.IDTokenSigningAlgValuesSupported)
+	}
+	var jwks jose.JSONWebKeySet
+	get("/jwks", &jwks)
+
+	form := url.Values{
+		"grant_type":    {core.GrantTypeAuthorizationCode},
+		"client_id":     {"rp"},
+		"code":          {code},
+		"code_verifier": {verifier},
+		"redirect_uri":  {"https://rp.example.com/cb"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", bytes.NewBufferString(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("token endpoint: status = %d: %s", w.Code, w.Body)
+	}
+	var resp OAuthTokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate the ID token as a relying party would (OpenID Connect Core, section 3.1.3.7)
+	jws, err := jose.Parse(resp.IDToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := jwks.Find(jws.Header.Kid)
+	if len(keys) != 1 || keys[0].Alg != jws.Header.Alg {
+		t.Fatalf("JWKS has no %s key for kid %q", jws.Header.Alg, jws.Header.Kid)
+	}
+	pub, err := keys[0].PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jws.Verify(pub); err != nil {
+		t.Fatalf("ID token does not verify with the JWKS key: %v", err)
+	}
+	var claims oauthserver.IDTokenClaims
+	if err := jws.Claims(&claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != issuer || claims.Subject != user.ID.String() || !claims.Audience.Contains("rp") || claims.Azp != "rp" {
+		t.Errorf("iss/sub/aud/azp = %q/%q/%v/%q", claims.Issuer, claims.Subject, claims.Audience, claims.Azp)
+	}
+	if claims.Nonce != "n-0S6_WzA2Mj" {
+		t.Errorf("nonce = %q, want the authorization request's nonce", claims.Nonce)
+	}
+	if claims.AtHash == "" || claims.AtHash != h.idTokenSigner.AccessTokenHash(resp.AccessToken) {
+		t.Errorf("at_hash = %q does not match the access token", claims.AtHash)
+	}
+	if !claims.AuthTime.Time().Equal(authTime) {
+		t.Errorf("auth_time = %v, want %v", claims.AuthTime.Time(), authTime)
+	}
+	if !claims.Expiry.Time().After(time.Now()) {
+		t.Errorf("exp = %v is not in the future", claims.Expiry.Time())
+	}
+	if claims.Email != user.Email || claims.Name != "" {
+		t.Errorf("email/name = %q/%q, want only the email scope's claims", claims.Email, claims.Name)
+	}
+
+	// The code is single-use
+	req = httptest.NewRequest(http.MethodPost, "/oauth/token", bytes.NewBufferString(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("replayed code: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
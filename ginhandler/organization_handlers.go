@@ -0,0 +1,144 @@
+package ginhandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// ListOrganizationsHandler lists the organizations the authenticated user belongs to,
+// with their roles in each. This handler relies on AuthMiddleware to have run and set the payload.
+func (h *AuthGinHandler) ListOrganizationsHandler(c *gin.Context) {
+	if h.organizations == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Organizations are not enabled", nil)
+		return
+	}
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || authPayload.IsClientCredentials() {
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return
+	}
+
+	memberships, err := h.organizations.ListMembershipsByUserID(c.Request.Context(), authPayload.UserID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	resp := make([]OrganizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		org, err := h.organizations.GetOrganization(c.Request.Context(), membership.OrganizationID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				continue // Organization deleted while the membership remained
+			}
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		resp = append(resp, NewOrganizationResponse(org, membership, authPayload.TenantID))
+	}
+	RespondWithSuccess(c, http.StatusOK, resp)
+}
+
+// CreateOrganizationHandler creates an organization with the authenticated user as its
+// member in AuthConfig.OrganizationOwnerRole.
+func (h *AuthGinHandler) CreateOrganizationHandler(c *gin.Context) {
+	if h.organizations == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Organizations are not enabled", nil)
+		return
+	}
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || !authPayload.IsLoginToken() {
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return
+	}
+	if authPayload.IsImpersonated() {
+		MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+		return
+	}
+
+	now := time.Now()
+	org, err := h.organizations.CreateOrganization(c.Request.Context(), core.Organization{
+		ID:        uuid.New(),
+		Name:      req.Name,
+		Slug:      req.Slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to create organization: %w", err))
+		return
+	}
+	membership := core.Membership{
+		OrganizationID: org.ID,
+		UserID:         authPayload.UserID,
+		Role:           h.config.OrganizationOwnerRole,
+		JoinedAt:       now,
+	}
+	if err := h.organizations.AddMember(c.Request.Context(), membership); err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to add organization owner: %w", err))
+		return
+	}
+	RespondWithSuccess(c, http.StatusCreated, NewOrganizationResponse(org, membership, authPayload.TenantID))
+}
+
+// SwitchOrganizationHandler re-issues the authenticated user's login token scoped to one
+// of their organizations. The new token carries the organization as its tenant ID and the
+// user's roles there, which RoleMiddleware and RequirePermission then evaluate instead of
+// the global roles. An empty organization ID returns to an unscoped token. The previous
+// token stays valid until it expires unless single-device login is enforced.
+func (h *AuthGinHandler) SwitchOrganizationHandler(c *gin.Context) {
+	if h.organizations == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Organizations are not enabled", nil)
+		return
+	}
+	var req SwitchOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+
+	authPayload, exists := GetAuthPayload(c)
+	if !exists || !authPayload.IsLoginToken() {
+		RespondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User login required", nil)
+		return
+	}
+	if authPayload.IsImpersonated() {
+		MapSDKErrorToHTTP(c, core.ErrImpersonationDenied)
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if req.OrganizationID == "" {
+		h.respondWithLoginToken(c, user)
+		return
+	}
+
+	orgID, _ := uuid.Parse(req.OrganizationID) // Validated by binding
+	membership, err := h.organizations.GetMembership(ctx, orgID, user.ID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			MapSDKErrorToHTTP(c, core.ErrNotMember)
+			return
+		}
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to get membership: %w", err))
+		return
+	}
+	h.respondWithLoginToken(c, user, token.WithTenant(orgID.String(), membership.AllRoles()...))
+}
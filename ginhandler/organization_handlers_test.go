@@ -0,0 +1,121 @@
+package ginhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+type memoryOrganizationStore struct {
+	core.OrganizationStorer
+	orgs    map[uuid.UUID]core.Organization
+	members []core.Membership
+}
+
+func (s *memoryOrganizationStore) GetOrganization(ctx context.Context, id uuid.UUID) (core.Organization, error) {
+	org, ok := s.orgs[id]
+	if !ok {
+		return core.Organization{}, core.ErrNotFound
+	}
+	return org, nil
+}
+
+func (s *memoryOrganizationStore) GetMembership(ctx context.Context, orgID, userID uuid.UUID) (core.Membership, error) {
+	for _, m := range s.members {
+		if m.OrganizationID == orgID && m.UserID == userID {
+			return m, nil
+		}
+	}
+	return core.Membership{}, core.ErrNotFound
+}
+
+func (s *memoryOrganizationStore) AddMember(ctx context.Context, membership core.Membership) error {
+	if _, err := s.GetMembership(ctx, membership.OrganizationID, membership.UserID); err == nil {
+		return core.ErrAlreadyMember
+	}
+	s.members = append(s.members, membership)
+	return nil
+}
+
+func TestSwitchOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	cfg.EnforceSingleDeviceLogin = false
+	maker, err := token.NewPasetoMaker(cfg.TokenSymmetricKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := core.User{ID: uuid.New(), Username: "alice", Role: "user", Status: core.StatusActive}
+	users := memoryUserStore{users: map[uuid.UUID]core.User{alice.ID: alice}}
+	acme, globex := uuid.New(), uuid.New()
+	orgs := &memoryOrganizationStore{members: []core.Membership{
+		{OrganizationID: acme, UserID: alice.ID, Role: "admin", Roles: []string{"billing"}},
+		{OrganizationID: globex, UserID: uuid.New(), Role: "owner"}, // Someone else's organization
+	}}
+	h := &AuthGinHandler{
+		config:        cfg,
+		tokenMaker:    maker,
+		store:         users,
+		organizations: orgs,
+		auth:          service.New(users, maker, nil, nil, cfg),
+	}
+
+	tests := []struct {
+		name      string
+		orgID     string
+		want      int
+		wantRoles []string
+	}{
+		{"member", acme.String(), http.StatusOK, []string{"admin", "billing"}},
+		{"not a member", globex.String(), http.StatusForbidden, nil},
+		{"unknown organization", uuid.NewString(), http.StatusForbidden, nil},
+		{"back to no organization", "", http.StatusOK, []string{"user"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/switch", func(c *gin.Context) {
+				setAuthPayload(c, &token.Payload{UserID: alice.ID, Username: "alice", Role: "user", SessionID: "s1"})
+			}, h.SwitchOrganizationHandler)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/switch", strings.NewReader(`{"organization_id":"`+tt.orgID+`"}`)))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want != http.StatusOK {
+				if !strings.Contains(w.Body.String(), "NOT_A_MEMBER") {
+					t.Errorf("body = %s, want NOT_A_MEMBER", w.Body)
+				}
+				return
+			}
+
+			var resp struct{ Data TokenResponse }
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			payload, err := maker.VerifyToken(resp.Data.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payload.TenantID != tt.orgID || resp.Data.OrganizationID != tt.orgID {
+				t.Errorf("tenant = %q, organization_id = %q, want %q", payload.TenantID, resp.Data.OrganizationID, tt.orgID)
+			}
+			if got := payload.EffectiveRoles(); !slices.Equal(got, tt.wantRoles) {
+				t.Errorf("effective roles = %v, want %v", got, tt.wantRoles)
+			}
+		})
+	}
+}
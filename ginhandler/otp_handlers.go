@@ -0,0 +1,252 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/otp"
+)
+
+// RequestOTPHandler generates a one-time passcode and sends it over the requested channel.
+// The response is the same whether or not the account exists or can receive codes over
+// the channel, and whether or not a code was sent, to avoid leaking which emails are registered.
+func (h *AuthGinHandler) RequestOTPHandler(c *gin.Context) {
+	if h.otpStore == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "One-time passcodes are not enabled", nil)
+		return
+	}
+
+	var req RequestOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	if req.Purpose == core.OTPPurposeEmailVerification && req.Channel != core.OTPChannelEmail {
+		RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Email verification codes can only be sent by email", nil)
+		return
+	}
+	if _, ok := h.otpSenders[req.Channel]; !ok {
+		RespondWithError(c, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Channel '%s' is not supported", req.Channel), nil)
+		return
+	}
+
+	accepted := MessageResponse{Message: "If the account exists, a code has been sent."}
+
+	user, err := h.store.GetUserByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
+			RespondWithSuccess(c, http.StatusAccepted, accepted)
+			return
+		}
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if req.Purpose == core.OTPPurposeEmailVerification && user.Status != core.StatusPending {
+		RespondWithSuccess(c, http.StatusAccepted, accepted) // Nothing to verify
+		return
+	}
+
+	err = h.sendOTP(c.Request.Context(), user, req.Channel, req.Purpose)
+	if err != nil && !errors.Is(err, core.ErrOTPChannelUnavailable) { // E.g. no phone number: answer as usual
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to issue one-time passcode: %w", err))
+		return
+	}
+
+	RespondWithSuccess(c, http.StatusAccepted, accepted)
+}
+
+// VerifyOTPHandler checks a one-time passcode. Apart from the lockout, every failure is
+// reported as an invalid code, whether or not the account exists or has a live code.
+// For the login purpose it responds with a TokenResponse, like LoginUser.
+// For the email_verification purpose it activates the account, like VerifyEmailHandler.
+func (h *AuthGinHandler) VerifyOTPHandler(c *gin.Context) {
+	if h.otpStore == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "One-time passcodes are not enabled", nil)
+		return
+	}
+
+	var req VerifyOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+
+	user, err := h.store.GetUserByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
+			MapSDKErrorToHTTP(c, core.ErrOTPInvalid) // Same error as a wrong code
+			return
+		}
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	if err := h.checkOTP(c.Request.Context(), user.ID, req.Purpose, req.Code); err != nil {
+		// A missing or expired code would tell registered emails apart from unknown ones
+		if errors.Is(err, core.ErrOTPNotFound) || errors.Is(err, core.ErrOTPExpired) {
+			err = core.ErrOTPInvalid
+		}
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+
+	switch req.Purpose {
+	case core.OTPPurposeLogin:
+		if !h.checkLoginStatus(c, user) {
+			return
+		}
+		h.respondWithLoginToken(c, user)
+
+	case core.OTPPurposeEmailVerification:
+		activeStatus := core.StatusActive
+		updatedUser, err := h.store.UpdateUser(c.Request.Context(), user.ID, core.UpdateUserParams{Status: &activeStatus})
+		if err != nil {
+			MapSDKErrorToHTTP(c, fmt.Errorf("failed to activate user: %w", err))
+			return
+		}
+		// Any outstanding verification links are no longer needed
+		if err := h.store.DeleteVerificationDataByUserID(c.Request.Context(), user.ID); err != nil {
+			fmt.Printf("Warning: Failed to delete verification data for %s: %v\n", user.Email, err)
+		}
+		RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: fmt.Sprintf("Email for %s successfully verified.", updatedUser.Email)})
+	}
+}
+
+// sendOTP generates and stores a new code for the user, replacing any previous one
+// for the same purpose, and sends it asynchronously over the given channel. The new code
+// inherits the failed attempts of a live previous one. Nothing is sent while the previous
+// code is locked out or younger than AuthConfig.OTPResendInterval.
+func (h *AuthGinHandler) sendOTP(ctx context.Context, user core.User, channel core.OTPChannel, purpose core.OTPPurpose) error {
+	sender, ok := h.otpSenders[channel]
+	if !ok {
+		return core.ErrOTPChannelUnavailable
+	}
+	destination := user.Email
+	if channel == core.OTPChannelSMS {
+		destination = user.PhoneNumber
+	}
+	if destination == "" {
+		return core.ErrOTPChannelUnavailable
+	}
+
+	now := time.Now()
+	attempts := 0
+	previous, err := h.otpStore.GetOTP(ctx, user.ID, purpose)
+	switch {
+	case err == nil && now.Before(previous.ExpiresAt):
+		if previous.Attempts >= previous.MaxAttempts || now.Sub(previous.CreatedAt) < h.config.OTPResendInterval {
+			return nil // The caller answers as if a code was sent
+		}
+		attempts = previous.Attempts
+	case err != nil && !errors.Is(err, core.ErrOTPNotFound):
+		return fmt.Errorf("load otp: %w", err)
+	}
+
+	key, err := h.otpHashKey()
+	if err != nil {
+		return err
+	}
+	code, err := otp.GenerateCode(h.config.OTPLength)
+	if err != nil {
+		return err
+	}
+	err = h.otpStore.StoreOTP(ctx, core.OTP{
+		UserID:      user.ID,
+		Purpose:     purpose,
+		Channel:     channel,
+		CodeHash:    otp.HashCode(key, code),
+		Attempts:    attempts,
+		MaxAttempts: h.config.OTPMaxAttempts,
+		ExpiresAt:   now.Add(h.config.OTPDuration),
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return fmt.Errorf("store otp: %w", err)
+	}
+
+	// Send asynchronously to avoid blocking the request
+	go func(to, userNm string) {
+		bgCtx := context.Background()
+		if err := sender.SendOTP(bgCtx, to, userNm, code, purpose); err != nil {
+			// h.logger.Error("Failed to send one-time passcode", "error", err, "channel", channel)
+			fmt.Printf("Error sending %s one-time passcode to %s: %v\n", channel, to, err)
+		}
+	}(destination, user.FullName)
+
+	return nil
+}
+
+// checkOTP verifies a code against the stored OTP, enforcing expiry and the attempt limit.
+// Every check reserves an attempt before the code is compared, so that parallel guesses
+// cannot compare more codes than MaxAttempts allows. The OTP is deleted once it is used or
+// expired. One that ran out of attempts is kept until it expires, so that requesting a new
+// code does not renew the attempt budget.
+func (h *AuthGinHandler) checkOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose, code string) error {
+	stored, err := h.otpStore.GetOTP(ctx, userID, purpose)
+	if err != nil {
+		return err // Typically core.ErrOTPNotFound
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		h.deleteOTP(ctx, userID, purpose)
+		return core.ErrOTPExpired
+	}
+	if stored.Attempts >= stored.MaxAttempts {
+		return core.ErrOTPAttemptsExceeded
+	}
+	attempts, err := h.otpStore.IncrementOTPAttempts(ctx, userID, purpose)
+	if err != nil {
+		if errors.Is(err, core.ErrOTPNotFound) {
+			return err
+		}
+		return fmt.Errorf("increment otp attempts: %w", err)
+	}
+	if attempts > stored.MaxAttempts {
+		return core.ErrOTPAttemptsExceeded
+	}
+
+	key, err := h.otpHashKey()
+	if err != nil {
+		return err
+	}
+	if !otp.CheckCode(key, stored.CodeHash, code) {
+		if attempts >= stored.MaxAttempts {
+			return core.ErrOTPAttemptsExceeded
+		}
+		return core.ErrOTPInvalid
+	}
+
+	// Codes are single use: only one of several parallel checks consumes it
+	if err := h.otpStore.ConsumeOTP(ctx, userID, purpose, stored.CodeHash); err != nil {
+		if errors.Is(err, core.ErrOTPNotFound) {
+			return err
+		}
+		return fmt.Errorf("consume otp: %w", err)
+	}
+	return nil
+}
+
+// otpHashKey returns the server secret codes are hashed with.
+func (h *AuthGinHandler) otpHashKey() ([]byte, error) {
+	key := h.config.OTPHashKey
+	if key == "" {
+		key = h.config.TokenSymmetricKey
+	}
+	if key == "" {
+		return nil, errors.New("neither OTPHashKey nor TokenSymmetricKey is configured")
+	}
+	return []byte(key), nil
+}
+
+func (h *AuthGinHandler) deleteOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) {
+	if err := h.otpStore.DeleteOTP(ctx, userID, purpose); err != nil {
+		fmt.Printf("Warning: Failed to delete %s one-time passcode for user %s: %v\n", purpose, userID, err)
+	}
+}
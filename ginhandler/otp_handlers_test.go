@@ -0,0 +1,276 @@
+package ginhandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+)
+
+type memoryOTPStore struct {
+	mu   sync.Mutex
+	otps map[core.OTPPurpose]core.OTP // One user is enough here
+}
+
+func (s *memoryOTPStore) StoreOTP(ctx context.Context, entry core.OTP) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.otps[entry.Purpose] = entry
+	return nil
+}
+
+func (s *memoryOTPStore) GetOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) (core.OTP, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.otps[purpose]
+	if !ok {
+		return core.OTP{}, core.ErrOTPNotFound
+	}
+	return entry, nil
+}
+
+func (s *memoryOTPStore) IncrementOTPAttempts(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.otps[purpose]
+	if !ok {
+		return 0, core.ErrOTPNotFound
+	}
+	entry.Attempts++
+	s.otps[purpose] = entry
+	return entry.Attempts, nil
+}
+
+func (s *memoryOTPStore) ConsumeOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose, codeHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.otps[purpose]
+	if !ok || entry.CodeHash != codeHash {
+		return core.ErrOTPNotFound
+	}
+	delete(s.otps, purpose)
+	return nil
+}
+
+func (s *memoryOTPStore) DeleteOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.otps, purpose)
+	return nil
+}
+
+// barrierOTPStore holds every GetOTP until all expected checks have loaded the OTP,
+// the worst case for checks that run in parallel. If counted is set, a check whose
+// context carries lastGuess counts its attempt only after all other checks have counted theirs.
+type barrierOTPStore struct {
+	*memoryOTPStore
+	loaded, counted *sync.WaitGroup
+}
+
+type lastGuess struct{}
+
+func (s barrierOTPStore) GetOTP(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) (core.OTP, error) {
+	entry, err := s.memoryOTPStore.GetOTP(ctx, userID, purpose)
+	s.loaded.Done()
+	s.loaded.Wait()
+	return entry, err
+}
+
+func (s barrierOTPStore) IncrementOTPAttempts(ctx context.Context, userID uuid.UUID, purpose core.OTPPurpose) (int, error) {
+	switch {
+	case s.counted == nil:
+	case ctx.Value(lastGuess{}) != nil:
+		s.counted.Wait()
+	default:
+		defer s.counted.Done()
+	}
+	return s.memoryOTPStore.IncrementOTPAttempts(ctx, userID, purpose)
+}
+
+type recordingOTPSender struct{ codes chan string }
+
+func (s recordingOTPSender) SendOTP(ctx context.Context, destination, username, code string, purpose core.OTPPurpose) error {
+	s.codes <- code
+	return nil
+}
+
+func TestOTPAttemptBudget(t *testing.T) {
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	cfg.OTPMaxAttempts = 2
+	store := &memoryOTPStore{otps: make(map[core.OTPPurpose]core.OTP)}
+	sender := recordingOTPSender{codes: make(chan string, 10)}
+	h := &AuthGinHandler{config: cfg, otpStore: store, otpSenders: map[core.OTPChannel]core.OTPSender{core.OTPChannelEmail: sender}}
+	ctx := context.Background()
+	user := core.User{ID: uuid.New(), Email: "ada@example.com"}
+
+	if err := h.sendOTP(ctx, user, core.OTPChannelEmail, core.OTPPurposeLogin); err != nil {
+		t.Fatal(err)
+	}
+	code := <-sender.codes
+	if err := h.checkOTP(ctx, user.ID, core.OTPPurposeLogin, "wrong"); !errors.Is(err, core.ErrOTPInvalid) {
+		t.Fatalf("checkOTP() with a wrong code = %v, want ErrOTPInvalid", err)
+	}
+
+	// Re-sending within the resend interval sends nothing
+	if err := h.sendOTP(ctx, user, core.OTPChannelEmail, core.OTPPurposeLogin); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-sender.codes:
+		t.Fatal("a code was re-sent within OTPResendInterval")
+	default:
+	}
+
+	// A re-sent code inherits the failed attempts
+	h.config.OTPResendInterval = 0
+	if err := h.sendOTP(ctx, user, core.OTPChannelEmail, core.OTPPurposeLogin); err != nil {
+		t.Fatal(err)
+	}
+	newCode := <-sender.codes
+	if err := h.checkOTP(ctx, user.ID, core.OTPPurposeLogin, "wrong"); !errors.Is(err, core.ErrOTPAttemptsExceeded) {
+		t.Fatalf("checkOTP() after re-sending = %v, want ErrOTPAttemptsExceeded", err)
+	}
+
+	// Locked out until the code expires: no new code, and not even the right one passes
+	if err := h.sendOTP(ctx, user, core.OTPChannelEmail, core.OTPPurposeLogin); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-sender.codes:
+		t.Fatal("a code was sent while locked out")
+	default:
+	}
+	for _, c := range []string{code, newCode} {
+		if err := h.checkOTP(ctx, user.ID, core.OTPPurposeLogin, c); !errors.Is(err, core.ErrOTPAttemptsExceeded) {
+			t.Errorf("checkOTP() while locked out = %v, want ErrOTPAttemptsExceeded", err)
+		}
+	}
+}
+
+func TestOTPParallelGuesses(t *testing.T) {
+	const parallel = 50
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	cfg.OTPMaxAttempts = 3
+	memory := &memoryOTPStore{otps: make(map[core.OTPPurpose]core.OTP)}
+	sender := recordingOTPSender{codes: make(chan string, 1)}
+	user := core.User{ID: uuid.New(), Email: "ada@example.com"}
+
+	// guess runs the checks in parallel; each loads the OTP before any of them counts an attempt
+	guess := func(codes []string, lastCorrect bool) []error {
+		store := barrierOTPStore{memoryOTPStore: memory, loaded: &sync.WaitGroup{}}
+		store.loaded.Add(len(codes))
+		if lastCorrect {
+			store.counted = &sync.WaitGroup{}
+			store.counted.Add(len(codes) - 1)
+		}
+		h := &AuthGinHandler{config: cfg, otpStore: store}
+		errs := make([]error, len(codes))
+		var wg sync.WaitGroup
+		for i, code := range codes {
+			ctx := context.Background()
+			if lastCorrect && i == len(codes)-1 {
+				ctx = context.WithValue(ctx, lastGuess{}, true)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = h.checkOTP(ctx, user.ID, core.OTPPurposeLogin, code)
+			}()
+		}
+		wg.Wait()
+		return errs
+	}
+	send := func() string {
+		memory.otps = make(map[core.OTPPurpose]core.OTP)
+		h := &AuthGinHandler{config: cfg, otpStore: memory, otpSenders: map[core.OTPChannel]core.OTPSender{core.OTPChannelEmail: sender}}
+		if err := h.sendOTP(context.Background(), user, core.OTPChannelEmail, core.OTPPurposeLogin); err != nil {
+			t.Fatal(err)
+		}
+		return <-sender.codes
+	}
+
+	// A burst of wrong guesses locks the code before the correct guess is compared
+	code := send()
+	codes := make([]string, parallel)
+	for i := range codes {
+		codes[i] = "wrong"
+	}
+	codes[parallel-1] = code
+	errs := guess(codes, true)
+	if !errors.Is(errs[parallel-1], core.ErrOTPAttemptsExceeded) {
+		t.Errorf("correct guess after %d parallel wrong ones = %v, want ErrOTPAttemptsExceeded", parallel-1, errs[parallel-1])
+	}
+	invalid := 0
+	for _, err := range errs[:parallel-1] {
+		if errors.Is(err, core.ErrOTPInvalid) {
+			invalid++
+		}
+	}
+	if invalid != cfg.OTPMaxAttempts-1 {
+		t.Errorf("%d parallel wrong guesses were compared before the lockout, want %d", invalid, cfg.OTPMaxAttempts-1)
+	}
+
+	// The correct code is accepted once, however many checks run in parallel
+	code = send()
+	for i := range codes {
+		codes[i] = code
+	}
+	succeeded := 0
+	for _, err := range guess(codes, false) {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d parallel checks of the correct code succeeded, want exactly 1", succeeded)
+	}
+}
+
+func TestVerifyOTPDoesNotRevealAccounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	user := core.User{ID: uuid.New(), Email: "ada@example.com", Status: core.StatusActive}
+	store := &memoryOTPStore{otps: make(map[core.OTPPurpose]core.OTP)}
+	h := &AuthGinHandler{
+		config:   cfg,
+		store:    memoryUserStore{users: map[uuid.UUID]core.User{user.ID: user}},
+		otpStore: store,
+	}
+	r := gin.New()
+	r.POST("/otp/verify", h.VerifyOTPHandler)
+	verify := func(email string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(VerifyOTPRequest{Email: email, Code: "123456", Purpose: core.OTPPurposeLogin})
+		req := httptest.NewRequest(http.MethodPost, "/otp/verify", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	unknown := verify("nobody@example.com")
+	if unknown.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown email: status = %d: %s", unknown.Code, unknown.Body)
+	}
+	noCode := verify(user.Email)
+	store.otps[core.OTPPurposeLogin] = core.OTP{UserID: user.ID, Purpose: core.OTPPurposeLogin, MaxAttempts: 5, ExpiresAt: time.Now().Add(-time.Minute)}
+	expired := verify(user.Email)
+	for name, w := range map[string]*httptest.ResponseRecorder{"no live code": noCode, "expired code": expired} {
+		if w.Code != unknown.Code || w.Body.String() != unknown.Body.String() {
+			t.Errorf("%s: %d %s, want the unknown email's response %d %s", name, w.Code, w.Body, unknown.Code, unknown.Body)
+		}
+	}
+}
@@ -5,25 +5,17 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shawgichan/go-authkit/core" // Adjust import path
+	"github.com/shawgichan/go-authkit/httphandler"
+	"github.com/shawgichan/go-authkit/token"
 )
 
 // === Request Structs (for SDK-provided handlers) ===
 
 // RegisterRequest defines the expected body for user registration.
-type RegisterRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"` // Assuming min length from config
-	FullName string `json:"full_name" binding:"required"`
-	// Role is typically set by the system (e.g., default role from config)
-	// or handled by application-specific logic if allowed from request.
-	// For a generic SDK, role might not be part of the direct request here.
-}
+type RegisterRequest = httphandler.RegisterRequest
 
 // LoginRequest defines the expected body for user login.
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-}
+type LoginRequest = httphandler.LoginRequest
 
 // VerifyEmailRequest defines query parameters for email verification.
 // The SDK handler would get the token from the query.
@@ -31,6 +23,138 @@ type VerifyEmailRequest struct {
 	Token string `form:"token" binding:"required"` // Or "secret_code" depending on link
 }
 
+// RequestOTPRequest defines the expected body for requesting a one-time passcode.
+type RequestOTPRequest struct {
+	Email   string          `json:"email" binding:"required,email"`
+	Channel core.OTPChannel `json:"channel" binding:"required,oneof=email sms"`
+	Purpose core.OTPPurpose `json:"purpose" binding:"required,oneof=login email_verification"`
+}
+
+// VerifyOTPRequest defines the expected body for verifying a one-time passcode.
+type VerifyOTPRequest struct {
+	Email   string          `json:"email" binding:"required,email"`
+	Code    string          `json:"code" binding:"required,numeric,min=6,max=8"`
+	Purpose core.OTPPurpose `json:"purpose" binding:"required,oneof=login email_verification"`
+}
+
+// LinkIdentityRequest starts linking an external identity to the logged-in user.
+// Password is required for re-authentication when the user has one.
+type LinkIdentityRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	Password string `json:"password"`
+}
+
+// AuthorizeRequest holds the OAuth2 authorization request parameters (RFC 6749 section 4.1.1, RFC 7636).
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	Nonce               string `form:"nonce" json:"nonce"` // OpenID Connect
+}
+
+// AuthorizeConsentRequest is the user's decision on a consent prompt.
+type AuthorizeConsentRequest struct {
+	AuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// EndSessionRequest holds the OpenID Connect RP-initiated logout parameters.
+type EndSessionRequest struct {
+	IDTokenHint           string `form:"id_token_hint" json:"id_token_hint" binding:"required"`
+	ClientID              string `form:"client_id" json:"client_id"`
+	PostLogoutRedirectURI string `form:"post_logout_redirect_uri" json:"post_logout_redirect_uri"`
+	State                 string `form:"state" json:"state"`
+}
+
+// DeviceVerificationRequest looks up a device flow user code.
+type DeviceVerificationRequest struct {
+	UserCode string `form:"user_code" json:"user_code" binding:"required"`
+}
+
+// CreateOrganizationRequest creates an organization owned by the authenticated user.
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=200"`
+	Slug string `json:"slug" binding:"omitempty,max=100"`
+}
+
+// SwitchOrganizationRequest asks for a token scoped to an organization.
+// An empty OrganizationID returns to a token without organization.
+type SwitchOrganizationRequest struct {
+	OrganizationID string `json:"organization_id" binding:"omitempty,uuid"`
+}
+
+// CreateInvitationRequest invites someone to the current organization.
+// Role defaults to AuthConfig.OrganizationMemberRole.
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"omitempty,max=100"`
+}
+
+// InvitationTokenRequest carries the token from an invitation email.
+type InvitationTokenRequest struct {
+	Token string `form:"token" json:"token" binding:"required"`
+}
+
+// InvitationSignupRequest creates an account for an invited email address and accepts the invitation.
+type InvitationSignupRequest struct {
+	Token       string `json:"token" binding:"required"`
+	Password    string `json:"password" binding:"required,min=8"`
+	FullName    string `json:"full_name" binding:"required"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+}
+
+// CreateAPIKeyRequest creates an API key for the authenticated user.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"` // Omit for a key that does not expire, if AuthConfig allows it
+}
+
+// CreateServiceAccountRequest creates a service account. Role defaults to AuthConfig.ServiceAccountRole.
+type CreateServiceAccountRequest struct {
+	Name        string   `json:"name" binding:"required,max=100"` // Stored as the username
+	Description string   `json:"description" binding:"max=500"`   // Stored as the full name
+	Role        string   `json:"role" binding:"omitempty,max=100"`
+	Roles       []string `json:"roles"`
+}
+
+// UpdateServiceAccountRequest changes a service account. Omitted fields are left unchanged.
+type UpdateServiceAccountRequest struct {
+	Description *string          `json:"description" binding:"omitempty,max=500"`
+	Role        *string          `json:"role" binding:"omitempty,min=1,max=100"`
+	Roles       *[]string        `json:"roles"`
+	Status      *core.UserStatus `json:"status" binding:"omitempty,oneof=active suspended"`
+}
+
+// ImpersonateUserRequest asks for a token to act as another user.
+type ImpersonateUserRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	Reason string `json:"reason" binding:"required,max=500"` // Recorded in the audit log
+}
+
+// DeviceApprovalRequest is the user's decision on a device authorization request.
+type DeviceApprovalRequest struct {
+	UserCode string `json:"user_code" binding:"required"`
+	Approve  bool   `json:"approve"`
+}
+
+// CreateOAuthClientRequest registers a new OAuth2 client.
+type CreateOAuthClientRequest struct {
+	Name                   string   `json:"name" binding:"required"`
+	RedirectURIs           []string `json:"redirect_uris"`
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris"`
+	GrantTypes             []string `json:"grant_types" binding:"required,min=1,dive,oneof=authorization_code refresh_token client_credentials urn:ietf:params:oauth:grant-type:device_code urn:ietf:params:oauth:grant-type:token-exchange"`
+	Scopes                 []string `json:"scopes"`
+	Public                 bool     `json:"public"` // Public clients get no secret and must use PKCE
+	FirstParty             bool     `json:"first_party"`
+	ResourceServer         bool     `json:"resource_server"`                             // May introspect tokens of other clients
+	ServiceAccountID       string   `json:"service_account_id" binding:"omitempty,uuid"` // client_credentials tokens act as this service account
+}
+
 // ForgotPasswordRequest defines the expected body for initiating password reset.
 type ForgotPasswordRequest struct {
 	Email string `json:"email" binding:"required,email"`
@@ -57,40 +181,257 @@ type ChangeNameRequest struct {
 
 // UserResponse is a generic representation of a user for API responses.
 // It omits sensitive information like PasswordHash.
-type UserResponse struct {
-	ID        uuid.UUID       `json:"id"`
-	Username  string          `json:"username"`
-	Email     string          `json:"email"`
-	FullName  string          `json:"full_name"`
-	Role      string          `json:"role"`
-	Status    core.UserStatus `json:"status"` // Use core.UserStatus type
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
-	// Add other non-sensitive fields you want to expose
-}
-
-// NewUserResponse maps a core.User to a UserResponse.
+type UserResponse = httphandler.UserResponse
+
+// NewSDKUserResponse maps a core.User to a UserResponse.
 func NewSDKUserResponse(user core.User) UserResponse {
-	return UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		FullName:  user.FullName,
-		Role:      user.Role,
-		Status:    user.Status,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}
+	return httphandler.NewSDKUserResponse(user)
 }
 
 // TokenResponse is returned on successful login or token refresh.
-type TokenResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        UserResponse `json:"user"`
-	ExpiresAt   time.Time    `json:"expires_at"` // Expiry of the access token
-}
+type TokenResponse = httphandler.TokenResponse
 
 // MessageResponse is for simple status messages.
-type MessageResponse struct {
-	Message string `json:"message"`
+type MessageResponse = httphandler.MessageResponse
+
+// OrganizationResponse is an organization the user belongs to, with their roles in it.
+type OrganizationResponse struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Slug     string    `json:"slug,omitempty"`
+	Role     string    `json:"role"`
+	Roles    []string  `json:"roles,omitempty"`
+	JoinedAt time.Time `json:"joined_at"`
+	Current  bool      `json:"current"` // The request's token is scoped to this organization
+}
+
+// NewOrganizationResponse creates an OrganizationResponse from an organization and a membership.
+func NewOrganizationResponse(org core.Organization, membership core.Membership, currentTenantID string) OrganizationResponse {
+	return OrganizationResponse{
+		ID:       org.ID.String(),
+		Name:     org.Name,
+		Slug:     org.Slug,
+		Role:     membership.Role,
+		Roles:    membership.Roles,
+		JoinedAt: membership.JoinedAt,
+		Current:  org.ID.String() == currentTenantID,
+	}
+}
+
+// InvitationResponse is an invitation to an organization. The token is never returned.
+type InvitationResponse struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organization_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	InvitedBy      string    `json:"invited_by"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NewInvitationResponse creates an InvitationResponse from a core.Invitation.
+func NewInvitationResponse(invitation core.Invitation) InvitationResponse {
+	return InvitationResponse{
+		ID:             invitation.ID.String(),
+		OrganizationID: invitation.OrganizationID.String(),
+		Email:          invitation.Email,
+		Role:           invitation.Role,
+		InvitedBy:      invitation.InvitedBy.String(),
+		Status:         string(invitation.Status),
+		ExpiresAt:      invitation.ExpiresAt,
+		CreatedAt:      invitation.CreatedAt,
+	}
+}
+
+// InvitationDetailsResponse describes a pending invitation to the invitee.
+type InvitationDetailsResponse struct {
+	OrganizationName string    `json:"organization_name"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse describes an API key. The key itself is only returned on creation.
+type APIKeyResponse struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Hint           string     `json:"hint"`
+	Scopes         []string   `json:"scopes"`
+	OrganizationID string     `json:"organization_id,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// NewAPIKeyResponse creates an APIKeyResponse from a core.APIKey.
+func NewAPIKeyResponse(key core.APIKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:             key.ID.String(),
+		Name:           key.Name,
+		Hint:           key.Hint,
+		Scopes:         append([]string{}, key.Scopes...),
+		OrganizationID: key.TenantID,
+		CreatedAt:      key.CreatedAt,
+	}
+	if !key.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &key.ExpiresAt
+	}
+	if !key.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &key.LastUsedAt
+	}
+	return resp
+}
+
+// CreateAPIKeyResponse is a new API key. Key is shown only once and cannot be retrieved later.
+type CreateAPIKeyResponse struct {
+	Key string `json:"key"`
+	APIKeyResponse
+}
+
+// ImpersonationResponse is a short-lived token to act as User. Requests made with it
+// carry the administrator as the token's actor.
+type ImpersonationResponse struct {
+	AccessToken    string       `json:"access_token"`
+	User           UserResponse `json:"user"`
+	ImpersonatorID string       `json:"impersonator_id"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+}
+
+// IdentityResponse represents a login method linked to the user.
+type IdentityResponse struct {
+	ID       string    `json:"id"` // "password" for the email/password login method
+	Provider string    `json:"provider"`
+	Email    string    `json:"email,omitempty"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+// NewIdentityResponse maps a core.Identity to an IdentityResponse.
+func NewIdentityResponse(identity core.Identity) IdentityResponse {
+	return IdentityResponse{
+		ID:       identity.ID.String(),
+		Provider: identity.Provider,
+		Email:    identity.Email,
+		LinkedAt: identity.LinkedAt,
+	}
+}
+
+// AuthorizationURLResponse is returned when the client must redirect the user to a provider.
+type AuthorizationURLResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// ConsentPromptResponse asks the user to approve the scopes requested by a client.
+// The frontend submits the decision with the same parameters to the consent endpoint.
+type ConsentPromptResponse struct {
+	ConsentRequired bool             `json:"consent_required"`
+	ClientID        string           `json:"client_id"`
+	ClientName      string           `json:"client_name"`
+	Scopes          []string         `json:"scopes"`
+	Request         AuthorizeRequest `json:"request"`
+}
+
+// AuthorizationRedirectResponse tells the frontend where to send the user next:
+// the client's redirect URI with either a code or an error.
+type AuthorizationRedirectResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// OAuthClientResponse represents a registered OAuth2 client. ClientSecret is only
+// returned once, when the client is created.
+type OAuthClientResponse struct {
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	PostLogout   []string  `json:"post_logout_redirect_uris,omitempty"`
+	GrantTypes   []string  `json:"grant_types"`
+	Scopes       []string  `json:"scopes"`
+	Public       bool      `json:"public"`
+	FirstParty   bool      `json:"first_party"`
+	Resource     bool      `json:"resource_server"`
+	ServiceAcct  string    `json:"service_account_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NewOAuthClientResponse maps a core.OAuthClient to an OAuthClientResponse.
+func NewOAuthClientResponse(client core.OAuthClient) OAuthClientResponse {
+	resp := OAuthClientResponse{
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		PostLogout:   client.PostLogoutRedirectURIs,
+		GrantTypes:   client.GrantTypes,
+		Scopes:       client.Scopes,
+		Public:       client.IsPublic(),
+		FirstParty:   client.FirstParty,
+		Resource:     client.ResourceServer,
+		CreatedAt:    client.CreatedAt,
+	}
+	if client.ServiceAccountID != uuid.Nil {
+		resp.ServiceAcct = client.ServiceAccountID.String()
+	}
+	return resp
+}
+
+// OAuthTokenResponse is the standard token endpoint response (RFC 6749 section 5.1).
+// Unlike the other responses it is not wrapped in SuccessResponse, so that
+// off-the-shelf OAuth2 client libraries can consume it.
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"` // OpenID Connect, for the "openid" scope
+
+	IssuedTokenType string `json:"issued_token_type,omitempty"` // Token exchange only
+}
+
+// DeviceAuthorizationResponse is the device authorization endpoint response (RFC 8628 section 3.2).
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// DeviceVerificationResponse describes a pending device request for the user to approve.
+type DeviceVerificationResponse struct {
+	UserCode   string   `json:"user_code"`
+	ClientID   string   `json:"client_id"`
+	ClientName string   `json:"client_name"`
+	Scopes     []string `json:"scopes"`
+}
+
+// IntrospectionResponse is the token introspection response (RFC 7662 section 2.2).
+// Only Active is set for inactive tokens.
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Nbf       int64    `json:"nbf,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Role      string   `json:"role,omitempty"`
+
+	Act *token.Actor `json:"act,omitempty"` // Acting party of impersonated and exchanged tokens
+	Tid string       `json:"tid,omitempty"` // Organization the token is scoped to
+	Pty string       `json:"pty,omitempty"` // Principal type: user, service or client
+
+	Cnf *token.Confirmation `json:"cnf,omitempty"` // Key the token is bound to
+}
+
+// OAuthErrorResponse is the standard OAuth2 error response (RFC 6749 section 5.2).
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
 }
@@ -0,0 +1,348 @@
+package ginhandler
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shawgichan/go-authkit/oauthserver"
+)
+
+// RoutePaths are the paths RegisterRoutes mounts the handlers at, relative to its
+// group. A route with an empty path is not mounted. Paths of resources, such as
+// APIKeys, also prefix their item routes ("/:id").
+type RoutePaths struct {
+	// Public authentication routes
+	Register          string
+	Login             string
+	VerifyEmail       string // Verification links point here
+	OTPRequest        string // WithOTP
+	OTPVerify         string // WithOTP
+	OAuthLogin        string // WithOAuth; must contain the :provider parameter
+	OAuthCallback     string // WithOAuth; must contain the :provider parameter
+	Invitation        string // WithInvitations; invitation emails link here with the token
+	InvitationSignup  string // WithInvitations
+	InvitationDecline string // WithInvitations
+
+	// Cookie sessions (WithSessionCookies), mounted with CookieTransport
+	SessionLogin   string
+	SessionRefresh string
+	SessionLogout  string
+
+	// Routes of the authenticated user, behind RouteOptions.AuthMiddleware
+	UserInfo                string
+	Identities              string // WithIdentities; also behind RequireLogin
+	APIKeys                 string // WithAPIKeys; also behind RequireLogin
+	Organizations           string // WithOrganizations: the user's organizations
+	CreateOrganization      string // WithOrganizations
+	SwitchOrganization      string // WithOrganizations
+	AcceptInvitation        string // WithInvitations
+	OrganizationInvitations string // WithInvitations; for the organization owner role
+
+	// OAuth2 authorization server (WithAuthorizationServer)
+	Token               string
+	Introspect          string
+	Revoke              string
+	Authorize           string // Behind RouteOptions.AuthMiddleware
+	DeviceAuthorization string // WithDeviceAuthorization
+	Device              string // WithDeviceAuthorization; behind RouteOptions.AuthMiddleware
+
+	// OpenID Connect provider (WithOpenIDProvider)
+	OpenIDConfiguration string
+	JWKS                string
+	OIDCUserInfo        string // Behind RouteOptions.AuthMiddleware
+	EndSession          string // POST (confirmation) behind RouteOptions.AuthMiddleware
+
+	// Administration, behind RequireLogin and RouteOptions.AdminMiddleware
+	OAuthClients    string // WithAuthorizationServer
+	Impersonate     string // WithAuditLog
+	ServiceAccounts string // WithServiceAccounts
+}
+
+// DefaultRoutePaths returns the paths used by the example application.
+func DefaultRoutePaths() RoutePaths {
+	return RoutePaths{
+		Register:          "/auth/register",
+		Login:             "/auth/login",
+		VerifyEmail:       "/auth/verify-email",
+		OTPRequest:        "/auth/otp/request",
+		OTPVerify:         "/auth/otp/verify",
+		OAuthLogin:        "/auth/oauth/:provider/login",
+		OAuthCallback:     "/auth/oauth/:provider/callback",
+		Invitation:        "/auth/invitations",
+		InvitationSignup:  "/auth/invitations/signup",
+		InvitationDecline: "/auth/invitations/decline",
+
+		SessionLogin:   "/web/auth/login",
+		SessionRefresh: "/web/auth/refresh",
+		SessionLogout:  "/web/auth/logout",
+
+		UserInfo:                "/api/me",
+		Identities:              "/api/me/identities",
+		APIKeys:                 "/api/me/api-keys",
+		Organizations:           "/api/me/organizations",
+		CreateOrganization:      "/api/organizations",
+		SwitchOrganization:      "/api/organizations/switch",
+		AcceptInvitation:        "/api/invitations/accept",
+		OrganizationInvitations: "/api/organization/invitations",
+
+		Token:               "/oauth2/token",
+		Introspect:          "/oauth2/introspect",
+		Revoke:              "/oauth2/revoke",
+		Authorize:           "/api/oauth2/authorize",
+		DeviceAuthorization: "/oauth2/device_authorization",
+		Device:              "/api/oauth2/device",
+
+		OpenIDConfiguration: "/.well-known/openid-configuration",
+		JWKS:                "/oauth2/jwks",
+		OIDCUserInfo:        "/api/oauth2/userinfo",
+		EndSession:          "/oauth2/end-session",
+
+		OAuthClients:    "/admin/oauth2/clients",
+		Impersonate:     "/admin/impersonate",
+		ServiceAccounts: "/admin/service-accounts",
+	}
+}
+
+// RouteOptions configures RegisterRoutes.
+type RouteOptions struct {
+	Paths *RoutePaths // Defaults to DefaultRoutePaths()
+
+	// AuthMiddleware protects the routes of the authenticated user. It defaults to
+	// AuthMiddleware with the revocation, membership, client, API key, client certificate
+	// and DPoP checks of the features enabled on the handler.
+	AuthMiddleware gin.HandlerFunc
+	// AdminMiddleware protects the administration routes, after AuthMiddleware and
+	// RequireLogin. It defaults to GlobalRoleMiddleware with the configured admin role.
+	AdminMiddleware gin.HandlerFunc
+
+	DisableRegistration bool // No open sign-up; invited people can still sign up (WithInvitations)
+	DisableAdmin        bool // The administration routes are mounted by the application, if at all
+}
+
+// RegisterRoutes mounts the handlers of all features enabled on h on group. Routes of
+// features that are not enabled, and routes with an empty path, are not mounted.
+// URLs the config or the OpenID provider config leave empty are derived from the mounted
+// routes under AppBaseURL: email verification and invitation links, the device
+// verification URI, the discovery endpoints, and the refresh cookie path (the directory
+// of the session routes). RegisterRoutes is meant to be called once, while setting up
+// the router.
+func (h *AuthGinHandler) RegisterRoutes(group *gin.RouterGroup, opts RouteOptions) {
+	paths := DefaultRoutePaths()
+	if opts.Paths != nil {
+		paths = *opts.Paths
+	}
+	authMiddleware := opts.AuthMiddleware
+	if authMiddleware == nil {
+		authMiddleware = h.defaultAuthMiddleware()
+	}
+	adminMiddleware := opts.AdminMiddleware
+	if adminMiddleware == nil {
+		adminMiddleware = GlobalRoleMiddleware(h.config.AdminRole)
+	}
+	ownerMiddleware := RoleMiddleware(h.config.OrganizationOwnerRole)
+	// Account and administration routes need the user's own login session
+	loginMiddleware := RequireLogin()
+
+	mount := func(method, relativePath string, handlers ...gin.HandlerFunc) {
+		if relativePath != "" {
+			group.Handle(method, relativePath, handlers...)
+		}
+	}
+	routeURL := func(relativePath string) string {
+		if relativePath == "" {
+			return ""
+		}
+		return strings.TrimSuffix(h.config.AppBaseURL, "/") + path.Join(group.BasePath(), relativePath)
+	}
+	mountResource := func(method, resourcePath, itemPath string, handlers ...gin.HandlerFunc) {
+		if resourcePath != "" {
+			group.Handle(method, strings.TrimSuffix(resourcePath, "/")+itemPath, handlers...)
+		}
+	}
+
+	// Public authentication routes
+	if !opts.DisableRegistration {
+		mount(http.MethodPost, paths.Register, h.RegisterUser)
+	}
+	mount(http.MethodPost, paths.Login, h.LoginUser)
+	mount(http.MethodGet, paths.VerifyEmail, h.VerifyEmailHandler)
+	if paths.VerifyEmail != "" && h.config.EmailVerificationURI == "" {
+		h.auth.VerificationURI = routeURL(paths.VerifyEmail)
+	}
+	if h.otpStore != nil {
+		mount(http.MethodPost, paths.OTPRequest, h.RequestOTPHandler)
+		mount(http.MethodPost, paths.OTPVerify, h.VerifyOTPHandler)
+	}
+	if h.oauthProviders != nil {
+		mount(http.MethodGet, paths.OAuthLogin, h.OAuthLoginHandler)
+		mount(http.MethodGet, paths.OAuthCallback, h.OAuthCallbackHandler)
+	}
+	if h.invitations != nil {
+		mount(http.MethodGet, paths.Invitation, h.InvitationDetailsHandler)
+		h.invitationURI = routeURL(paths.Invitation)
+		mount(http.MethodPost, paths.InvitationSignup, h.InvitationSignupHandler)
+		mount(http.MethodPost, paths.InvitationDecline, h.DeclineInvitationHandler)
+	}
+
+	if h.sessionTokens != nil {
+		mount(http.MethodPost, paths.SessionLogin, CookieTransport(), h.LoginUser)
+		mount(http.MethodPost, paths.SessionRefresh, CookieTransport(), h.RefreshSessionHandler)
+		mount(http.MethodPost, paths.SessionLogout, CookieTransport(), h.LogoutHandler)
+		h.sessionCookiePath = commonDir(group.BasePath(), paths.SessionLogin, paths.SessionRefresh, paths.SessionLogout)
+	}
+
+	// Browser apps call the routes of the authenticated user with their session cookie
+	userMiddleware := authMiddleware
+	if h.sessionTokens != nil {
+		userMiddleware = func(c *gin.Context) {
+			c.Set(cookieTransportKey, true)
+			authMiddleware(c)
+		}
+	}
+
+	// Routes of the authenticated user
+	mount(http.MethodGet, paths.UserInfo, userMiddleware, h.UserInfoHandler)
+	if h.identities != nil {
+		mount(http.MethodGet, paths.Identities, userMiddleware, loginMiddleware, h.ListIdentitiesHandler)
+		mount(http.MethodPost, paths.Identities, userMiddleware, loginMiddleware, h.LinkIdentityHandler)
+		mountResource(http.MethodDelete, paths.Identities, "/:id", userMiddleware, loginMiddleware, h.UnlinkIdentityHandler)
+	}
+	if h.apiKeys != nil {
+		mount(http.MethodGet, paths.APIKeys, userMiddleware, loginMiddleware, h.ListAPIKeysHandler)
+		mount(http.MethodPost, paths.APIKeys, userMiddleware, loginMiddleware, h.CreateAPIKeyHandler)
+		mountResource(http.MethodDelete, paths.APIKeys, "/:id", userMiddleware, loginMiddleware, h.RevokeAPIKeyHandler)
+	}
+	if h.organizations != nil {
+		mount(http.MethodGet, paths.Organizations, userMiddleware, h.ListOrganizationsHandler)
+		mount(http.MethodPost, paths.CreateOrganization, userMiddleware, h.CreateOrganizationHandler)
+		mount(http.MethodPost, paths.SwitchOrganization, userMiddleware, h.SwitchOrganizationHandler)
+	}
+	if h.invitations != nil {
+		mount(http.MethodPost, paths.AcceptInvitation, userMiddleware, h.AcceptInvitationHandler)
+		mount(http.MethodPost, paths.OrganizationInvitations, userMiddleware, ownerMiddleware, h.CreateInvitationHandler)
+		mount(http.MethodGet, paths.OrganizationInvitations, userMiddleware, ownerMiddleware, h.ListInvitationsHandler)
+		mountResource(http.MethodDelete, paths.OrganizationInvitations, "/:id", userMiddleware, ownerMiddleware, h.RevokeInvitationHandler)
+		mountResource(http.MethodPost, paths.OrganizationInvitations, "/:id/resend", userMiddleware, ownerMiddleware, h.ResendInvitationHandler)
+	}
+
+	// OAuth2 authorization server and OpenID Connect provider
+	if h.oauthServer != nil {
+		mount(http.MethodPost, paths.Token, h.TokenHandler)
+		mount(http.MethodPost, paths.Introspect, h.IntrospectHandler)
+		mount(http.MethodPost, paths.Revoke, h.RevokeHandler)
+		mount(http.MethodGet, paths.Authorize, userMiddleware, h.AuthorizeHandler)
+		mount(http.MethodPost, paths.Authorize, userMiddleware, h.AuthorizeConsentHandler)
+		if h.deviceAuths != nil {
+			mount(http.MethodPost, paths.DeviceAuthorization, h.DeviceAuthorizationHandler)
+			mount(http.MethodGet, paths.Device, userMiddleware, h.DeviceVerificationHandler)
+			mount(http.MethodPost, paths.Device, userMiddleware, h.DeviceApprovalHandler)
+			h.deviceVerificationURI = routeURL(paths.Device)
+		}
+	}
+	if h.idTokenSigner != nil {
+		mount(http.MethodGet, paths.OpenIDConfiguration, h.OpenIDConfigurationHandler)
+		mount(http.MethodGet, paths.JWKS, h.JWKSHandler)
+		mount(http.MethodGet, paths.OIDCUserInfo, authMiddleware, h.OIDCUserInfoHandler)
+		mount(http.MethodGet, paths.EndSession, h.EndSessionHandler)
+		mount(http.MethodPost, paths.EndSession, userMiddleware, h.EndSessionConfirmHandler)
+		h.openIDConfig = h.derivedOpenIDConfig(paths, routeURL)
+	}
+
+	// Administration
+	if opts.DisableAdmin {
+		return
+	}
+	if h.oauthClients != nil {
+		mount(http.MethodPost, paths.OAuthClients, authMiddleware, loginMiddleware, adminMiddleware, h.CreateOAuthClientHandler)
+		mount(http.MethodGet, paths.OAuthClients, authMiddleware, loginMiddleware, adminMiddleware, h.ListOAuthClientsHandler)
+		mountResource(http.MethodDelete, paths.OAuthClients, "/:client_id", authMiddleware, loginMiddleware, adminMiddleware, h.DeleteOAuthClientHandler)
+	}
+	if h.auditLog != nil {
+		mount(http.MethodPost, paths.Impersonate, authMiddleware, loginMiddleware, adminMiddleware, h.ImpersonateUserHandler)
+	}
+	if h.serviceAccounts != nil {
+		mount(http.MethodPost, paths.ServiceAccounts, authMiddleware, loginMiddleware, adminMiddleware, h.CreateServiceAccountHandler)
+		mount(http.MethodGet, paths.ServiceAccounts, authMiddleware, loginMiddleware, adminMiddleware, h.ListServiceAccountsHandler)
+		mountResource(http.MethodPatch, paths.ServiceAccounts, "/:id", authMiddleware, loginMiddleware, adminMiddleware, h.UpdateServiceAccountHandler)
+		mountResource(http.MethodPost, paths.ServiceAccounts, "/:id/api-keys", authMiddleware, loginMiddleware, adminMiddleware, h.CreateServiceAccountAPIKeyHandler)
+		mountResource(http.MethodGet, paths.ServiceAccounts, "/:id/api-keys", authMiddleware, loginMiddleware, adminMiddleware, h.ListServiceAccountAPIKeysHandler)
+		mountResource(http.MethodDelete, paths.ServiceAccounts, "/:id/api-keys/:key_id", authMiddleware, loginMiddleware, adminMiddleware, h.RevokeServiceAccountAPIKeyHandler)
+	}
+}
+
+// defaultAuthMiddleware returns AuthMiddleware with the checks of the enabled features.
+func (h *AuthGinHandler) defaultAuthMiddleware() gin.HandlerFunc {
+	var opts []MiddlewareOption
+	if h.revocations != nil {
+		opts = append(opts, CheckRevocation(h.revocations))
+	}
+	if h.organizations != nil {
+		opts = append(opts, CheckMembership(h.organizations))
+	}
+	if h.oauthClients != nil {
+		opts = append(opts, CheckClients(h.oauthClients))
+	}
+	if h.apiKeys != nil {
+		opts = append(opts, AcceptAPIKeys(h.apiKeys))
+	}
+	if h.clientCerts != nil {
+		opts = append(opts, ClientCertificates(*h.clientCerts))
+	}
+	if h.dpopNonces != nil {
+		opts = append(opts, AcceptDPoP(h.dpopNonces))
+	}
+	return AuthMiddleware(h.tokenMaker, h.store, h.config, opts...)
+}
+
+// derivedOpenIDConfig fills the endpoints the OpenID provider config leaves empty with the
+// URLs of the mounted routes. The authorization endpoint is the application's consent
+// page, which calls the Authorize route, so it is never derived.
+func (h *AuthGinHandler) derivedOpenIDConfig(paths RoutePaths, routeURL func(string) string) oauthserver.OpenIDConfig {
+	cfg := h.openIDConfig
+	setDefault := func(field *string, relativePath string) {
+		if *field == "" {
+			*field = routeURL(relativePath)
+		}
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = strings.TrimSuffix(h.config.AppBaseURL, "/")
+	}
+	setDefault(&cfg.UserinfoEndpoint, paths.OIDCUserInfo)
+	setDefault(&cfg.JWKSURI, paths.JWKS)
+	setDefault(&cfg.EndSessionEndpoint, paths.EndSession)
+	if h.oauthServer != nil {
+		setDefault(&cfg.TokenEndpoint, paths.Token)
+		setDefault(&cfg.IntrospectionEndpoint, paths.Introspect)
+		setDefault(&cfg.RevocationEndpoint, paths.Revoke)
+		if h.deviceAuths != nil {
+			setDefault(&cfg.DeviceAuthorizationEndpoint, paths.DeviceAuthorization)
+		}
+	}
+	return cfg
+}
+
+// commonDir returns the longest directory containing all routePaths under basePath,
+// ignoring empty ones.
+func commonDir(basePath string, routePaths ...string) string {
+	var common []string
+	first := true
+	for _, p := range routePaths {
+		if p == "" {
+			continue
+		}
+		dir := strings.Split(strings.Trim(path.Dir(path.Join("/", basePath, p)), "/"), "/")
+		if first {
+			common, first = dir, false
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(dir) && common[n] == dir[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return "/" + strings.Join(common, "/")
+}
@@ -0,0 +1,59 @@
+package ginhandler
+
+import (
+	"testing"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+)
+
+func TestCommonDir(t *testing.T) {
+	tests := []struct {
+		basePath   string
+		routePaths []string
+		want       string
+	}{
+		{"/", []string{"/web/auth/login", "/web/auth/refresh", "/web/auth/logout"}, "/web/auth"},
+		{"/v1", []string{"/web/auth/login", "", "/web/auth/logout"}, "/v1/web/auth"},
+		{"/", []string{"/web/auth/login", "/session/refresh"}, "/"},
+		{"", []string{"/refresh"}, "/"},
+	}
+	for _, tt := range tests {
+		if got := commonDir(tt.basePath, tt.routePaths...); got != tt.want {
+			t.Errorf("commonDir(%q, %q) = %q, want %q", tt.basePath, tt.routePaths, got, tt.want)
+		}
+	}
+}
+
+func TestDerivedOpenIDConfig(t *testing.T) {
+	cfg := config.DefaultAuthConfig()
+	cfg.AppBaseURL = "https://auth.example.com/"
+	h := &AuthGinHandler{
+		config:       cfg,
+		oauthServer:  struct{ core.OAuthServerStorer }{}, // Only checked for nil
+		openIDConfig: oauthserver.OpenIDConfig{JWKSURI: "https://keys.example.com/jwks"},
+	}
+	paths := DefaultRoutePaths()
+	paths.Revoke = ""
+	routeURL := func(relativePath string) string {
+		if relativePath == "" {
+			return ""
+		}
+		return "https://auth.example.com/v1" + relativePath
+	}
+
+	got := h.derivedOpenIDConfig(paths, routeURL)
+	if got.Issuer != "https://auth.example.com" {
+		t.Errorf("Issuer = %q, want AppBaseURL without the trailing slash", got.Issuer)
+	}
+	if got.TokenEndpoint != "https://auth.example.com/v1/oauth2/token" {
+		t.Errorf("TokenEndpoint = %q, want the mounted token route", got.TokenEndpoint)
+	}
+	if got.JWKSURI != "https://keys.example.com/jwks" {
+		t.Errorf("JWKSURI = %q, want the configured URL", got.JWKSURI)
+	}
+	if got.RevocationEndpoint != "" || got.DeviceAuthorizationEndpoint != "" || got.AuthorizationEndpoint != "" {
+		t.Errorf("unmounted endpoints were derived: %+v", got)
+	}
+}
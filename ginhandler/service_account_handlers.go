@@ -0,0 +1,218 @@
+package ginhandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// CreateServiceAccountHandler creates a service account: a machine identity with its own
+// roles, no password and no email, that authenticates with API keys or an OAuth2 client
+// linked to it (client_credentials). Its tokens carry the "service" principal type.
+// It needs a login session, and callers may only grant roles they hold themselves.
+// Protect it with GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) CreateServiceAccountHandler(c *gin.Context) {
+	if h.serviceAccounts == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Service accounts are not enabled", nil)
+		return
+	}
+	authPayload, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	var req CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	if !canGrantRoles(c, authPayload, append([]string{req.Role}, req.Roles...)) {
+		return
+	}
+	role := req.Role
+	if role == "" {
+		role = h.config.ServiceAccountRole
+	}
+
+	account, err := h.store.CreateUser(c.Request.Context(), core.CreateUserParams{
+		Username: req.Name,
+		FullName: req.Description,
+		Role:     role,
+		Roles:    req.Roles,
+		Status:   core.StatusActive, // Nothing to verify
+		Type:     core.UserTypeService,
+	})
+	if err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to create service account: %w", err))
+		return
+	}
+	RespondWithSuccess(c, http.StatusCreated, NewSDKUserResponse(account))
+}
+
+// ListServiceAccountsHandler lists the service accounts. Protect it with GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) ListServiceAccountsHandler(c *gin.Context) {
+	if h.serviceAccounts == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Service accounts are not enabled", nil)
+		return
+	}
+	if _, ok := loginSession(c); !ok {
+		return
+	}
+	accounts, err := h.serviceAccounts.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	resp := make([]UserResponse, 0, len(accounts))
+	for _, account := range accounts {
+		resp = append(resp, NewSDKUserResponse(account))
+	}
+	RespondWithSuccess(c, http.StatusOK, resp)
+}
+
+// UpdateServiceAccountHandler changes a service account's description, roles or status
+// (route parameter "id"). Suspending it rejects its API keys and tokens immediately.
+// As with creation, callers may only grant roles they hold themselves. Protect it with GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) UpdateServiceAccountHandler(c *gin.Context) {
+	authPayload, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	var req UpdateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	var requested []string
+	if req.Role != nil {
+		requested = append(requested, *req.Role)
+	}
+	if req.Roles != nil {
+		requested = append(requested, *req.Roles...)
+	}
+	if !canGrantRoles(c, authPayload, requested) {
+		return
+	}
+	account, ok := h.serviceAccount(c)
+	if !ok {
+		return
+	}
+	updated, err := h.store.UpdateUser(c.Request.Context(), account.ID, core.UpdateUserParams{
+		FullName: req.Description,
+		Role:     req.Role,
+		Roles:    req.Roles,
+		Status:   req.Status,
+	})
+	if err != nil {
+		MapSDKErrorToHTTP(c, fmt.Errorf("failed to update service account: %w", err))
+		return
+	}
+	RespondWithSuccess(c, http.StatusOK, NewSDKUserResponse(updated))
+}
+
+// CreateServiceAccountAPIKeyHandler creates an API key for a service account (route
+// parameter "id"). The key is only returned in this response. Protect it with
+// GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) CreateServiceAccountAPIKeyHandler(c *gin.Context) {
+	if h.apiKeys == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "API keys are not enabled", nil)
+		return
+	}
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	account, ok := h.serviceAccount(c)
+	if !ok {
+		return
+	}
+	h.createAPIKey(c, req, account.ID, "")
+}
+
+// ListServiceAccountAPIKeysHandler lists a service account's API keys (route parameter "id").
+// Protect it with GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) ListServiceAccountAPIKeysHandler(c *gin.Context) {
+	if h.apiKeys == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "API keys are not enabled", nil)
+		return
+	}
+	account, ok := h.serviceAccount(c)
+	if !ok {
+		return
+	}
+	h.listAPIKeys(c, account.ID)
+}
+
+// RevokeServiceAccountAPIKeyHandler deletes an API key (route parameter "key_id") of a
+// service account (route parameter "id"). Protect it with GlobalRoleMiddleware(cfg.AdminRole).
+func (h *AuthGinHandler) RevokeServiceAccountAPIKeyHandler(c *gin.Context) {
+	if h.apiKeys == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "API keys are not enabled", nil)
+		return
+	}
+	account, ok := h.serviceAccount(c)
+	if !ok {
+		return
+	}
+	h.revokeAPIKey(c, account.ID, c.Param("key_id"))
+}
+
+// serviceAccount loads the service account named by the route parameter "id" for a caller
+// with a login session (see loginSession). It writes the error response and returns false
+// if there is none; human users are not found here.
+func (h *AuthGinHandler) serviceAccount(c *gin.Context) (core.User, bool) {
+	if h.serviceAccounts == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Service accounts are not enabled", nil)
+		return core.User{}, false
+	}
+	if _, ok := loginSession(c); !ok {
+		return core.User{}, false
+	}
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		MapSDKErrorToHTTP(c, core.ErrNotFound)
+		return core.User{}, false
+	}
+	account, err := h.store.GetUserByID(c.Request.Context(), accountID)
+	if err != nil {
+		if errors.Is(err, core.ErrUserDeleted) {
+			err = core.ErrNotFound
+		}
+		MapSDKErrorToHTTP(c, err)
+		return core.User{}, false
+	}
+	if !account.IsServiceAccount() {
+		MapSDKErrorToHTTP(c, core.ErrNotFound)
+		return core.User{}, false
+	}
+	return account, true
+}
+
+// canGrantRoles reports whether the caller holds every role in roles (empty entries are
+// ignored), so that nobody can mint a machine identity more privileged than themselves.
+// It writes a 403 response and returns false otherwise.
+func canGrantRoles(c *gin.Context, authPayload *token.Payload, roles []string) bool {
+	if role := roleNotHeld(authPayload, roles); role != "" {
+		MapSDKErrorToHTTP(c, fmt.Errorf("%w: cannot grant role %q", core.ErrForbidden, role))
+		return false
+	}
+	return true
+}
+
+// roleNotHeld returns a role in roles that is not among the caller's global roles, or ""
+// if the caller holds them all. Empty entries are ignored.
+func roleNotHeld(authPayload *token.Payload, roles []string) string {
+	held := authPayload.AllRoles()
+	for _, role := range roles {
+		if role != "" && !containsRoleFold(held, role) {
+			return role
+		}
+	}
+	return ""
+}
@@ -0,0 +1,288 @@
+package ginhandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// cookieTransportKey marks requests of route groups using CookieTransport.
+const cookieTransportKey = "cookie_transport"
+
+// CookieTransport makes a route group carry tokens in cookies instead of the
+// Authorization header, so browser apps never hold them in script-readable storage.
+// Login handlers in the group set HttpOnly access and refresh token cookies and a CSRF
+// cookie instead of returning the tokens, and AuthMiddleware reads the access token from
+// its cookie when there is no Authorization header. Cookie-authenticated requests with
+// unsafe methods must repeat the CSRF cookie in the CSRF header (double-submit); the CSRF
+// token is derived from the session's ID, so it is only valid for that session.
+// Cookie sessions must be enabled with WithSessionCookies.
+func CookieTransport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(cookieTransportKey, true)
+		c.Next()
+	}
+}
+
+// WithSessionCookies enables cookie sessions in route groups using CookieTransport.
+// Their refresh tokens are kept in refreshTokens, which may be the authorization
+// server's store: session tokens have no client ID and are not accepted at the token endpoint.
+func WithSessionCookies(refreshTokens core.RefreshTokenStorer) HandlerOption {
+	return func(h *AuthGinHandler) {
+		h.sessionTokens = refreshTokens
+	}
+}
+
+// usesCookieSession reports whether tokens issued for this request go into cookies.
+// Requests authenticated with the Authorization header get their tokens in the body.
+func (h *AuthGinHandler) usesCookieSession(c *gin.Context) bool {
+	return h.sessionTokens != nil && c.GetBool(cookieTransportKey) && c.GetHeader("Authorization") == ""
+}
+
+// RefreshSessionHandler issues new session cookies for the refresh token cookie. The
+// refresh token is rotated, and the new access token keeps the session's original
+// authentication time and organization.
+func (h *AuthGinHandler) RefreshSessionHandler(c *gin.Context) {
+	if h.sessionTokens == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Cookie sessions are not enabled", nil)
+		return
+	}
+	raw, err := c.Cookie(h.config.RefreshTokenCookieName)
+	if err != nil || raw == "" {
+		MapSDKErrorToHTTP(c, core.ErrTokenInvalid)
+		return
+	}
+
+	ctx := c.Request.Context()
+	tokenHash := oauthserver.HashToken(raw)
+	stored, err := h.sessionTokens.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			h.clearSessionCookies(c)
+			MapSDKErrorToHTTP(c, core.ErrTokenInvalid)
+			return
+		}
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if stored.ClientID != "" { // Issued by the authorization server, not a session
+		h.clearSessionCookies(c)
+		MapSDKErrorToHTTP(c, core.ErrTokenInvalid)
+		return
+	}
+	// The session ID is stored with the refresh token. It is checked before the token is
+	// consumed, so a forged refresh cannot end the session
+	if err := service.CheckCSRF(c.Request, h.config, stored.SessionID); err != nil {
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if stored, err = h.sessionTokens.ConsumeRefreshToken(ctx, tokenHash); err != nil {
+		if errors.Is(err, core.ErrNotFound) { // Refreshed concurrently
+			MapSDKErrorToHTTP(c, core.ErrTokenInvalid)
+			return
+		}
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		h.clearSessionCookies(c)
+		MapSDKErrorToHTTP(c, core.ErrTokenExpired)
+		return
+	}
+
+	user, err := h.store.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
+			h.clearSessionCookies(c)
+			MapSDKErrorToHTTP(c, core.ErrTokenInvalid)
+			return
+		}
+		MapSDKErrorToHTTP(c, err)
+		return
+	}
+	if !h.checkLoginStatus(c, user) {
+		h.clearSessionCookies(c)
+		return
+	}
+
+	opts := []token.PayloadOption{token.WithAuthTime(stored.AuthTime), token.WithSessionID(stored.SessionID)}
+	if stored.TenantID != "" && h.organizations != nil {
+		tenantOpt, err := h.sessionTenant(c, stored)
+		if err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+		opts = append(opts, tenantOpt...)
+	}
+	c.Set(cookieTransportKey, true) // Refreshing only makes sense for cookie sessions
+	h.respondWithLoginToken(c, user, opts...)
+}
+
+// sessionTenant returns the option scoping a refreshed token to the session's
+// organization, or none if the user has left it since.
+func (h *AuthGinHandler) sessionTenant(c *gin.Context, stored core.RefreshToken) ([]token.PayloadOption, error) {
+	orgID, err := uuid.Parse(stored.TenantID)
+	if err != nil {
+		return nil, nil
+	}
+	membership, err := h.organizations.GetMembership(c.Request.Context(), orgID, stored.UserID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	return []token.PayloadOption{token.WithTenant(stored.TenantID, membership.AllRoles()...)}, nil
+}
+
+// LogoutHandler ends a cookie session: it deletes the session's refresh token, revokes
+// its access token when token revocation is enabled, and clears the session cookies.
+func (h *AuthGinHandler) LogoutHandler(c *gin.Context) {
+	if h.sessionTokens == nil {
+		RespondWithError(c, http.StatusNotFound, "NOT_ENABLED", "Cookie sessions are not enabled", nil)
+		return
+	}
+	ctx := c.Request.Context()
+	refreshToken, _ := c.Cookie(h.config.RefreshTokenCookieName)
+	accessToken, _ := c.Cookie(h.config.AccessTokenCookieName)
+	if refreshToken == "" && accessToken == "" {
+		h.clearSessionCookies(c)
+		RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: "Logged out."})
+		return
+	}
+	// The CSRF token is checked against the access token's session, or the refresh token's
+	// if the access token has expired, before anything is deleted
+	var payload *token.Payload
+	if accessToken != "" {
+		payload, _ = h.verifyToken(accessToken)
+	}
+	if payload != nil {
+		if err := service.CheckCSRF(c.Request, h.config, payload.SessionID); err != nil {
+			MapSDKErrorToHTTP(c, err)
+			return
+		}
+	}
+
+	if refreshToken != "" {
+		tokenHash := oauthserver.HashToken(refreshToken)
+		if payload == nil {
+			stored, err := h.sessionTokens.GetRefreshToken(ctx, tokenHash)
+			if err != nil && !errors.Is(err, core.ErrNotFound) {
+				MapSDKErrorToHTTP(c, fmt.Errorf("failed to get refresh token: %w", err))
+				return
+			}
+			if err == nil {
+				if err := service.CheckCSRF(c.Request, h.config, stored.SessionID); err != nil {
+					MapSDKErrorToHTTP(c, err)
+					return
+				}
+			}
+		}
+		if _, err := h.sessionTokens.ConsumeRefreshToken(ctx, tokenHash); err != nil && !errors.Is(err, core.ErrNotFound) {
+			MapSDKErrorToHTTP(c, fmt.Errorf("failed to delete refresh token: %w", err))
+			return
+		}
+	}
+	if payload != nil {
+		if h.revocations != nil {
+			if err := h.revocations.RevokeToken(ctx, payload.TokenID, payload.ExpiredAt); err != nil {
+				MapSDKErrorToHTTP(c, fmt.Errorf("failed to revoke access token: %w", err))
+				return
+			}
+		}
+		if h.config.EnforceSingleDeviceLogin {
+			noToken := ""
+			if _, err := h.store.UpdateUser(ctx, payload.UserID, core.UpdateUserParams{ActiveToken: &noToken}); err != nil {
+				// h.logger.Error("Failed to clear active token", "error", err, "user_id", payload.UserID)
+				fmt.Printf("Warning: Failed to clear active token for %s: %v\n", payload.UserID, err)
+			}
+		}
+	}
+	h.clearSessionCookies(c)
+	RespondWithSuccess(c, http.StatusOK, MessageResponse{Message: "Logged out."})
+}
+
+// startCookieSession stores a refresh token for a new login token and sets the session
+// cookies. The tokens are removed from resp, which gets the CSRF token instead. A refresh
+// token the browser still holds, e.g. when switching organizations, is deleted.
+func (h *AuthGinHandler) startCookieSession(c *gin.Context, resp *TokenResponse, accessToken string, payload *token.Payload) error {
+	ctx := c.Request.Context()
+	if previous, err := c.Cookie(h.config.RefreshTokenCookieName); err == nil && previous != "" {
+		if _, err := h.sessionTokens.ConsumeRefreshToken(ctx, oauthserver.HashToken(previous)); err != nil && !errors.Is(err, core.ErrNotFound) {
+			return fmt.Errorf("failed to delete previous refresh token: %w", err)
+		}
+	}
+
+	refreshToken, err := oauthserver.GenerateToken()
+	if err != nil {
+		return err
+	}
+	csrfToken, err := service.CSRFToken(h.config, payload.SessionID)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(h.config.RefreshTokenDuration)
+	err = h.sessionTokens.StoreRefreshToken(ctx, core.RefreshToken{
+		TokenHash: oauthserver.HashToken(refreshToken),
+		UserID:    payload.UserID,
+		AuthTime:  payload.AuthenticatedAt(),
+		TenantID:  payload.TenantID,
+		SessionID: payload.SessionID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	h.setSessionCookie(c, h.config.AccessTokenCookieName, accessToken, h.config.CookiePath, payload.ExpiredAt, true)
+	h.setSessionCookie(c, h.config.RefreshTokenCookieName, refreshToken, h.refreshCookiePath(), expiresAt, true)
+	h.setSessionCookie(c, h.config.CSRFCookieName, csrfToken, h.config.CookiePath, expiresAt, false)
+	resp.AccessToken = ""
+	resp.CSRFToken = csrfToken
+	return nil
+}
+
+// clearSessionCookies expires the session cookies.
+func (h *AuthGinHandler) clearSessionCookies(c *gin.Context) {
+	h.setSessionCookie(c, h.config.AccessTokenCookieName, "", h.config.CookiePath, time.Time{}, true)
+	h.setSessionCookie(c, h.config.RefreshTokenCookieName, "", h.refreshCookiePath(), time.Time{}, true)
+	h.setSessionCookie(c, h.config.CSRFCookieName, "", h.config.CookiePath, time.Time{}, false)
+}
+
+// setSessionCookie sets a session cookie expiring at expiresAt, or deletes it if value is empty.
+func (h *AuthGinHandler) setSessionCookie(c *gin.Context, name, value, path string, expiresAt time.Time, httpOnly bool) {
+	maxAge := -1
+	if value != "" {
+		maxAge = int(time.Until(expiresAt).Seconds())
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   !h.config.CookieInsecure,
+		HttpOnly: httpOnly,
+		SameSite: h.config.CookieSameSite,
+	})
+}
+
+func (h *AuthGinHandler) refreshCookiePath() string {
+	if h.config.RefreshCookiePath != "" {
+		return h.config.RefreshCookiePath
+	}
+	if h.sessionCookiePath != "" {
+		return h.sessionCookiePath
+	}
+	return h.config.CookiePath
+}
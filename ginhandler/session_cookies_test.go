@@ -0,0 +1,90 @@
+package ginhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/service"
+)
+
+type memoryRefreshTokenStore struct {
+	core.RefreshTokenStorer
+	tokens map[string]core.RefreshToken
+}
+
+func (s memoryRefreshTokenStore) GetRefreshToken(ctx context.Context, tokenHash string) (core.RefreshToken, error) {
+	stored, ok := s.tokens[tokenHash]
+	if !ok {
+		return core.RefreshToken{}, core.ErrNotFound
+	}
+	return stored, nil
+}
+
+func (s memoryRefreshTokenStore) ConsumeRefreshToken(ctx context.Context, tokenHash string) (core.RefreshToken, error) {
+	stored, ok := s.tokens[tokenHash]
+	if !ok {
+		return core.RefreshToken{}, core.ErrNotFound
+	}
+	delete(s.tokens, tokenHash)
+	return stored, nil
+}
+
+func TestSessionCSRFBeforeConsume(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	const refreshToken = "refresh-token"
+	csrfToken, err := service.CSRFToken(cfg, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		csrf    string
+		want    int
+		consume bool
+	}{
+		{"forged refresh", "/refresh", "", http.StatusForbidden, false},
+		{"forged logout", "/logout", "", http.StatusForbidden, false},
+		{"logout", "/logout", csrfToken, http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memoryRefreshTokenStore{tokens: map[string]core.RefreshToken{oauthserver.HashToken(refreshToken): {
+				UserID:    uuid.New(),
+				SessionID: "session-1",
+				ExpiresAt: time.Now().Add(time.Hour),
+			}}}
+			h := &AuthGinHandler{config: cfg, sessionTokens: store}
+			r := gin.New()
+			r.POST("/refresh", h.RefreshSessionHandler)
+			r.POST("/logout", h.LogoutHandler)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.AddCookie(&http.Cookie{Name: cfg.RefreshTokenCookieName, Value: refreshToken})
+			if tt.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: cfg.CSRFCookieName, Value: tt.csrf})
+				req.Header.Set(cfg.CSRFHeaderName, tt.csrf)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if consumed := len(store.tokens) == 0; consumed != tt.consume {
+				t.Errorf("refresh token consumed = %v, want %v", consumed, tt.consume)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package grpcauth
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TokenCredentials attaches an access token to every call of a gRPC client, for
+// servers protected by Interceptor:
+//
+//	conn, err := grpc.NewClient(addr,
+//		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+//		grpc.WithPerRPCCredentials(&grpcauth.TokenCredentials{Token: accessToken}),
+//	)
+type TokenCredentials struct {
+	Token string
+	// Source returns the token for each call instead of Token, e.g. to refresh it
+	// before it expires.
+	Source func(ctx context.Context) (string, error)
+	// AllowInsecure allows sending the token without transport security, for local
+	// development only.
+	AllowInsecure bool
+}
+
+var _ credentials.PerRPCCredentials = (*TokenCredentials)(nil)
+
+// GetRequestMetadata returns the authorization metadata of a call.
+func (c *TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	accessToken := c.Token
+	if c.Source != nil {
+		var err error
+		accessToken, err = c.Source(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("grpcauth: get access token: %w", err)
+		}
+	}
+	if accessToken == "" {
+		return nil, fmt.Errorf("grpcauth: no access token")
+	}
+	return map[string]string{AuthorizationMetadataKey: "Bearer " + accessToken}, nil
+}
+
+// RequireTransportSecurity reports whether the token may only be sent over TLS.
+func (c *TokenCredentials) RequireTransportSecurity() bool {
+	return !c.AllowInsecure
+}
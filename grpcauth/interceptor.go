@@ -0,0 +1,229 @@
+// Package grpcauth authenticates gRPC calls with the SDK's access tokens. Interceptor
+// provides unary and stream server interceptors that read "authorization: Bearer
+// <token>" metadata, run the same token, binding, user status and session checks as the
+// HTTP middlewares, enforce per-method roles and put the payload in the call's context
+// (see token.FromContext). Errors are returned as gRPC statuses (see StatusError).
+// TokenCredentials attaches tokens on the client side.
+package grpcauth
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// AuthorizationMetadataKey is the metadata key carrying the access token.
+const AuthorizationMetadataKey = "authorization"
+
+// Interceptor authenticates the calls of a gRPC server:
+//
+//	auth := grpcauth.NewInterceptor(tokenMaker, userStore, cfg,
+//		grpcauth.PublicMethods("/grpc.health.v1.Health/Check"),
+//		grpcauth.RequireRoles("/billing.v1.Billing/", "billing"),
+//	)
+//	server := grpc.NewServer(grpc.UnaryInterceptor(auth.Unary()), grpc.StreamInterceptor(auth.Stream()))
+type Interceptor struct {
+	authenticator *service.Authenticator
+	publicMethods map[string]bool
+	methodRoles   map[string][]string
+}
+
+// Option configures optional checks of an Interceptor.
+type Option func(*Interceptor)
+
+// PublicMethods lets calls to the given methods through without a token, e.g. health
+// checks or a login method. Methods are full names such as "/pkg.Service/Method", or
+// "/pkg.Service/" for all methods of a service.
+func PublicMethods(methods ...string) Option {
+	return func(i *Interceptor) {
+		for _, method := range methods {
+			i.publicMethods[method] = true
+		}
+	}
+}
+
+// RequireRoles makes calls to method fail with PermissionDenied unless the user holds
+// any of the roles, in the token's organization if it is scoped to one. method is a
+// full name such as "/pkg.Service/Method", or "/pkg.Service/" for all methods of a
+// service; a method's own requirement takes precedence over its service's.
+func RequireRoles(method string, roles ...string) Option {
+	return func(i *Interceptor) {
+		i.methodRoles[method] = append(i.methodRoles[method], roles...)
+	}
+}
+
+// CheckRevocation rejects tokens that were revoked.
+func CheckRevocation(store core.TokenRevocationStorer) Option {
+	return func(i *Interceptor) {
+		i.authenticator.Revocations = store
+	}
+}
+
+// CheckMembership reloads the roles of tokens scoped to an organization from the
+// user's current membership, rejecting users who are no longer members.
+func CheckMembership(store core.OrganizationStorer) Option {
+	return func(i *Interceptor) {
+		i.authenticator.Organizations = store
+	}
+}
+
+// CheckClients rejects tokens issued to an OAuth2 client, on its own
+// behalf or a user's, once the client is deleted.
+func CheckClients(store core.OAuthClientStorer) Option {
+	return func(i *Interceptor) {
+		i.authenticator.OAuthClients = store
+	}
+}
+
+// VerifyWith replaces the token verification options, which default to the issuer,
+// audience and leeway in the config.
+func VerifyWith(opts ...token.VerifyOption) Option {
+	return func(i *Interceptor) {
+		i.authenticator.VerifyOptions = opts
+	}
+}
+
+// NewInterceptor creates an Interceptor verifying tokens with tokenMaker and checking
+// users in userStorer.
+func NewInterceptor(tokenMaker token.Maker, userStorer core.UserStorer, cfg *config.AuthConfig, opts ...Option) *Interceptor {
+	i := &Interceptor{
+		authenticator: service.NewAuthenticator(tokenMaker, userStorer, cfg),
+		publicMethods: make(map[string]bool),
+		methodRoles:   make(map[string][]string),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Unary returns the unary server interceptor.
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the stream server interceptor.
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate checks the token of a call to method and returns the context carrying
+// its payload.
+func (i *Interceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if i.publicMethods[method] || i.publicMethods[serviceName(method)] {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AuthorizationMetadataKey)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata is not provided")
+	}
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+	}
+	if !strings.EqualFold(fields[0], "bearer") {
+		return nil, status.Errorf(codes.Unauthenticated, "unsupported authorization type: %s", strings.ToLower(fields[0]))
+	}
+
+	accessToken := fields[1]
+	payload, err := i.authenticator.VerifyToken(ctx, accessToken)
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	if err := checkBinding(ctx, payload); err != nil {
+		return nil, StatusError(err)
+	}
+	if err := i.authenticator.CheckSession(ctx, payload, accessToken); err != nil {
+		return nil, StatusError(err)
+	}
+	if err := i.checkRoles(payload, method); err != nil {
+		return nil, err
+	}
+	return token.NewContext(ctx, payload), nil
+}
+
+// checkBinding checks sender-constrained tokens. gRPC calls carry no DPoP proofs, so
+// DPoP-bound tokens are rejected; certificate-bound tokens need the client certificate
+// the connection's TLS handshake verified.
+func checkBinding(ctx context.Context, payload *token.Payload) error {
+	if payload.DPoPThumbprint() != "" {
+		return fmt.Errorf("%w: DPoP-bound tokens cannot be used over gRPC", core.ErrDPoPProofInvalid)
+	}
+	if payload.CertificateThumbprint() == "" {
+		return nil
+	}
+	var cert *x509.Certificate
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if chains := tlsInfo.State.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+				cert = chains[0][0]
+			}
+		}
+	}
+	return service.CheckCertificateBinding(payload, cert)
+}
+
+// checkRoles enforces the roles required for method, if any.
+func (i *Interceptor) checkRoles(payload *token.Payload, method string) error {
+	allowedRoles, ok := i.methodRoles[method]
+	if !ok {
+		allowedRoles, ok = i.methodRoles[serviceName(method)]
+	}
+	if !ok {
+		return nil
+	}
+	roles := payload.EffectiveRoles()
+	for _, allowed := range allowedRoles {
+		for _, role := range roles {
+			if strings.EqualFold(role, allowed) {
+				return nil
+			}
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "access denied: roles %v are not in allowed roles %v", roles, allowedRoles)
+}
+
+// serviceName returns the "/pkg.Service/" prefix of a full method name.
+func serviceName(method string) string {
+	if idx := strings.LastIndex(method, "/"); idx > 0 {
+		return method[:idx+1]
+	}
+	return method
+}
+
+// authenticatedStream replaces the context of a server stream with the authenticated one.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,61 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/mtls"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+func TestSenderConstrainedTokens(t *testing.T) {
+	tokenMaker, err := token.NewPasetoMaker("grpcauth-symmetric-key-32-bytes!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Binding is checked before the user, so no user store is needed
+	interceptor := NewInterceptor(tokenMaker, nil, config.DefaultAuthConfig())
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	withCert := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+	}})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		cnf  token.Confirmation
+	}{
+		{"DPoP-bound", withCert, token.Confirmation{JKT: "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"}},
+		{"certificate-bound without certificate", context.Background(), token.Confirmation{X5tS256: mtls.Thumbprint(cert)}},
+		{"certificate-bound to another certificate", withCert, token.Confirmation{X5tS256: "bwcK0esc3ACC3DB2Y5_lESsXE8o9ltc05O89jdN-dg2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessToken, _, err := tokenMaker.CreateToken(uuid.New(), "ada", "user", time.Minute, token.WithConfirmation(tt.cnf))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := metadata.NewIncomingContext(tt.ctx, metadata.Pairs(AuthorizationMetadataKey, "Bearer "+accessToken))
+			_, err = interceptor.authenticate(ctx, "/billing.v1.Billing/Charge")
+			if status.Code(err) != codes.Unauthenticated {
+				t.Errorf("authenticate() error = %v, want Unauthenticated", err)
+			}
+		})
+	}
+
+	payload := &token.Payload{Confirmation: &token.Confirmation{X5tS256: mtls.Thumbprint(cert)}}
+	if err := checkBinding(withCert, payload); err != nil {
+		t.Errorf("checkBinding() with the bound certificate = %v, want nil", err)
+	}
+}
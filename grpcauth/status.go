@@ -0,0 +1,70 @@
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/shawgichan/go-authkit/httphandler"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details attached by StatusError.
+const ErrorDomain = "go-authkit"
+
+// StatusError converts a core SDK error to a gRPC status error. The code follows the
+// HTTP status the HTTP adapters respond with (see httphandler.ErrorStatus), and the
+// SDK error code, e.g. "MULTI_DEVICE_LOGIN", is attached as the Reason of an
+// errdetails.ErrorInfo. Internal errors get a generic message. Errors that already are
+// gRPC statuses are returned as is.
+func StatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	httpStatus, errCode := httphandler.ErrorStatus(err)
+	code, message := Code(httpStatus), err.Error()
+	if code == codes.Internal { // Unexpected errors may describe storage internals
+		message = "An unexpected error occurred"
+	}
+	st := status.New(code, message)
+	if withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{Reason: errCode, Domain: ErrorDomain}); detailsErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// Code returns the gRPC code corresponding to an HTTP status.
+func Code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed, http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
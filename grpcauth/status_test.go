@@ -0,0 +1,32 @@
+package grpcauth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{"sdk error", core.ErrTokenExpired, codes.Unauthenticated, core.ErrTokenExpired.Error()},
+		{"internal error", fmt.Errorf("failed to get user: %w", errors.New("pq: connection to 10.0.0.5 refused")), codes.Internal, "An unexpected error occurred"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(StatusError(tt.err))
+			if st.Code() != tt.code || st.Message() != tt.message {
+				t.Errorf("StatusError() = %s %q, want %s %q", st.Code(), st.Message(), tt.code, tt.message)
+			}
+		})
+	}
+}
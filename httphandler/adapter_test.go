@@ -0,0 +1,23 @@
+package httphandler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/shawgichan/go-authkit/authtest"
+	"github.com/shawgichan/go-authkit/httphandler"
+)
+
+func TestAdapter(t *testing.T) {
+	authtest.Run(t, func(t *testing.T, deps authtest.Deps) http.Handler {
+		h := httphandler.NewHandler(deps.Auth)
+		auth := httphandler.Middleware(deps.Authenticator)
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST "+authtest.RegisterPath, h.Register)
+		mux.HandleFunc("POST "+authtest.LoginPath, h.Login)
+		mux.HandleFunc("GET "+authtest.VerifyEmailPath, h.VerifyEmail)
+		mux.Handle("GET "+authtest.UserInfoPath, auth(http.HandlerFunc(h.UserInfo)))
+		mux.Handle("GET "+authtest.AdminPath, auth(httphandler.RequireRole("admin")(http.HandlerFunc(h.UserInfo))))
+		return mux
+	})
+}
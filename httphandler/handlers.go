@@ -0,0 +1,121 @@
+// Package httphandler serves the authentication service over plain net/http, for
+// services using the standard library or routers such as chi. Handlers are
+// http.HandlerFunc methods, middleware has the func(http.Handler) http.Handler shape,
+// and the verified token payload is read from the request context with token.FromContext.
+// Responses use the same JSON envelope and error codes as ginhandler.
+package httphandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// Handler serves the register, login, email verification and user info routes.
+type Handler struct {
+	auth *service.Auth
+}
+
+// NewHandler creates the handlers for auth.
+func NewHandler(auth *service.Auth) *Handler {
+	return &Handler{auth: auth}
+}
+
+// Register handles user registration and sends the verification link.
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	var req RegisterRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+
+	user, err := h.auth.Register(r.Context(), service.RegisterParams{
+		Email:       req.Email,
+		Password:    req.Password,
+		FullName:    req.FullName,
+		PhoneNumber: req.PhoneNumber,
+	})
+	if err != nil {
+		MapSDKErrorToHTTP(w, err)
+		return
+	}
+	if err := h.auth.SendVerificationLink(r.Context(), user); err != nil {
+		// Registration succeeds even if the link could not be sent
+		fmt.Printf("Warning: Failed to send verification link to %s: %v\n", user.Email, err)
+	}
+	RespondWithSuccess(w, http.StatusCreated, NewSDKUserResponse(user))
+}
+
+// Login handles password login and responds with an access token.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid request body", err.Error())
+		return
+	}
+
+	user, err := h.auth.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		MapSDKErrorToHTTP(w, err)
+		return
+	}
+	accessToken, payload, err := h.auth.IssueLoginToken(r.Context(), user)
+	if err != nil {
+		MapSDKErrorToHTTP(w, err)
+		return
+	}
+	RespondWithSuccess(w, http.StatusOK, TokenResponse{
+		AccessToken:    accessToken,
+		User:           NewSDKUserResponse(user),
+		ExpiresAt:      payload.ExpiredAt,
+		OrganizationID: payload.TenantID,
+	})
+}
+
+// VerifyEmail handles the email verification link (?token=...).
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	verificationToken := r.URL.Query().Get("token")
+	if verificationToken == "" {
+		RespondWithError(w, http.StatusBadRequest, "INVALID_REQUEST_QUERY", "Invalid query parameters", "token is required")
+		return
+	}
+	user, err := h.auth.VerifyEmail(r.Context(), verificationToken)
+	if err != nil {
+		MapSDKErrorToHTTP(w, err) // Handles ErrVerificationNotFound
+		return
+	}
+	RespondWithSuccess(w, http.StatusOK, MessageResponse{Message: fmt.Sprintf("Email for %s successfully verified.", user.Email)})
+}
+
+// UserInfo responds with the authenticated user. It must run behind Middleware.
+func (h *Handler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	payload, exists := token.FromContext(r.Context())
+	if !exists {
+		RespondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization payload not found", nil)
+		return
+	}
+	user, err := h.auth.GetUser(r.Context(), payload.UserID)
+	if err != nil {
+		MapSDKErrorToHTTP(w, err)
+		return
+	}
+	RespondWithSuccess(w, http.StatusOK, NewSDKUserResponse(user))
+}
+
+// maxBodyBytes limits request bodies read by the handlers.
+const maxBodyBytes = 1 << 20
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	return json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(v)
+}
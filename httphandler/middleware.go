@@ -0,0 +1,58 @@
+package httphandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/service"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// Middleware authenticates requests with "Authorization: Bearer <token>", and the
+// ApiKey and DPoP schemes if authenticator accepts them. It verifies the credential,
+// checks sender-constrained tokens and the user's status and active session (see
+// service.Authenticator.AuthenticateRequest), then stores the payload in the request
+// context (see token.FromContext).
+func Middleware(authenticator *service.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			payload, err := authenticator.AuthenticateRequest(r, false)
+			if err != nil {
+				if errors.Is(err, core.ErrDPoPProofInvalid) {
+					w.Header().Set("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+				}
+				MapSDKErrorToHTTP(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(token.NewContext(r.Context(), payload)))
+		})
+	}
+}
+
+// RequireRole passes requests whose user holds any of the allowed roles, in the token's
+// organization if it is scoped to one. It must run behind Middleware.
+func RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			payload, exists := token.FromContext(r.Context())
+			if !exists {
+				RespondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization payload not found for role check", nil)
+				return
+			}
+			roles := payload.EffectiveRoles()
+			for _, allowed := range allowedRoles {
+				for _, role := range roles {
+					if strings.EqualFold(role, allowed) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			errDetails := fmt.Sprintf("Access denied. Your roles %v are not in allowed roles: %v", roles, allowedRoles)
+			RespondWithError(w, http.StatusForbidden, "FORBIDDEN", "You do not have permission to access this resource", errDetails)
+		})
+	}
+}
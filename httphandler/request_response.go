@@ -0,0 +1,114 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// The binding tags are used by ginhandler; the other adapters check the same
+// rules with Validate.
+
+// RegisterRequest defines the expected body for user registration.
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"` // service.MinPasswordLength
+	FullName string `json:"full_name" binding:"required"`
+	// PhoneNumber is optional and only needed for SMS one-time passcodes.
+	PhoneNumber string `json:"phone_number,omitempty"`
+	// Role is typically set by the system (e.g., default role from config)
+	// or handled by application-specific logic if allowed from request.
+	// For a generic SDK, role might not be part of the direct request here.
+}
+
+// Validate checks the required fields. The password length is checked by service.Auth.
+func (r RegisterRequest) Validate() error {
+	if err := validateEmail(r.Email); err != nil {
+		return err
+	}
+	if r.FullName == "" {
+		return fmt.Errorf("full_name is required")
+	}
+	return nil
+}
+
+// LoginRequest defines the expected body for user login.
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Validate checks the required fields.
+func (r LoginRequest) Validate() error {
+	if err := validateEmail(r.Email); err != nil {
+		return err
+	}
+	if r.Password == "" {
+		return fmt.Errorf("password is required")
+	}
+	return nil
+}
+
+func validateEmail(email string) error {
+	if email == "" {
+		return fmt.Errorf("email is required")
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return fmt.Errorf("email is not a valid email address")
+	}
+	return nil
+}
+
+// UserResponse is a generic representation of a user for API responses.
+// It omits sensitive information like PasswordHash.
+type UserResponse struct {
+	ID        uuid.UUID       `json:"id"`
+	Username  string          `json:"username"`
+	Email     string          `json:"email"`
+	Phone     string          `json:"phone_number,omitempty"`
+	FullName  string          `json:"full_name"`
+	Role      string          `json:"role"`
+	Roles     []string        `json:"roles,omitempty"` // Additional roles
+	Status    core.UserStatus `json:"status"`          // Use core.UserStatus type
+	Type      core.UserType   `json:"type,omitempty"`  // "service" for service accounts
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	// Add other non-sensitive fields you want to expose
+}
+
+// NewSDKUserResponse maps a core.User to a UserResponse.
+func NewSDKUserResponse(user core.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Phone:     user.PhoneNumber,
+		FullName:  user.FullName,
+		Role:      user.Role,
+		Roles:     user.Roles,
+		Status:    user.Status,
+		Type:      user.Type,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// TokenResponse is returned on successful login or token refresh.
+type TokenResponse struct {
+	AccessToken string       `json:"access_token,omitempty"` // Omitted for cookie sessions
+	User        UserResponse `json:"user"`
+	ExpiresAt   time.Time    `json:"expires_at"` // Expiry of the access token
+
+	OrganizationID string `json:"organization_id,omitempty"` // Organization the token is scoped to
+	TokenType      string `json:"token_type,omitempty"`      // "DPoP" for DPoP-bound tokens
+	CSRFToken      string `json:"csrf_token,omitempty"`      // Cookie sessions: send it in the CSRF header
+}
+
+// MessageResponse is for simple status messages.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,153 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// ErrorResponse is a generic JSON error response.
+type ErrorResponse struct {
+	Status  string      `json:"status"`
+	Code    string      `json:"code"` // SDK-defined error code or HTTP status text
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// SuccessResponse is a generic JSON success response.
+type SuccessResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// RespondWithError sends a JSON error response.
+func RespondWithError(w http.ResponseWriter, httpStatusCode int, sdkErrorCode string, message string, details interface{}) {
+	writeJSON(w, httpStatusCode, ErrorResponse{
+		Status:  "error",
+		Code:    sdkErrorCode,
+		Message: message,
+		Details: details,
+	})
+}
+
+// RespondWithSuccess sends a JSON success response.
+func RespondWithSuccess(w http.ResponseWriter, httpStatusCode int, data interface{}) {
+	writeJSON(w, httpStatusCode, SuccessResponse{
+		Status: "success",
+		Data:   data,
+	})
+}
+
+// MapSDKErrorToHTTP sends the error response for a core SDK error.
+func MapSDKErrorToHTTP(w http.ResponseWriter, err error) {
+	status, code := ErrorStatus(err)
+	message := "An unexpected error occurred"
+	if err != nil { // Default message from error
+		message = err.Error()
+	}
+	RespondWithError(w, status, code, message, nil)
+}
+
+// ErrorStatus maps a core SDK error to an HTTP status and SDK error code.
+// Unknown errors are internal errors.
+func ErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, core.ErrNotFound):
+		return http.StatusNotFound, "NOT_FOUND"
+	case errors.Is(err, core.ErrDuplicateEmail), errors.Is(err, core.ErrDuplicateUsername):
+		return http.StatusConflict, "CONFLICT_RESOURCE"
+	case errors.Is(err, core.ErrInvalidCredentials):
+		return http.StatusUnauthorized, "INVALID_CREDENTIALS"
+	case errors.Is(err, core.ErrUserNotVerified):
+		return http.StatusForbidden, "USER_NOT_VERIFIED"
+	case errors.Is(err, core.ErrTokenInvalid), errors.Is(err, core.ErrTokenExpired):
+		return http.StatusUnauthorized, "INVALID_TOKEN"
+	case errors.Is(err, core.ErrVerificationNotFound):
+		return http.StatusBadRequest, "INVALID_VERIFICATION_TOKEN"
+	case errors.Is(err, core.ErrForbidden):
+		return http.StatusForbidden, "FORBIDDEN"
+	case errors.Is(err, core.ErrOTPNotFound), errors.Is(err, core.ErrOTPInvalid), errors.Is(err, core.ErrOTPExpired):
+		return http.StatusUnauthorized, "INVALID_OTP"
+	case errors.Is(err, core.ErrOTPAttemptsExceeded):
+		return http.StatusTooManyRequests, "OTP_ATTEMPTS_EXCEEDED"
+	case errors.Is(err, core.ErrOTPChannelUnavailable):
+		return http.StatusBadRequest, "OTP_CHANNEL_UNAVAILABLE"
+	case errors.Is(err, core.ErrOAuthStateNotFound):
+		return http.StatusBadRequest, "INVALID_OAUTH_STATE"
+	case errors.Is(err, core.ErrOAuthProviderNotFound):
+		return http.StatusNotFound, "OAUTH_PROVIDER_NOT_FOUND"
+	case errors.Is(err, core.ErrEmailNotVerified):
+		return http.StatusForbidden, "EMAIL_NOT_VERIFIED"
+	case errors.Is(err, core.ErrIdentityAlreadyLinked):
+		return http.StatusConflict, "IDENTITY_ALREADY_LINKED"
+	case errors.Is(err, core.ErrLastLoginMethod):
+		return http.StatusConflict, "LAST_LOGIN_METHOD"
+	case errors.Is(err, core.ErrReauthRequired):
+		return http.StatusUnauthorized, "REAUTHENTICATION_REQUIRED"
+	case errors.Is(err, core.ErrInsufficientScope):
+		return http.StatusForbidden, "INSUFFICIENT_SCOPE"
+	case errors.Is(err, core.ErrTokenRevoked):
+		return http.StatusUnauthorized, "TOKEN_REVOKED"
+	case errors.Is(err, core.ErrImpersonationDenied):
+		return http.StatusForbidden, "IMPERSONATION_DENIED"
+	case errors.Is(err, core.ErrPermissionDenied):
+		return http.StatusForbidden, "PERMISSION_DENIED"
+	case errors.Is(err, core.ErrAccessDenied):
+		return http.StatusForbidden, "ACCESS_DENIED"
+	case errors.Is(err, core.ErrNotMember):
+		return http.StatusForbidden, "NOT_A_MEMBER"
+	case errors.Is(err, core.ErrAlreadyMember):
+		return http.StatusConflict, "ALREADY_MEMBER"
+	case errors.Is(err, core.ErrInvitationNotFound):
+		return http.StatusNotFound, "INVITATION_NOT_FOUND"
+	case errors.Is(err, core.ErrInvitationPending):
+		return http.StatusConflict, "INVITATION_PENDING"
+	case errors.Is(err, core.ErrInvitationLimit):
+		return http.StatusTooManyRequests, "INVITATION_LIMIT_REACHED"
+	case errors.Is(err, core.ErrInvitationEmail):
+		return http.StatusForbidden, "INVITATION_EMAIL_MISMATCH"
+	case errors.Is(err, core.ErrAPIKeyInvalid):
+		return http.StatusUnauthorized, "API_KEY_INVALID"
+	case errors.Is(err, core.ErrAPIKeyLimit):
+		return http.StatusTooManyRequests, "API_KEY_LIMIT_REACHED"
+	case errors.Is(err, core.ErrSignatureInvalid), errors.Is(err, core.ErrSignatureExpired):
+		return http.StatusUnauthorized, "SIGNATURE_INVALID"
+	case errors.Is(err, core.ErrRequestReplayed):
+		return http.StatusUnauthorized, "REQUEST_REPLAYED"
+	case errors.Is(err, core.ErrClientCertRequired):
+		return http.StatusUnauthorized, "CLIENT_CERTIFICATE_REQUIRED"
+	case errors.Is(err, core.ErrCertificateMismatch):
+		return http.StatusUnauthorized, "CERTIFICATE_MISMATCH"
+	case errors.Is(err, core.ErrDPoPProofInvalid):
+		return http.StatusUnauthorized, "INVALID_DPOP_PROOF"
+	case errors.Is(err, core.ErrCSRFTokenInvalid):
+		return http.StatusForbidden, "CSRF_TOKEN_INVALID"
+	case errors.Is(err, core.ErrTooManyAttempts):
+		return http.StatusTooManyRequests, "TOO_MANY_ATTEMPTS"
+	case errors.Is(err, core.ErrAuthorizationMissing), errors.Is(err, core.ErrAuthorizationInvalid):
+		return http.StatusUnauthorized, "UNAUTHORIZED"
+	case errors.Is(err, core.ErrPasswordTooShort):
+		return http.StatusBadRequest, "VALIDATION_ERROR"
+	case errors.Is(err, core.ErrUserInactive), errors.Is(err, core.ErrUserSuspended):
+		return http.StatusForbidden, "ACCOUNT_INACTIVE"
+	case errors.Is(err, core.ErrUserNotFound):
+		return http.StatusUnauthorized, "USER_NOT_FOUND"
+	case errors.Is(err, core.ErrSessionExpired):
+		return http.StatusUnauthorized, "SESSION_EXPIRED"
+	case errors.Is(err, core.ErrSessionReplaced):
+		return http.StatusUnauthorized, "MULTI_DEVICE_LOGIN"
+	default:
+		return http.StatusInternalServerError, "INTERNAL_ERROR"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		fmt.Printf("Warning: Failed to write response: %v\n", err)
+	}
+}
@@ -0,0 +1,106 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONWebKeyRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, MinRSAKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		alg string
+		key crypto.Signer
+	}{
+		{"RS256", rsaKey},
+		{"ES256", ecKey},
+		{"EdDSA", edKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			jwk, err := NewJSONWebKey(tt.key.Public(), "k1", tt.alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(JSONWebKeySet{Keys: []JSONWebKey{jwk}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var set JSONWebKeySet
+			if err := json.Unmarshal(b, &set); err != nil {
+				t.Fatal(err)
+			}
+			keys := set.Find("k1")
+			if len(keys) != 1 {
+				t.Fatalf("Find(k1) returned %d keys, want 1", len(keys))
+			}
+			pub, err := keys[0].PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			raw, err := Sign(Header{Alg: tt.alg, Kid: "k1", Typ: "JWT"}, map[string]string{"sub": "alice"}, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jws, err := Parse(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := jws.Verify(pub); err != nil {
+				t.Errorf("Verify with the key decoded from the JWKS: %v", err)
+			}
+		})
+	}
+}
+
+func TestJSONWebKeyRejectsSmallRSAKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err := NewJSONWebKey(key.Public(), "", "RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwk.PublicKey(); err == nil {
+		t.Error("PublicKey accepted a 1024-bit RSA key")
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// The example key of RFC 7638, section 3.1
+	jwk := JSONWebKey{
+		Kty: "RSA",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMst" +
+			"n64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5" +
+			"hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+		Alg: "RS256",
+		Kid: "2011-04-29",
+	}
+	got, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("Thumbprint = %q, want %q", got, want)
+	}
+}
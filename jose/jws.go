@@ -0,0 +1,225 @@
+// Package jose implements the subset of JWS (RFC 7515) and JWK (RFC 7517) needed
+// for OpenID Connect ID tokens and DPoP proofs: compact serialization with the
+// RS256/384/512, PS256, ES256/384/512 and EdDSA algorithms.
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256" // Register hash functions used by the algorithms
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrMalformed        = errors.New("malformed jws")
+	ErrUnsupportedAlg   = errors.New("unsupported jws algorithm")
+	ErrInvalidSignature = errors.New("invalid jws signature")
+)
+
+// Header is the protected JOSE header.
+type Header struct {
+	Alg string      `json:"alg"`
+	Kid string      `json:"kid,omitempty"`
+	Typ string      `json:"typ,omitempty"`
+	JWK *JSONWebKey `json:"jwk,omitempty"` // Used by DPoP proofs
+}
+
+// JWS is a parsed, not yet verified, compact JWS.
+type JWS struct {
+	Header    Header
+	Payload   []byte
+	signed    string // base64url(header) "." base64url(payload)
+	signature []byte
+}
+
+// Parse decodes a compact JWS. The signature is not checked; call Verify.
+func Parse(compact string) (*JWS, error) {
+	parts := strings.Split(compact, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	enc := base64.RawURLEncoding
+	rawHeader, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: header encoding", ErrMalformed)
+	}
+	var h Header
+	if err := json.Unmarshal(rawHeader, &h); err != nil {
+		return nil, fmt.Errorf("%w: header json", ErrMalformed)
+	}
+	payload, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload encoding", ErrMalformed)
+	}
+	sig, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrMalformed)
+	}
+	return &JWS{Header: h, Payload: payload, signed: parts[0] + "." + parts[1], signature: sig}, nil
+}
+
+// Claims decodes the payload into v.
+func (j *JWS) Claims(v interface{}) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("%w: payload json", ErrMalformed)
+	}
+	return nil
+}
+
+// Verify checks the signature with the given public key, using the algorithm from the header.
+// Callers must check that the header algorithm is one they accept before calling Verify.
+func (j *JWS) Verify(pub crypto.PublicKey) error {
+	alg, ok := algorithms[j.Header.Alg]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlg, j.Header.Alg)
+	}
+	return alg.verify(pub, []byte(j.signed), j.signature)
+}
+
+// Sign serializes claims and signs them with key, returning the compact JWS.
+func Sign(header Header, claims interface{}, key crypto.Signer) (string, error) {
+	alg, ok := algorithms[header.Alg]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAlg, header.Alg)
+	}
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(h) + "." + enc.EncodeToString(p)
+	sig, err := alg.sign(key, []byte(signed))
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+// SupportedAlgorithm reports whether alg can be signed and verified by this package.
+func SupportedAlgorithm(alg string) bool {
+	_, ok := algorithms[alg]
+	return ok
+}
+
+// Algorithms returns the names of the algorithms supported by this package, sorted.
+func Algorithms() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HashForAlgorithm returns the hash function associated with alg (e.g. SHA-256 for RS256),
+// as used for OpenID Connect at_hash/c_hash and DPoP ath values.
+func HashForAlgorithm(alg string) (crypto.Hash, bool) {
+	a, ok := algorithms[alg]
+	return a.hash, ok
+}
+
+type algorithm struct {
+	hash   crypto.Hash
+	kind   string // "rsa", "pss", "ecdsa", "eddsa"
+	ecSize int    // ECDSA coordinate size in bytes
+}
+
+var algorithms = map[string]algorithm{
+	"RS256": {hash: crypto.SHA256, kind: "rsa"},
+	"RS384": {hash: crypto.SHA384, kind: "rsa"},
+	"RS512": {hash: crypto.SHA512, kind: "rsa"},
+	"PS256": {hash: crypto.SHA256, kind: "pss"},
+	"ES256": {hash: crypto.SHA256, kind: "ecdsa", ecSize: 32},
+	"ES384": {hash: crypto.SHA384, kind: "ecdsa", ecSize: 48},
+	"ES512": {hash: crypto.SHA512, kind: "ecdsa", ecSize: 66},
+	"EdDSA": {hash: crypto.SHA512, kind: "eddsa"},
+}
+
+func (a algorithm) digest(data []byte) []byte {
+	h := a.hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (a algorithm) verify(pub crypto.PublicKey, data, sig []byte) error {
+	switch a.kind {
+	case "rsa", "pss":
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key is %T, want rsa", ErrInvalidSignature, pub)
+		}
+		var err error
+		if a.kind == "rsa" {
+			err = rsa.VerifyPKCS1v15(k, a.hash, a.digest(data), sig)
+		} else {
+			err = rsa.VerifyPSS(k, a.hash, a.digest(data), sig, nil)
+		}
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case "ecdsa":
+		k, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key is %T, want ecdsa", ErrInvalidSignature, pub)
+		}
+		if len(sig) != 2*a.ecSize {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(sig[:a.ecSize])
+		s := new(big.Int).SetBytes(sig[a.ecSize:])
+		if !ecdsa.Verify(k, a.digest(data), r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case "eddsa":
+		k, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key is %T, want ed25519", ErrInvalidSignature, pub)
+		}
+		if !ed25519.Verify(k, data, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return ErrUnsupportedAlg
+}
+
+func (a algorithm) sign(key crypto.Signer, data []byte) ([]byte, error) {
+	switch a.kind {
+	case "rsa":
+		return key.Sign(rand.Reader, a.digest(data), a.hash)
+	case "pss":
+		return key.Sign(rand.Reader, a.digest(data), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: a.hash})
+	case "ecdsa":
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: key is %T, want ecdsa", ErrUnsupportedKey, key)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, k, a.digest(data))
+		if err != nil {
+			return nil, err
+		}
+		sig := make([]byte, 2*a.ecSize)
+		r.FillBytes(sig[:a.ecSize])
+		s.FillBytes(sig[a.ecSize:])
+		return sig, nil
+	case "eddsa":
+		return key.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	return nil, ErrUnsupportedAlg
+}
@@ -0,0 +1,55 @@
+package mtls
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// Mapper maps a verified client certificate to the user or service account it identifies.
+type Mapper interface {
+	MapCertificate(ctx context.Context, cert *x509.Certificate) (core.User, error)
+}
+
+// MapperFunc adapts a function to a Mapper.
+type MapperFunc func(ctx context.Context, cert *x509.Certificate) (core.User, error)
+
+// MapCertificate calls f.
+func (f MapperFunc) MapCertificate(ctx context.Context, cert *x509.Certificate) (core.User, error) {
+	return f(ctx, cert)
+}
+
+// Identities returns the names a certificate asserts, most specific first: URI SANs
+// (e.g. SPIFFE IDs), DNS SANs, email SANs, then the subject common name.
+func Identities(cert *x509.Certificate) []string {
+	var ids []string
+	for _, uri := range cert.URIs {
+		ids = append(ids, uri.String())
+	}
+	ids = append(ids, cert.DNSNames...)
+	ids = append(ids, cert.EmailAddresses...)
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	return ids
+}
+
+// IdentityMapper returns a Mapper that calls lookup with each of the certificate's
+// Identities until one is found. lookup returns core.ErrNotFound for unknown names.
+// A certificate matching no user fails with core.ErrInvalidCredentials.
+func IdentityMapper(lookup func(ctx context.Context, identity string) (core.User, error)) Mapper {
+	return MapperFunc(func(ctx context.Context, cert *x509.Certificate) (core.User, error) {
+		for _, id := range Identities(cert) {
+			user, err := lookup(ctx, id)
+			if err == nil {
+				return user, nil
+			}
+			if !errors.Is(err, core.ErrNotFound) {
+				return core.User{}, err
+			}
+		}
+		return core.User{}, core.ErrInvalidCredentials
+	})
+}
@@ -0,0 +1,65 @@
+package mtls
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+func TestIdentityMapper(t *testing.T) {
+	spiffeID, _ := url.Parse("spiffe://example.com/billing")
+	cert := &x509.Certificate{
+		URIs:           []*url.URL{spiffeID},
+		DNSNames:       []string{"billing.internal"},
+		EmailAddresses: []string{"billing@example.com"},
+		Subject:        pkix.Name{CommonName: "billing"},
+	}
+	want := []string{"spiffe://example.com/billing", "billing.internal", "billing@example.com", "billing"}
+	if got := Identities(cert); !slices.Equal(got, want) {
+		t.Fatalf("Identities() = %v, want %v", got, want)
+	}
+
+	errStore := errors.New("store unavailable")
+	tests := []struct {
+		name    string
+		users   map[string]string // Identity -> username
+		failOn  string            // Identity whose lookup fails
+		want    string
+		wantErr error
+	}{
+		{"URI SAN first", map[string]string{"billing": "cn", "billing.internal": "dns", "spiffe://example.com/billing": "uri"}, "", "uri", nil},
+		{"DNS SAN before email", map[string]string{"billing@example.com": "email", "billing.internal": "dns"}, "", "dns", nil},
+		{"common name last", map[string]string{"billing": "cn"}, "", "cn", nil},
+		{"no match", map[string]string{"payroll": "payroll"}, "", "", core.ErrInvalidCredentials},
+		{"lookup error stops the search", map[string]string{"billing": "cn"}, "billing.internal", "", errStore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var looked []string
+			mapper := IdentityMapper(func(ctx context.Context, identity string) (core.User, error) {
+				looked = append(looked, identity)
+				if identity == tt.failOn {
+					return core.User{}, errStore
+				}
+				username, ok := tt.users[identity]
+				if !ok {
+					return core.User{}, core.ErrNotFound
+				}
+				return core.User{Username: username}, nil
+			})
+			user, err := mapper.MapCertificate(context.Background(), cert)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MapCertificate() = %v, want %v (looked up %v)", err, tt.wantErr, looked)
+			}
+			if user.Username != tt.want {
+				t.Errorf("MapCertificate() = %q, want %q (looked up %v)", user.Username, tt.want, looked)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package mtls authenticates callers by TLS client certificate, either verified by this
+// server's own TLS listener or forwarded by a TLS-terminating proxy, and supports
+// certificate-bound access tokens (RFC 8705).
+package mtls
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// Thumbprint returns the base64url SHA-256 thumbprint of cert, the value of the
+// "x5t#S256" confirmation claim.
+func Thumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Source extracts the client certificate of a request. The zero value only accepts
+// certificates verified by the server's own TLS listener, which must request them
+// (tls.Config.ClientAuth VerifyClientCertIfGiven or RequireAndVerifyClientCert).
+type Source struct {
+	// Header carrying the certificate forwarded by a TLS-terminating proxy, as a
+	// URL-escaped PEM (nginx $ssl_client_escaped_cert) or base64 DER. Empty disables it.
+	Header string
+	// TrustedProxies are the peers whose Header is believed; it is ignored from anyone else.
+	TrustedProxies []netip.Prefix
+	// Roots verifies forwarded certificates. Nil relies on the proxy's verification.
+	Roots *x509.CertPool
+}
+
+// Certificate returns the verified client certificate of r, or core.ErrClientCertRequired.
+func (s Source) Certificate(r *http.Request) (*x509.Certificate, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return r.TLS.VerifiedChains[0][0], nil
+	}
+	if s.Header == "" || !s.fromTrustedProxy(r) {
+		return nil, core.ErrClientCertRequired
+	}
+	value := r.Header.Get(s.Header)
+	if value == "" {
+		return nil, core.ErrClientCertRequired
+	}
+	cert, err := parseForwarded(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrClientCertRequired, err)
+	}
+	if err := s.verify(cert); err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrClientCertRequired, err)
+	}
+	return cert, nil
+}
+
+func (s Source) fromTrustedProxy(r *http.Request) bool {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := peer.Addr().Unmap()
+	for _, prefix := range s.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Source) verify(cert *x509.Certificate) error {
+	if s.Roots == nil {
+		now := time.Now()
+		if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			return fmt.Errorf("certificate is not valid at %s", now.Format(time.RFC3339))
+		}
+		return nil
+	}
+	_, err := cert.Verify(x509.VerifyOptions{Roots: s.Roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	return err
+}
+
+// parseForwarded decodes a certificate header value: URL-escaped or plain PEM, or base64 DER.
+func parseForwarded(value string) (*x509.Certificate, error) {
+	// PathUnescape keeps the "+" of base64, which the proxy escapes as %2B
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		value = unescaped
+	}
+	var der []byte
+	if strings.Contains(value, "-----BEGIN") {
+		block, _ := pem.Decode([]byte(value))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("forwarded certificate is not a PEM certificate")
+		}
+		der = block.Bytes
+	} else {
+		var err error
+		if der, err = base64.StdEncoding.DecodeString(value); err != nil {
+			return nil, fmt.Errorf("forwarded certificate is neither PEM nor base64 DER")
+		}
+	}
+	return x509.ParseCertificate(der)
+}
@@ -0,0 +1,148 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCA{cert: cert, key: key}
+}
+
+// issue signs template for a new key.
+func (ca testCA) issue(t *testing.T, template *x509.Certificate) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(2)
+	if template.NotAfter.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(time.Hour)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// escapedPEM escapes cert like nginx's $ssl_client_escaped_cert.
+func escapedPEM(cert *x509.Certificate) string {
+	escaped := url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	return strings.ReplaceAll(escaped, "+", "%2B")
+}
+
+func TestSourceCertificate(t *testing.T) {
+	ca, otherCA := newTestCA(t, "client-ca"), newTestCA(t, "other-ca")
+	clientAuth := []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	client := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}, ExtKeyUsage: clientAuth})
+	foreign := otherCA.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}, ExtKeyUsage: clientAuth})
+	serverOnly := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	expired := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "billing"},
+		ExtKeyUsage: clientAuth,
+		NotBefore:   time.Now().Add(-2 * time.Hour),
+		NotAfter:    time.Now().Add(-time.Hour),
+	})
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name   string
+		source Source
+		peer   string
+		header string
+		tls    *x509.Certificate // Verified by the server's own listener
+		want   *x509.Certificate
+	}{
+		{"verified by the listener", Source{}, "203.0.113.5:443", "", client, client},
+		{"no certificate", Source{Header: "X-Client-Cert", TrustedProxies: proxies}, "10.0.0.2:443", "", nil, nil},
+		{"forwarded by a trusted proxy", Source{Header: "X-Client-Cert", TrustedProxies: proxies}, "10.0.0.2:443", escapedPEM(client), nil, client},
+		{"forwarded as base64 DER", Source{Header: "X-Client-Cert", TrustedProxies: proxies}, "10.0.0.2:443", base64.StdEncoding.EncodeToString(client.Raw), nil, client},
+		{"forwarded by an untrusted peer", Source{Header: "X-Client-Cert", TrustedProxies: proxies}, "203.0.113.5:443", escapedPEM(client), nil, nil},
+		{"forwarded header disabled", Source{TrustedProxies: proxies}, "10.0.0.2:443", escapedPEM(client), nil, nil},
+		{"garbage header", Source{Header: "X-Client-Cert", TrustedProxies: proxies}, "10.0.0.2:443", "not a certificate", nil, nil},
+		{"expired without roots", Source{Header: "X-Client-Cert", TrustedProxies: proxies}, "10.0.0.2:443", escapedPEM(expired), nil, nil},
+		{"issued by the roots", Source{Header: "X-Client-Cert", TrustedProxies: proxies, Roots: roots}, "10.0.0.2:443", escapedPEM(client), nil, client},
+		{"issued by another CA", Source{Header: "X-Client-Cert", TrustedProxies: proxies, Roots: roots}, "10.0.0.2:443", escapedPEM(foreign), nil, nil},
+		{"another CA without roots", Source{Header: "X-Client-Cert", TrustedProxies: proxies}, "10.0.0.2:443", escapedPEM(foreign), nil, foreign},
+		{"not for client authentication", Source{Header: "X-Client-Cert", TrustedProxies: proxies, Roots: roots}, "10.0.0.2:443", escapedPEM(serverOnly), nil, nil},
+		{"expired with roots", Source{Header: "X-Client-Cert", TrustedProxies: proxies, Roots: roots}, "10.0.0.2:443", escapedPEM(expired), nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.peer
+			if tt.header != "" {
+				r.Header.Set("X-Client-Cert", tt.header)
+			}
+			if tt.tls != nil {
+				r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{tt.tls, ca.cert}}}
+			}
+			cert, err := tt.source.Certificate(r)
+			if tt.want == nil {
+				if !errors.Is(err, core.ErrClientCertRequired) {
+					t.Errorf("Certificate() = %v, want ErrClientCertRequired", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Certificate() = %v, want the certificate", err)
+			}
+			if !cert.Equal(tt.want) {
+				t.Errorf("Certificate() returned %s, want %s", cert.Subject, tt.want.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package oauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TokenResponse is the token endpoint response (RFC 6749 section 5.1).
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Client implements the parts of the authorization-code flow shared by all providers.
+// It is exported so that custom providers (see the oidc package) can reuse it.
+type Client struct {
+	cfg Config
+}
+
+// NewClient creates a new Client.
+func NewClient(cfg Config) *Client {
+	return &Client{cfg: cfg}
+}
+
+// Config returns the client's configuration.
+func (cl *Client) Config() Config {
+	return cl.cfg
+}
+
+// HTTPClient returns the configured HTTP client, or http.DefaultClient.
+func (cl *Client) HTTPClient() *http.Client {
+	if cl.cfg.HTTPClient != nil {
+		return cl.cfg.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// BuildAuthCodeURL builds the authorization URL with PKCE. extra holds provider-specific parameters.
+func (cl *Client) BuildAuthCodeURL(state, codeChallenge string, extra url.Values) string {
+	v := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {cl.cfg.ClientID},
+		"redirect_uri":          {cl.cfg.RedirectURL},
+		"scope":                 {strings.Join(cl.cfg.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	for k, vals := range extra {
+		v[k] = vals
+	}
+	sep := "?"
+	if strings.Contains(cl.cfg.AuthURL, "?") {
+		sep = "&"
+	}
+	return cl.cfg.AuthURL + sep + v.Encode()
+}
+
+// ExchangeCode trades an authorization code for tokens at the token endpoint.
+func (cl *Client) ExchangeCode(ctx context.Context, code, codeVerifier string) (TokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cl.cfg.RedirectURL},
+		"client_id":     {cl.cfg.ClientID},
+		"client_secret": {cl.cfg.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cl.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json") // GitHub returns form-encoded bodies otherwise
+
+	resp, err := cl.HTTPClient().Do(req)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	var tr TokenResponse
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return TokenResponse{}, fmt.Errorf("%w: status %d: invalid response body", ErrExchangeFailed, resp.StatusCode)
+	}
+	if tr.Error != "" {
+		return TokenResponse{}, fmt.Errorf("%w: %s: %s", ErrExchangeFailed, tr.Error, tr.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		return TokenResponse{}, fmt.Errorf("%w: status %d", ErrExchangeFailed, resp.StatusCode)
+	}
+	return tr, nil
+}
+
+// GetJSON fetches an API resource with the access token and decodes it into v.
+func (cl *Client) GetJSON(ctx context.Context, rawURL, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInfo, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := cl.HTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInfo, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned status %d", ErrUserInfo, rawURL, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInfo, err)
+	}
+	return nil
+}
+
+// idTokenClaims are the ID token claims the built-in providers need.
+type idTokenClaims struct {
+	Subject       string `json:"sub"`
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified *bool  `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// decodeIDTokenClaims decodes the claims of an ID token WITHOUT verifying its signature.
+// This is only acceptable for ID tokens received directly from the provider's token
+// endpoint over TLS (OpenID Connect Core, section 3.1.3.7). Use the oidc package to
+// verify ID tokens against the issuer's keys.
+func decodeIDTokenClaims(idToken string) (idTokenClaims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return idTokenClaims{}, fmt.Errorf("%w: malformed id_token", ErrExchangeFailed)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return idTokenClaims{}, fmt.Errorf("%w: malformed id_token payload", ErrExchangeFailed)
+	}
+	var claims idTokenClaims
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return idTokenClaims{}, fmt.Errorf("%w: malformed id_token claims", ErrExchangeFailed)
+	}
+	return claims, nil
+}
+
+// checkNonce verifies the nonce of an ID token returned by the token endpoint.
+func checkNonce(idToken, nonce string) (idTokenClaims, error) {
+	if idToken == "" {
+		return idTokenClaims{}, fmt.Errorf("%w: id_token missing from token response", ErrExchangeFailed)
+	}
+	claims, err := decodeIDTokenClaims(idToken)
+	if err != nil {
+		return idTokenClaims{}, err
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return idTokenClaims{}, ErrNonceMismatch
+	}
+	return claims, nil
+}
+
+// withDefaults fills in the endpoints and scopes a provider uses when the Config leaves them empty.
+func withDefaults(cfg Config, authURL, tokenURL, userInfoURL string, scopes []string) Config {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = authURL
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = tokenURL
+	}
+	if cfg.UserInfoURL == "" {
+		cfg.UserInfoURL = userInfoURL
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = scopes
+	}
+	return cfg
+}
@@ -0,0 +1,302 @@
+package oauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testRedirectURL = "https://app.example.com/auth/oauth/callback"
+
+// stubGrant is what the stub provider remembers about an authorization request.
+type stubGrant struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+}
+
+// stubProvider is an authorization server that enforces PKCE, echoes the nonce in
+// the ID token and serves fixed userinfo documents to the access tokens it issued.
+type stubProvider struct {
+	*httptest.Server
+	idTokenClaims map[string]interface{} // Added to sub and nonce; no ID token if nil
+	userInfo      interface{}
+	emails        interface{} // Served at /userinfo/emails, as GitHub does
+
+	mu     sync.Mutex
+	codes  map[string]stubGrant
+	tokens map[string]bool
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	s := &stubProvider{codes: make(map[string]stubGrant), tokens: make(map[string]bool)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/userinfo", s.serve(func() interface{} { return s.userInfo }))
+	mux.HandleFunc("/userinfo/emails", s.serve(func() interface{} { return s.emails }))
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubProvider) config() Config {
+	return Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  testRedirectURL,
+		AuthURL:      s.URL + "/authorize",
+		TokenURL:     s.URL + "/token",
+		UserInfoURL:  s.URL + "/userinfo",
+		HTTPClient:   s.Client(),
+	}
+}
+
+func (s *stubProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != "client" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	code := "code-" + q.Get("state")
+	s.mu.Lock()
+	s.codes[code] = stubGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), redirectURI: q.Get("redirect_uri")}
+	s.mu.Unlock()
+	http.Redirect(w, r, q.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (s *stubProvider) token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fail := func(code, description string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		fail("unsupported_grant_type", "")
+		return
+	}
+	if r.PostFormValue("client_id") != "client" || r.PostFormValue("client_secret") != "secret" {
+		fail("invalid_client", "")
+		return
+	}
+	s.mu.Lock()
+	grant, ok := s.codes[r.PostFormValue("code")]
+	delete(s.codes, r.PostFormValue("code"))
+	s.mu.Unlock()
+	if !ok || grant.redirectURI != r.PostFormValue("redirect_uri") {
+		fail("invalid_grant", "unknown code")
+		return
+	}
+	if CodeChallengeS256(r.PostFormValue("code_verifier")) != grant.challenge {
+		fail("invalid_grant", "code_verifier does not match the code_challenge")
+		return
+	}
+
+	accessToken := "at-" + r.PostFormValue("code")
+	s.mu.Lock()
+	s.tokens[accessToken] = true
+	s.mu.Unlock()
+	resp := map[string]string{"access_token": accessToken, "token_type": "Bearer"}
+	if s.idTokenClaims != nil {
+		claims := map[string]interface{}{"nonce": grant.nonce}
+		for k, v := range s.idTokenClaims {
+			claims[k] = v
+		}
+		payload, _ := json.Marshal(claims)
+		enc := base64.RawURLEncoding
+		resp["id_token"] = enc.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." + enc.EncodeToString(payload) + ".c2ln"
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *stubProvider) serve(doc func() interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		s.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc())
+	}
+}
+
+func newVerifier(t *testing.T) string {
+	t.Helper()
+	verifier, err := GenerateCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+// login follows the provider's authorization URL like a browser would and returns
+// the code from the redirect back to the application, after checking the state.
+func login(t *testing.T, p Provider, state, nonce, verifier string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(p.AuthCodeURL(state, nonce, CodeChallengeS256(verifier)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization request rejected: status %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != testRedirectURL {
+		t.Fatalf("redirected to %q, want %q", got, testRedirectURL)
+	}
+	if got := location.Query().Get("state"); got != state {
+		t.Fatalf("state = %q, want %q", got, state)
+	}
+	return location.Query().Get("code")
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	s := newStubProvider(t)
+	tests := []struct {
+		provider  Provider
+		wantNonce bool
+	}{
+		{NewGoogle(s.config()), true},
+		{NewMicrosoft(s.config(), ""), true},
+		{NewGitHub(s.config()), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider.Name(), func(t *testing.T) {
+			u, err := url.Parse(tt.provider.AuthCodeURL("the-state", "the-nonce", CodeChallengeS256("verifier")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			q := u.Query()
+			if q.Get("state") != "the-state" || q.Get("redirect_uri") != testRedirectURL || q.Get("client_id") != "client" {
+				t.Errorf("state/redirect_uri/client_id = %q/%q/%q", q.Get("state"), q.Get("redirect_uri"), q.Get("client_id"))
+			}
+			if q.Get("code_challenge") != CodeChallengeS256("verifier") || q.Get("code_challenge_method") != "S256" {
+				t.Errorf("code_challenge = %q (%s), want the S256 challenge of the verifier", q.Get("code_challenge"), q.Get("code_challenge_method"))
+			}
+			if got := q.Get("nonce"); (got == "the-nonce") != tt.wantNonce {
+				t.Errorf("nonce = %q, want it sent: %v", got, tt.wantNonce)
+			}
+		})
+	}
+}
+
+func TestCodeChallengeS256(t *testing.T) {
+	// RFC 7636, appendix B
+	if got := CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("CodeChallengeS256 = %q", got)
+	}
+}
+
+func TestGoogleExchange(t *testing.T) {
+	s := newStubProvider(t)
+	s.idTokenClaims = map[string]interface{}{"sub": "g-123"}
+	s.userInfo = map[string]interface{}{
+		"sub": "g-123", "email": "alice@example.com", "email_verified": true, "name": "Alice", "picture": "https://img.example.com/a.png",
+	}
+	p := NewGoogle(s.config())
+	ctx := context.Background()
+
+	verifier := newVerifier(t)
+	code := login(t, p, "state-1", "nonce-1", verifier)
+	info, err := p.Exchange(ctx, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := UserInfo{Provider: "google", Subject: "g-123", Email: "alice@example.com", EmailVerified: true, Name: "Alice", AvatarURL: "https://img.example.com/a.png"}
+	if info != want {
+		t.Errorf("Exchange() = %+v, want %+v", info, want)
+	}
+
+	// The code is single-use
+	if _, err := p.Exchange(ctx, code, verifier, "nonce-1"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("replayed code: err = %v, want ErrExchangeFailed", err)
+	}
+
+	code = login(t, p, "state-2", "nonce-2", verifier)
+	if _, err := p.Exchange(ctx, code, "a-different-code-verifier-of-sufficient-length", "nonce-2"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("wrong code_verifier: err = %v, want ErrExchangeFailed", err)
+	}
+
+	code = login(t, p, "state-3", "nonce-3", verifier)
+	if _, err := p.Exchange(ctx, code, verifier, "nonce-of-another-login"); !errors.Is(err, ErrNonceMismatch) {
+		t.Errorf("wrong nonce: err = %v, want ErrNonceMismatch", err)
+	}
+
+	s.userInfo = map[string]interface{}{"sub": "someone-else", "email": "mallory@example.com", "email_verified": true}
+	code = login(t, p, "state-4", "nonce-4", verifier)
+	if _, err := p.Exchange(ctx, code, verifier, "nonce-4"); !errors.Is(err, ErrUserInfo) {
+		t.Errorf("userinfo for another subject: err = %v, want ErrUserInfo", err)
+	}
+}
+
+func TestMicrosoftExchange(t *testing.T) {
+	s := newStubProvider(t)
+	p := NewMicrosoft(s.config(), "")
+	s.userInfo = map[string]interface{}{"sub": "ms-1", "email": "bob@corp.example", "name": "Bob"}
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		want   bool
+	}{
+		{"email_verified asserted", map[string]interface{}{"sub": "ms-1", "email": "bob@corp.example", "email_verified": true}, true},
+		{"email_verified missing", map[string]interface{}{"sub": "ms-1", "email": "bob@corp.example"}, false},
+		{"verified email differs from userinfo", map[string]interface{}{"sub": "ms-1", "email": "other@corp.example", "email_verified": true}, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.idTokenClaims = tt.claims
+			verifier := newVerifier(t)
+			nonce := "nonce-" + tt.name
+			code := login(t, p, fmt.Sprintf("state-%d", i), nonce, verifier)
+			info, err := p.Exchange(context.Background(), code, verifier, nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Subject != "ms-1" || info.Email != "bob@corp.example" || info.Name != "Bob" || info.Provider != "microsoft" {
+				t.Errorf("Exchange() = %+v", info)
+			}
+			if info.EmailVerified != tt.want {
+				t.Errorf("EmailVerified = %v, want %v", info.EmailVerified, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHubExchange(t *testing.T) {
+	s := newStubProvider(t)
+	s.userInfo = map[string]interface{}{"id": 42, "login": "octocat", "avatar_url": "https://img.example.com/o.png"}
+	s.emails = []map[string]interface{}{
+		{"email": "octocat@users.noreply.example", "primary": false, "verified": true},
+		{"email": "octo@example.com", "primary": true, "verified": false},
+	}
+	p := NewGitHub(s.config())
+
+	verifier := newVerifier(t)
+	code := login(t, p, "state-1", "", verifier)
+	info, err := p.Exchange(context.Background(), code, verifier, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The primary address is used even when a verified secondary one exists
+	want := UserInfo{Provider: "github", Subject: "42", Email: "octo@example.com", EmailVerified: false, Name: "octocat", AvatarURL: "https://img.example.com/o.png"}
+	if info != want {
+		t.Errorf("Exchange() = %+v, want %+v", info, want)
+	}
+}
@@ -0,0 +1,80 @@
+package oauth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// GitHub is the GitHub OAuth2 provider. GitHub does not issue ID tokens,
+// so the nonce is not used.
+type GitHub struct {
+	*Client
+	emailsURL string
+}
+
+// NewGitHub creates a new GitHub provider.
+// The emails endpoint is derived from UserInfoURL (UserInfoURL + "/emails").
+func NewGitHub(cfg Config) *GitHub {
+	cfg = withDefaults(cfg,
+		"https://github.com/login/oauth/authorize",
+		"https://github.com/login/oauth/access_token",
+		"https://api.github.com/user",
+		[]string{"read:user", "user:email"},
+	)
+	return &GitHub{
+		Client:    NewClient(cfg),
+		emailsURL: strings.TrimSuffix(cfg.UserInfoURL, "/") + "/emails",
+	}
+}
+
+func (p *GitHub) Name() string { return "github" }
+
+func (p *GitHub) AuthCodeURL(state, nonce, codeChallenge string) string {
+	return p.BuildAuthCodeURL(state, codeChallenge, nil)
+}
+
+func (p *GitHub) Exchange(ctx context.Context, code, codeVerifier, nonce string) (UserInfo, error) {
+	tr, err := p.ExchangeCode(ctx, code, codeVerifier)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := p.GetJSON(ctx, p.cfg.UserInfoURL, tr.AccessToken, &user); err != nil {
+		return UserInfo{}, err
+	}
+
+	// The profile email may be empty or unverified; use the primary verified address instead.
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.GetJSON(ctx, p.emailsURL, tr.AccessToken, &emails); err != nil {
+		return UserInfo{}, err
+	}
+
+	info := UserInfo{
+		Provider:  p.Name(),
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Name:      user.Name,
+		AvatarURL: user.AvatarURL,
+	}
+	if info.Name == "" {
+		info.Name = user.Login
+	}
+	for _, e := range emails {
+		if e.Primary {
+			info.Email = e.Email
+			info.EmailVerified = e.Verified
+			break
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,63 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Google is the Google OAuth2/OpenID Connect provider.
+type Google struct {
+	*Client
+}
+
+// NewGoogle creates a new Google provider.
+func NewGoogle(cfg Config) *Google {
+	cfg = withDefaults(cfg,
+		"https://accounts.google.com/o/oauth2/v2/auth",
+		"https://oauth2.googleapis.com/token",
+		"https://openidconnect.googleapis.com/v1/userinfo",
+		[]string{"openid", "email", "profile"},
+	)
+	return &Google{NewClient(cfg)}
+}
+
+func (p *Google) Name() string { return "google" }
+
+func (p *Google) AuthCodeURL(state, nonce, codeChallenge string) string {
+	return p.BuildAuthCodeURL(state, codeChallenge, url.Values{"nonce": {nonce}})
+}
+
+func (p *Google) Exchange(ctx context.Context, code, codeVerifier, nonce string) (UserInfo, error) {
+	tr, err := p.ExchangeCode(ctx, code, codeVerifier)
+	if err != nil {
+		return UserInfo{}, err
+	}
+	claims, err := checkNonce(tr.IDToken, nonce)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+	if err := p.GetJSON(ctx, p.cfg.UserInfoURL, tr.AccessToken, &info); err != nil {
+		return UserInfo{}, err
+	}
+	if info.Sub != claims.Subject {
+		return UserInfo{}, fmt.Errorf("%w: userinfo subject does not match id_token", ErrUserInfo)
+	}
+
+	return UserInfo{
+		Provider:      p.Name(),
+		Subject:       info.Sub,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+		AvatarURL:     info.Picture,
+	}, nil
+}
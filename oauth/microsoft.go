@@ -0,0 +1,71 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Microsoft is the Microsoft identity platform (Entra ID / personal accounts) provider.
+type Microsoft struct {
+	*Client
+}
+
+// NewMicrosoft creates a new Microsoft provider.
+// tenant is "common", "organizations", "consumers" or a tenant ID; empty means "common".
+func NewMicrosoft(cfg Config, tenant string) *Microsoft {
+	if tenant == "" {
+		tenant = "common"
+	}
+	base := "https://login.microsoftonline.com/" + url.PathEscape(tenant) + "/oauth2/v2.0"
+	cfg = withDefaults(cfg,
+		base+"/authorize",
+		base+"/token",
+		"https://graph.microsoft.com/oidc/userinfo",
+		[]string{"openid", "email", "profile"},
+	)
+	return &Microsoft{NewClient(cfg)}
+}
+
+func (p *Microsoft) Name() string { return "microsoft" }
+
+func (p *Microsoft) AuthCodeURL(state, nonce, codeChallenge string) string {
+	return p.BuildAuthCodeURL(state, codeChallenge, url.Values{"nonce": {nonce}, "response_mode": {"query"}})
+}
+
+func (p *Microsoft) Exchange(ctx context.Context, code, codeVerifier, nonce string) (UserInfo, error) {
+	tr, err := p.ExchangeCode(ctx, code, codeVerifier)
+	if err != nil {
+		return UserInfo{}, err
+	}
+	claims, err := checkNonce(tr.IDToken, nonce)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	var info struct {
+		Sub     string `json:"sub"`
+		Email   string `json:"email"`
+		Name    string `json:"name"`
+		Picture string `json:"picture"`
+	}
+	if err := p.GetJSON(ctx, p.cfg.UserInfoURL, tr.AccessToken, &info); err != nil {
+		return UserInfo{}, err
+	}
+	if info.Sub != claims.Subject {
+		return UserInfo{}, fmt.Errorf("%w: userinfo subject does not match id_token", ErrUserInfo)
+	}
+
+	// Microsoft does not guarantee that the email claim is verified. It is only
+	// trusted when the ID token explicitly says so (e.g. via the email_verified optional claim).
+	verified := claims.EmailVerified != nil && *claims.EmailVerified && claims.Email == info.Email
+
+	return UserInfo{
+		Provider:      p.Name(),
+		Subject:       info.Sub,
+		Email:         info.Email,
+		EmailVerified: verified,
+		Name:          info.Name,
+		AvatarURL:     info.Picture,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package oauth
+
+import "testing"
+
+func TestCanLinkEmail(t *testing.T) {
+	verified := UserInfo{Email: "alice@corp.example", EmailVerified: true}
+	tests := []struct {
+		name string
+		cfg  Config
+		info UserInfo
+		want bool
+	}{
+		{"on by default", Config{}, verified, true},
+		{"disabled", Config{DisableEmailLinking: true}, verified, false},
+		{"unverified", Config{}, UserInfo{Email: "alice@corp.example"}, false},
+		{"assumed verified", Config{}, UserInfo{Email: "alice@corp.example", EmailVerified: true, EmailAssumedVerified: true}, false},
+		{"domain allowed", Config{LinkEmailDomains: []string{"CORP.example"}}, verified, true},
+		{"domain not allowed", Config{LinkEmailDomains: []string{"other.example"}}, verified, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.CanLinkEmail(tt.info); got != tt.want {
+				t.Errorf("CanLinkEmail() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
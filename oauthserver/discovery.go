@@ -0,0 +1,96 @@
+package oauthserver
+
+import "github.com/shawgichan/go-authkit/jose"
+
+// OpenIDConfig holds the absolute URLs published in the discovery document.
+// The authorization and end-session endpoints are usually frontend pages that call
+// the corresponding go-authkit handlers with the user's session.
+type OpenIDConfig struct {
+	Issuer                string // e.g. "https://auth.example.com", without a trailing slash
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	UserinfoEndpoint      string
+	JWKSURI               string
+	EndSessionEndpoint    string // Optional
+	ScopesSupported       []string
+
+	DeviceAuthorizationEndpoint string // Optional; advertises the device_code grant when set
+	IntrospectionEndpoint       string // Optional
+	RevocationEndpoint          string // Optional
+
+	CertificateBoundAccessTokens bool // Set when access tokens are bound to client certificates (RFC 8705)
+	DPoP                         bool // Set when the token endpoint accepts DPoP proofs (RFC 9449)
+}
+
+// DiscoveryDocument is the OpenID Provider metadata (OpenID Connect Discovery 1.0, section 3).
+type DiscoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	EndSessionEndpoint                string   `json:"end_session_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint,omitempty"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                string   `json:"revocation_endpoint,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+
+	TLSClientCertificateBoundAccessTokens bool     `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+	DPoPSigningAlgValuesSupported         []string `json:"dpop_signing_alg_values_supported,omitempty"`
+}
+
+// Standard scopes understood by the OpenID provider.
+const (
+	ScopeOpenID        = "openid"
+	ScopeProfile       = "profile"
+	ScopeEmail         = "email"
+	ScopePhone         = "phone"
+	ScopeOfflineAccess = "offline_access"
+)
+
+// Discovery builds the discovery document for the configured endpoints and signer.
+func (cfg OpenIDConfig) Discovery(signer *IDTokenSigner) DiscoveryDocument {
+	scopes := cfg.ScopesSupported
+	if len(scopes) == 0 {
+		scopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopePhone, ScopeOfflineAccess}
+	}
+	grantTypes := []string{"authorization_code", "refresh_token", "client_credentials", "urn:ietf:params:oauth:grant-type:token-exchange"}
+	if cfg.DeviceAuthorizationEndpoint != "" {
+		grantTypes = append(grantTypes, "urn:ietf:params:oauth:grant-type:device_code")
+	}
+	var dpopAlgs []string
+	if cfg.DPoP {
+		dpopAlgs = jose.Algorithms()
+	}
+	return DiscoveryDocument{
+		Issuer:                            cfg.Issuer,
+		AuthorizationEndpoint:             cfg.AuthorizationEndpoint,
+		TokenEndpoint:                     cfg.TokenEndpoint,
+		UserinfoEndpoint:                  cfg.UserinfoEndpoint,
+		JWKSURI:                           cfg.JWKSURI,
+		EndSessionEndpoint:                cfg.EndSessionEndpoint,
+		DeviceAuthorizationEndpoint:       cfg.DeviceAuthorizationEndpoint,
+		IntrospectionEndpoint:             cfg.IntrospectionEndpoint,
+		RevocationEndpoint:                cfg.RevocationEndpoint,
+		ScopesSupported:                   scopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               grantTypes,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{signer.Algorithm()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "at_hash", "azp",
+			"name", "preferred_username", "updated_at", "email", "email_verified", "phone_number",
+		},
+		TLSClientCertificateBoundAccessTokens: cfg.CertificateBoundAccessTokens,
+		DPoPSigningAlgValuesSupported:         dpopAlgs,
+	}
+}
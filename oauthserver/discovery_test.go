@@ -0,0 +1,71 @@
+package oauthserver
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/shawgichan/go-authkit/jose"
+)
+
+func TestDiscovery(t *testing.T) {
+	signer := testSigners(t)["ES256"]
+	cfg := OpenIDConfig{
+		Issuer:                "https://auth.example.com",
+		AuthorizationEndpoint: "https://auth.example.com/oauth/authorize",
+		TokenEndpoint:         "https://auth.example.com/oauth/token",
+		UserinfoEndpoint:      "https://auth.example.com/oauth/userinfo",
+		JWKSURI:               "https://auth.example.com/.well-known/jwks.json",
+	}
+
+	doc := cfg.Discovery(signer)
+	if doc.Issuer != cfg.Issuer || doc.TokenEndpoint != cfg.TokenEndpoint || doc.JWKSURI != cfg.JWKSURI {
+		t.Errorf("issuer/token_endpoint/jwks_uri = %q/%q/%q", doc.Issuer, doc.TokenEndpoint, doc.JWKSURI)
+	}
+	if !slices.Equal(doc.IDTokenSigningAlgValuesSupported, []string{"ES256"}) {
+		t.Errorf("id_token_signing_alg_values_supported = %v, want the signer's algorithm", doc.IDTokenSigningAlgValuesSupported)
+	}
+	if !slices.Equal(doc.ResponseTypesSupported, []string{"code"}) || !slices.Equal(doc.CodeChallengeMethodsSupported, []string{"S256"}) {
+		t.Errorf("response_types/code_challenge_methods = %v/%v", doc.ResponseTypesSupported, doc.CodeChallengeMethodsSupported)
+	}
+	if !slices.Contains(doc.ScopesSupported, ScopeOpenID) || !slices.Contains(doc.SubjectTypesSupported, "public") {
+		t.Errorf("scopes/subject_types = %v/%v", doc.ScopesSupported, doc.SubjectTypesSupported)
+	}
+	for _, claim := range []string{"iss", "sub", "aud", "exp", "iat", "nonce", "at_hash"} {
+		if !slices.Contains(doc.ClaimsSupported, claim) {
+			t.Errorf("claims_supported lacks %q", claim)
+		}
+	}
+	const deviceGrant = "urn:ietf:params:oauth:grant-type:device_code"
+	if slices.Contains(doc.GrantTypesSupported, deviceGrant) {
+		t.Error("the device_code grant is advertised without a device authorization endpoint")
+	}
+	if len(doc.DPoPSigningAlgValuesSupported) != 0 {
+		t.Error("DPoP algorithms are advertised without DPoP")
+	}
+
+	cfg.DeviceAuthorizationEndpoint = "https://auth.example.com/oauth/device_authorization"
+	cfg.DPoP = true
+	doc = cfg.Discovery(signer)
+	if !slices.Contains(doc.GrantTypesSupported, deviceGrant) {
+		t.Errorf("grant_types_supported = %v, want the device_code grant", doc.GrantTypesSupported)
+	}
+	if !slices.Equal(doc.DPoPSigningAlgValuesSupported, jose.Algorithms()) {
+		t.Errorf("dpop_signing_alg_values_supported = %v", doc.DPoPSigningAlgValuesSupported)
+	}
+
+	// Required members are always serialized
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"issuer", "authorization_endpoint", "token_endpoint", "jwks_uri", "response_types_supported", "subject_types_supported", "id_token_signing_alg_values_supported"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("discovery document lacks %q", name)
+		}
+	}
+}
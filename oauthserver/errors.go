@@ -0,0 +1,48 @@
+// Package oauthserver contains the framework-independent building blocks of the
+// OAuth2 authorization server: protocol errors, scope handling, PKCE checks and
+// client authentication. The HTTP endpoints live in ginhandler.
+package oauthserver
+
+import "net/http"
+
+// Error is an OAuth2 protocol error (RFC 6749 section 5.2), rendered as
+// {"error": Code, "error_description": Description} on the token endpoint
+// or as query parameters on an authorization redirect.
+type Error struct {
+	Code        string
+	Description string
+	Status      int
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func newError(code string, status int) func(description string) *Error {
+	return func(description string) *Error {
+		return &Error{Code: code, Description: description, Status: status}
+	}
+}
+
+var (
+	ErrInvalidRequest          = newError("invalid_request", http.StatusBadRequest)
+	ErrInvalidClient           = newError("invalid_client", http.StatusUnauthorized)
+	ErrInvalidGrant            = newError("invalid_grant", http.StatusBadRequest)
+	ErrUnauthorizedClient      = newError("unauthorized_client", http.StatusBadRequest)
+	ErrUnsupportedGrantType    = newError("unsupported_grant_type", http.StatusBadRequest)
+	ErrUnsupportedResponseType = newError("unsupported_response_type", http.StatusBadRequest)
+	ErrInvalidScope            = newError("invalid_scope", http.StatusBadRequest)
+	ErrAccessDenied            = newError("access_denied", http.StatusForbidden)
+	ErrServerError             = newError("server_error", http.StatusInternalServerError)
+	ErrUnsupportedTokenType    = newError("unsupported_token_type", http.StatusBadRequest) // RFC 7009 section 2.2.1
+	ErrInvalidTarget           = newError("invalid_target", http.StatusBadRequest)         // RFC 8693 section 2.2.2
+	ErrInvalidDPoPProof        = newError("invalid_dpop_proof", http.StatusBadRequest)     // RFC 9449 section 5
+
+	// Device authorization grant (RFC 8628 section 3.5)
+	ErrAuthorizationPending = newError("authorization_pending", http.StatusBadRequest)
+	ErrSlowDown             = newError("slow_down", http.StatusBadRequest)
+	ErrExpiredToken         = newError("expired_token", http.StatusBadRequest)
+)
@@ -0,0 +1,143 @@
+package oauthserver
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/jose"
+)
+
+var ErrInvalidIDTokenHint = errors.New("id_token_hint is invalid")
+
+// IDTokenClaims are the claims of an OpenID Connect ID token issued by go-authkit.
+type IDTokenClaims struct {
+	Issuer   string           `json:"iss"`
+	Subject  string           `json:"sub"`
+	Audience jose.Audience    `json:"aud"`
+	Expiry   jose.NumericDate `json:"exp"`
+	IssuedAt jose.NumericDate `json:"iat"`
+	AuthTime jose.NumericDate `json:"auth_time,omitempty"`
+	Nonce    string           `json:"nonce,omitempty"`
+	AtHash   string           `json:"at_hash,omitempty"`
+	Azp      string           `json:"azp,omitempty"`
+
+	// Scope-dependent claims, see UserInfoClaims
+	UserInfoClaims
+}
+
+// UserInfoClaims are the standard claims released by scope (OpenID Connect Core, section 5.4).
+type UserInfoClaims struct {
+	Name              string           `json:"name,omitempty"`
+	PreferredUsername string           `json:"preferred_username,omitempty"`
+	UpdatedAt         jose.NumericDate `json:"updated_at,omitempty"`
+	Email             string           `json:"email,omitempty"`
+	EmailVerified     *bool            `json:"email_verified,omitempty"`
+	PhoneNumber       string           `json:"phone_number,omitempty"`
+}
+
+// IDTokenSigner signs ID tokens with an asymmetric key and publishes its public key as a JWKS.
+type IDTokenSigner struct {
+	key crypto.Signer
+	alg string
+	jwk jose.JSONWebKey
+}
+
+// NewIDTokenSigner creates a signer for an RSA (RS256), ECDSA P-256 (ES256) or Ed25519 (EdDSA) private key.
+// kid identifies the key in the JWKS; change it when rotating keys.
+func NewIDTokenSigner(key crypto.Signer, kid string) (*IDTokenSigner, error) {
+	var alg string
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < jose.MinRSAKeyBits {
+			return nil, fmt.Errorf("rsa key must be at least %d bits", jose.MinRSAKeyBits)
+		}
+		alg = "RS256"
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("ecdsa key must use the P-256 curve")
+		}
+		alg = "ES256"
+	case ed25519.PublicKey:
+		alg = "EdDSA"
+	default:
+		return nil, fmt.Errorf("%w: %T", jose.ErrUnsupportedKey, k)
+	}
+	jwk, err := jose.NewJSONWebKey(key.Public(), kid, alg)
+	if err != nil {
+		return nil, err
+	}
+	return &IDTokenSigner{key: key, alg: alg, jwk: jwk}, nil
+}
+
+// Algorithm returns the JWS algorithm of the signer.
+func (s *IDTokenSigner) Algorithm() string {
+	return s.alg
+}
+
+// JWKS returns the public key set to serve at the jwks_uri.
+func (s *IDTokenSigner) JWKS() jose.JSONWebKeySet {
+	return jose.JSONWebKeySet{Keys: []jose.JSONWebKey{s.jwk}}
+}
+
+// Sign serializes and signs the claims.
+func (s *IDTokenSigner) Sign(claims IDTokenClaims) (string, error) {
+	return jose.Sign(jose.Header{Alg: s.alg, Kid: s.jwk.Kid, Typ: "JWT"}, claims, s.key)
+}
+
+// Verify checks the signature and issuer of an ID token previously issued by this signer,
+// as needed for id_token_hint. Expiry is deliberately not checked: callers decide whether
+// an expired hint is acceptable.
+func (s *IDTokenSigner) Verify(rawIDToken, issuer string) (IDTokenClaims, error) {
+	jws, err := jose.Parse(rawIDToken)
+	if err != nil || jws.Header.Alg != s.alg {
+		return IDTokenClaims{}, ErrInvalidIDTokenHint
+	}
+	if err := jws.Verify(s.key.Public()); err != nil {
+		return IDTokenClaims{}, ErrInvalidIDTokenHint
+	}
+	var claims IDTokenClaims
+	if err := jws.Claims(&claims); err != nil || claims.Issuer != issuer {
+		return IDTokenClaims{}, ErrInvalidIDTokenHint
+	}
+	return claims, nil
+}
+
+// AccessTokenHash computes the at_hash claim: the base64url-encoded left half of the
+// hash of the access token, using the hash function of the ID token's algorithm.
+func (s *IDTokenSigner) AccessTokenHash(accessToken string) string {
+	hashFunc, ok := jose.HashForAlgorithm(s.alg)
+	if !ok {
+		return ""
+	}
+	h := hashFunc.New()
+	h.Write([]byte(accessToken))
+	sum := h.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+// UserInfoClaimsFor releases the user's claims allowed by the granted scopes.
+func UserInfoClaimsFor(user core.User, scopes []string) UserInfoClaims {
+	var claims UserInfoClaims
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeProfile:
+			claims.Name = user.FullName
+			claims.PreferredUsername = user.Username
+			claims.UpdatedAt = jose.NewNumericDate(user.UpdatedAt)
+		case ScopeEmail:
+			verified := user.Status != core.StatusPending
+			claims.Email = user.Email
+			claims.EmailVerified = &verified
+		case ScopePhone:
+			claims.PhoneNumber = user.PhoneNumber
+		}
+	}
+	return claims
+}
@@ -0,0 +1,147 @@
+package oauthserver
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shawgichan/go-authkit/jose"
+)
+
+func testSigners(t *testing.T) map[string]*IDTokenSigner {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, jose.MinRSAKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := make(map[string]*IDTokenSigner)
+	for _, key := range []crypto.Signer{rsaKey, ecKey, edKey} {
+		signer, err := NewIDTokenSigner(key, "k1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers[signer.Algorithm()] = signer
+	}
+	return signers
+}
+
+func TestNewIDTokenSignerRejectsWeakKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIDTokenSigner(rsaKey, "k1"); err == nil {
+		t.Error("NewIDTokenSigner accepted a 1024-bit RSA key")
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIDTokenSigner(ecKey, "k1"); err == nil {
+		t.Error("NewIDTokenSigner accepted a P-384 key")
+	}
+}
+
+func TestIDTokenSigner(t *testing.T) {
+	const issuer = "https://auth.example.com"
+	for alg, signer := range testSigners(t) {
+		t.Run(alg, func(t *testing.T) {
+			now := time.Now().Truncate(time.Second)
+			raw, err := signer.Sign(IDTokenClaims{
+				Issuer:         issuer,
+				Subject:        "user-1",
+				Audience:       jose.Audience{"rp"},
+				Expiry:         jose.NewNumericDate(now.Add(time.Hour)),
+				IssuedAt:       jose.NewNumericDate(now),
+				AuthTime:       jose.NewNumericDate(now.Add(-time.Minute)),
+				Nonce:          "n-0S6_WzA2Mj",
+				AtHash:         signer.AccessTokenHash("access-token"),
+				Azp:            "rp",
+				UserInfoClaims: UserInfoClaims{Email: "alice@example.com"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// A relying party verifies the token with the published JWKS only
+			jws, err := jose.Parse(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if jws.Header.Alg != alg || jws.Header.Kid != "k1" || jws.Header.Typ != "JWT" {
+				t.Errorf("header = %+v, want alg %s, kid k1, typ JWT", jws.Header, alg)
+			}
+			b, err := json.Marshal(signer.JWKS())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var set jose.JSONWebKeySet
+			if err := json.Unmarshal(b, &set); err != nil {
+				t.Fatal(err)
+			}
+			keys := set.Find(jws.Header.Kid)
+			if len(keys) != 1 || keys[0].Alg != alg || keys[0].Use != "sig" {
+				t.Fatalf("JWKS keys for kid k1 = %+v, want one %s signing key", keys, alg)
+			}
+			pub, err := keys[0].PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := jws.Verify(pub); err != nil {
+				t.Fatalf("Verify with the JWKS key: %v", err)
+			}
+
+			claims, err := signer.Verify(raw, issuer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != "user-1" || !claims.Audience.Contains("rp") || claims.Azp != "rp" {
+				t.Errorf("sub/aud/azp = %q/%v/%q", claims.Subject, claims.Audience, claims.Azp)
+			}
+			if claims.Nonce != "n-0S6_WzA2Mj" {
+				t.Errorf("nonce = %q, want the request's nonce", claims.Nonce)
+			}
+			if !claims.AuthTime.Time().Equal(now.Add(-time.Minute)) || !claims.Expiry.Time().Equal(now.Add(time.Hour)) {
+				t.Errorf("auth_time/exp = %v/%v", claims.AuthTime.Time(), claims.Expiry.Time())
+			}
+			if claims.Email != "alice@example.com" {
+				t.Errorf("email = %q", claims.Email)
+			}
+			if _, err := signer.Verify(raw, "https://other.example.com"); err == nil {
+				t.Error("Verify accepted a token for another issuer")
+			}
+		})
+	}
+}
+
+func TestAccessTokenHash(t *testing.T) {
+	// The access token and at_hash of OpenID Connect Core, appendix A.3
+	const accessToken = "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y"
+	sha512Sum := sha512.Sum512([]byte(accessToken))
+	want := map[string]string{
+		"RS256": "77QmUPtjPfzWtF2AnpK9RQ",
+		"ES256": "77QmUPtjPfzWtF2AnpK9RQ",
+		"EdDSA": base64.RawURLEncoding.EncodeToString(sha512Sum[:32]),
+	}
+	for alg, signer := range testSigners(t) {
+		if got := signer.AccessTokenHash(accessToken); got != want[alg] {
+			t.Errorf("%s: at_hash = %q, want %q", alg, got, want[alg])
+		}
+	}
+}
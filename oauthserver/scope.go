@@ -0,0 +1,40 @@
+package oauthserver
+
+import "strings"
+
+// ParseScope splits a space-delimited scope string, dropping duplicates.
+func ParseScope(scope string) []string {
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// JoinScope joins scopes into a space-delimited scope string.
+func JoinScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// ContainsAll reports whether every scope in requested is in granted.
+func ContainsAll(granted, requested []string) bool {
+	set := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		set[s] = true
+	}
+	for _, s := range requested {
+		if !set[s] {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns the scopes in a or b, preserving order.
+func Union(a, b []string) []string {
+	return ParseScope(JoinScope(a) + " " + JoinScope(b))
+}
@@ -0,0 +1,77 @@
+package oauthserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// GenerateToken returns a random opaque token for authorization codes,
+// refresh tokens and client secrets.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hash under which an opaque token is stored.
+// The tokens are high-entropy, so a fast hash is sufficient.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCE checks a PKCE code verifier against an S256 challenge (RFC 7636).
+// The plain method is not supported.
+func VerifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 || challenge == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// AuthenticateClient loads the client and checks its secret. Public clients must
+// not send a secret; confidential clients must send the right one.
+func AuthenticateClient(ctx context.Context, clients core.OAuthClientStorer, clientID, clientSecret string) (core.OAuthClient, error) {
+	if clientID == "" {
+		return core.OAuthClient{}, ErrInvalidClient("client authentication required")
+	}
+	client, err := clients.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return core.OAuthClient{}, ErrInvalidClient("unknown client")
+		}
+		return core.OAuthClient{}, fmt.Errorf("failed to get client: %w", err) // Not shown to the client
+	}
+
+	if client.IsPublic() {
+		if clientSecret != "" {
+			return core.OAuthClient{}, ErrInvalidClient("public clients must not send a secret")
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(HashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return core.OAuthClient{}, ErrInvalidClient("invalid client credentials")
+	}
+	return client, nil
+}
+
+// ValidRedirectURI reports whether uri exactly matches one of the client's registered redirect URIs.
+func ValidRedirectURI(client core.OAuthClient, uri string) bool {
+	for _, registered := range client.RedirectURIs {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
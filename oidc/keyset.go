@@ -0,0 +1,95 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shawgichan/go-authkit/jose"
+)
+
+var ErrNoMatchingKey = errors.New("no matching key in issuer jwks")
+
+// minRefreshInterval limits how often an unknown kid can trigger a JWKS refetch.
+const minRefreshInterval = time.Minute
+
+// remoteKeySet caches the issuer's JWKS and refetches it when a token is signed with an unknown key (key rotation).
+type remoteKeySet struct {
+	uri        string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+// verify checks the JWS signature against the cached keys, refreshing them once if no key matches.
+func (r *remoteKeySet) verify(ctx context.Context, jws *jose.JWS) error {
+	keys, err := r.keysFor(ctx, jws.Header.Kid, false)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		if keys, err = r.keysFor(ctx, jws.Header.Kid, true); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		if k.Alg != "" && k.Alg != jws.Header.Alg {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		if jws.Verify(pub) == nil {
+			return nil
+		}
+	}
+	if len(keys) == 0 {
+		return ErrNoMatchingKey
+	}
+	return jose.ErrInvalidSignature
+}
+
+func (r *remoteKeySet) keysFor(ctx context.Context, kid string, refresh bool) ([]jose.JSONWebKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stale := r.fetchedAt.IsZero() || (refresh && time.Since(r.fetchedAt) > minRefreshInterval)
+	if stale {
+		keys, err := r.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		r.keys = keys
+		r.fetchedAt = time.Now()
+	}
+	return r.keys.Find(kid), nil
+}
+
+func (r *remoteKeySet) fetch(ctx context.Context) (jose.JSONWebKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.uri, nil)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return jose.JSONWebKeySet{}, fmt.Errorf("fetch jwks: %s returned status %d", r.uri, resp.StatusCode)
+	}
+	var set jose.JSONWebKeySet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	return set, nil
+}
@@ -0,0 +1,321 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/shawgichan/go-authkit/jose"
+	"github.com/shawgichan/go-authkit/oauth"
+)
+
+var (
+	ErrInvalidIDToken  = errors.New("invalid id token")
+	ErrIssuerMismatch  = errors.New("id token issuer mismatch")
+	ErrAudience        = errors.New("id token audience mismatch")
+	ErrAuthorizedParty = errors.New("id token authorized party mismatch")
+	ErrIDTokenExpired  = errors.New("id token has expired")
+)
+
+// ClaimMapping names the claims used to build an oauth.UserInfo (and so a core.User).
+// Empty fields use the standard OpenID Connect claim names.
+type ClaimMapping struct {
+	Subject       string // Default "sub"
+	Email         string // Default "email"
+	EmailVerified string // Default "email_verified"
+	Name          string // Default "name"
+	Picture       string // Default "picture"
+	Role          string // Optional, e.g. "roles" or "groups". Not mapped when empty; see Config.AllowedRoles
+}
+
+func (m ClaimMapping) withDefaults() ClaimMapping {
+	def := func(v, d string) string {
+		if v == "" {
+			return d
+		}
+		return v
+	}
+	m.Subject = def(m.Subject, "sub")
+	m.Email = def(m.Email, "email")
+	m.EmailVerified = def(m.EmailVerified, "email_verified")
+	m.Name = def(m.Name, "name")
+	m.Picture = def(m.Picture, "picture")
+	return m
+}
+
+// Config configures a single OpenID Connect identity provider.
+type Config struct {
+	Name         string // Unique key in the oauth.Registry, used in routes (e.g. "okta")
+	Issuer       string // e.g. "https://example.okta.com" or "https://keycloak.example.com/realms/main"
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // Default: openid, email, profile
+
+	Claims ClaimMapping
+
+	// TrustEmail treats the email claim as verified when the IdP does not send
+	// email_verified. Only enable this for IdPs that control their users' emails,
+	// such as a company directory. Such emails can create accounts but never link
+	// existing ones.
+	TrustEmail bool
+	// LinkByEmail signs in to the existing account with the email the IdP verified.
+	// Unlike the built-in providers, generic IdPs do not link by email unless enabled.
+	// LinkEmailDomains: see oauth.Config.
+	LinkByEmail      bool
+	LinkEmailDomains []string
+
+	// AllowedRoles are the roles Claims.Role may assign to new users; the first claim
+	// value in the list is used and others are ignored. Nothing is mapped when empty.
+	// Do not list administrative roles: anyone the IdP lets sign in would get them.
+	AllowedRoles []string
+
+	// AllowedAlgs restricts ID token signing algorithms. Default: those advertised by the IdP.
+	AllowedAlgs []string
+	// ClockSkew is the leeway applied to exp and iat. Default: 1 minute.
+	ClockSkew time.Duration
+
+	HTTPClient *http.Client // Optional, defaults to http.DefaultClient
+}
+
+// Provider is a generic OpenID Connect provider. It implements oauth.Provider.
+type Provider struct {
+	*oauth.Client
+	cfg      Config
+	metadata ProviderMetadata
+	keys     *remoteKeySet
+	now      func() time.Time
+}
+
+// NewProvider runs discovery for cfg.Issuer and returns a ready to use Provider.
+func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
+	if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, errors.New("oidc: Name, Issuer and ClientID are required")
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	md, err := Discover(ctx, httpClient, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.ClockSkew == 0 {
+		cfg.ClockSkew = time.Minute
+	}
+	if len(cfg.AllowedAlgs) == 0 {
+		cfg.AllowedAlgs = md.IDTokenSigningAlgValuesSupported
+	}
+	if len(cfg.AllowedAlgs) == 0 {
+		cfg.AllowedAlgs = []string{"RS256"} // The spec's mandatory default
+	}
+	cfg.Claims = cfg.Claims.withDefaults()
+
+	return &Provider{
+		Client: oauth.NewClient(oauth.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       scopes,
+			AuthURL:      md.AuthorizationEndpoint,
+			TokenURL:     md.TokenEndpoint,
+			UserInfoURL:  md.UserinfoEndpoint,
+			HTTPClient:   httpClient,
+
+			DisableEmailLinking: !cfg.LinkByEmail,
+			LinkEmailDomains:    cfg.LinkEmailDomains,
+		}),
+		cfg:      cfg,
+		metadata: md,
+		keys:     &remoteKeySet{uri: md.JWKSURI, httpClient: httpClient},
+		now:      time.Now,
+	}, nil
+}
+
+// Register creates a Provider for each config and adds it to the registry.
+func Register(ctx context.Context, registry *oauth.Registry, cfgs ...Config) error {
+	for _, cfg := range cfgs {
+		p, err := NewProvider(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("oidc provider %q: %w", cfg.Name, err)
+		}
+		registry.Register(p)
+	}
+	return nil
+}
+
+func (p *Provider) Name() string { return p.cfg.Name }
+
+// Metadata returns the provider's discovery document.
+func (p *Provider) Metadata() ProviderMetadata { return p.metadata }
+
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	return p.BuildAuthCodeURL(state, codeChallenge, url.Values{"nonce": {nonce}})
+}
+
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (oauth.UserInfo, error) {
+	tr, err := p.ExchangeCode(ctx, code, codeVerifier)
+	if err != nil {
+		return oauth.UserInfo{}, err
+	}
+	if tr.IDToken == "" {
+		return oauth.UserInfo{}, fmt.Errorf("%w: id_token missing from token response", ErrInvalidIDToken)
+	}
+	claims, err := p.VerifyIDToken(ctx, tr.IDToken, nonce)
+	if err != nil {
+		return oauth.UserInfo{}, err
+	}
+
+	// Some IdPs only put profile claims in the userinfo response
+	if _, hasEmail := claims[p.cfg.Claims.Email]; !hasEmail && p.metadata.UserinfoEndpoint != "" {
+		var userinfo map[string]interface{}
+		if err := p.GetJSON(ctx, p.metadata.UserinfoEndpoint, tr.AccessToken, &userinfo); err != nil {
+			return oauth.UserInfo{}, err
+		}
+		if userinfo["sub"] != claims["sub"] {
+			return oauth.UserInfo{}, fmt.Errorf("%w: userinfo subject does not match id_token", oauth.ErrUserInfo)
+		}
+		for k, v := range userinfo {
+			if _, exists := claims[k]; !exists {
+				claims[k] = v
+			}
+		}
+	}
+
+	return p.mapClaims(claims)
+}
+
+// idTokenClaims are the registered claims checked during ID token validation.
+type idTokenClaims struct {
+	Issuer   string           `json:"iss"`
+	Subject  string           `json:"sub"`
+	Audience jose.Audience    `json:"aud"`
+	Azp      string           `json:"azp"`
+	Expiry   jose.NumericDate `json:"exp"`
+	IssuedAt jose.NumericDate `json:"iat"`
+	Nonce    string           `json:"nonce"`
+}
+
+// VerifyIDToken validates an ID token (OpenID Connect Core, section 3.1.3.7): signature
+// against the issuer JWKS, iss, aud, azp, exp and nonce. It returns all claims.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (map[string]interface{}, error) {
+	jws, err := jose.Parse(rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if !p.algAllowed(jws.Header.Alg) {
+		return nil, fmt.Errorf("%w: %w %q", ErrInvalidIDToken, jose.ErrUnsupportedAlg, jws.Header.Alg)
+	}
+	if err := p.keys.verify(ctx, jws); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	var std idTokenClaims
+	if err := jws.Claims(&std); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if std.Issuer != p.metadata.Issuer {
+		return nil, ErrIssuerMismatch
+	}
+	if std.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	if !std.Audience.Contains(p.cfg.ClientID) {
+		return nil, ErrAudience
+	}
+	// azp must be present when there are multiple audiences, and must be us if present
+	if (len(std.Audience) > 1 && std.Azp == "") || (std.Azp != "" && std.Azp != p.cfg.ClientID) {
+		return nil, ErrAuthorizedParty
+	}
+	now := p.now()
+	if std.Expiry == 0 || now.After(std.Expiry.Time().Add(p.cfg.ClockSkew)) {
+		return nil, ErrIDTokenExpired
+	}
+	if std.IssuedAt == 0 || std.IssuedAt.Time().After(now.Add(p.cfg.ClockSkew)) {
+		return nil, fmt.Errorf("%w: iat is missing or in the future", ErrInvalidIDToken)
+	}
+	if nonce == "" || std.Nonce != nonce {
+		return nil, oauth.ErrNonceMismatch
+	}
+
+	var claims map[string]interface{}
+	if err := jws.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	return claims, nil
+}
+
+func (p *Provider) algAllowed(alg string) bool {
+	if alg == "" || alg == "none" || !jose.SupportedAlgorithm(alg) {
+		return false
+	}
+	for _, a := range p.cfg.AllowedAlgs {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// mapClaims builds the UserInfo using the configured ClaimMapping.
+func (p *Provider) mapClaims(claims map[string]interface{}) (oauth.UserInfo, error) {
+	m := p.cfg.Claims
+	info := oauth.UserInfo{
+		Provider:  p.Name(),
+		Subject:   stringClaim(claims, m.Subject),
+		Email:     stringClaim(claims, m.Email),
+		Name:      stringClaim(claims, m.Name),
+		AvatarURL: stringClaim(claims, m.Picture),
+	}
+	if info.Subject == "" {
+		return oauth.UserInfo{}, fmt.Errorf("%w: claim %q is empty", ErrInvalidIDToken, m.Subject)
+	}
+
+	switch v := claims[m.EmailVerified].(type) {
+	case bool:
+		info.EmailVerified = v
+	case string: // Some IdPs send "true"/"false"
+		info.EmailVerified = v == "true"
+	case nil:
+		info.EmailVerified = p.cfg.TrustEmail
+		info.EmailAssumedVerified = p.cfg.TrustEmail
+	}
+
+	if m.Role != "" {
+		switch v := claims[m.Role].(type) {
+		case string:
+			info.Role = p.allowedRole(v)
+		case []interface{}: // e.g. "roles": ["admin", "user"]
+			for _, role := range v {
+				if s, ok := role.(string); ok && p.allowedRole(s) != "" {
+					info.Role = s
+					break
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// allowedRole returns role if it is in Config.AllowedRoles, otherwise "".
+func (p *Provider) allowedRole(role string) string {
+	for _, allowed := range p.cfg.AllowedRoles {
+		if role == allowed {
+			return role
+		}
+	}
+	return ""
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
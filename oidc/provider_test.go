@@ -0,0 +1,38 @@
+package oidc
+
+import "testing"
+
+func TestMapClaims(t *testing.T) {
+	p := &Provider{cfg: Config{
+		Name:         "sso",
+		TrustEmail:   true,
+		AllowedRoles: []string{"editor", "viewer"},
+		Claims:       ClaimMapping{Role: "roles"}.withDefaults(),
+	}}
+
+	info, err := p.mapClaims(map[string]interface{}{
+		"sub":   "123",
+		"email": "alice@corp.example",
+		"roles": []interface{}{"admin", "viewer"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Role != "viewer" {
+		t.Errorf("Role = %q, want the first allowed role %q", info.Role, "viewer")
+	}
+	if !info.EmailVerified || !info.EmailAssumedVerified {
+		t.Errorf("TrustEmail: EmailVerified = %v, EmailAssumedVerified = %v, want both true", info.EmailVerified, info.EmailAssumedVerified)
+	}
+
+	info, err = p.mapClaims(map[string]interface{}{"sub": "123", "email_verified": true, "roles": "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Role != "" {
+		t.Errorf("Role = %q, want no role for a claim outside AllowedRoles", info.Role)
+	}
+	if info.EmailAssumedVerified {
+		t.Error("EmailAssumedVerified is set although the IdP sent email_verified")
+	}
+}
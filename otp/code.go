@@ -0,0 +1,44 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+const (
+	MinDigits = 6
+	MaxDigits = 8
+)
+
+// GenerateCode returns a uniformly random numeric code with the given number of digits.
+// Leading zeros are kept, so the code must be treated as a string.
+func GenerateCode(digits int) (string, error) {
+	if digits < MinDigits || digits > MaxDigits {
+		return "", fmt.Errorf("invalid otp length %d: must be between %d and %d", digits, MinDigits, MaxDigits)
+	}
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("generate otp: %w", err)
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// HashCode returns the hex-encoded HMAC-SHA256 of a code under a server secret, suitable
+// for storage. Codes have few digits, so a plain hash would be reversed by trying them
+// all; the key keeps a leaked store from revealing live codes.
+func HashCode(key []byte, code string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckCode reports whether code matches the stored hash, in constant time.
+func CheckCode(key []byte, codeHash, code string) bool {
+	return subtle.ConstantTimeCompare([]byte(codeHash), []byte(HashCode(key, code))) == 1
+}
@@ -0,0 +1,17 @@
+package otp
+
+import "testing"
+
+func TestHashCode(t *testing.T) {
+	key := []byte("server secret")
+	hash := HashCode(key, "123456")
+	if !CheckCode(key, hash, "123456") {
+		t.Error("CheckCode() rejects the hashed code")
+	}
+	if CheckCode(key, hash, "654321") {
+		t.Error("CheckCode() accepts a different code")
+	}
+	if CheckCode([]byte("other secret"), hash, "123456") {
+		t.Error("CheckCode() accepts the code under a different key")
+	}
+}
@@ -0,0 +1,73 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// EmailTransport delivers a plain-text email. Applications implement this
+// on top of their email service (SMTP, SES, SendGrid, ...).
+type EmailTransport interface {
+	SendEmail(ctx context.Context, toEmail, subject, body string) error
+}
+
+// SMSTransport delivers a plain-text SMS. Applications implement this
+// on top of their SMS gateway (Twilio, SNS, ...).
+type SMSTransport interface {
+	SendSMS(ctx context.Context, toPhoneNumber, message string) error
+}
+
+// EmailSender is a core.OTPSender that delivers codes by email.
+type EmailSender struct {
+	transport EmailTransport
+	appName   string
+}
+
+// NewEmailSender creates a new EmailSender.
+// appName is used in the subject and body of the message.
+func NewEmailSender(transport EmailTransport, appName string) *EmailSender {
+	return &EmailSender{transport: transport, appName: appName}
+}
+
+func (s *EmailSender) SendOTP(ctx context.Context, destination, username, code string, purpose core.OTPPurpose) error {
+	subject := fmt.Sprintf("Your %s code: %s", s.appName, code)
+	body := fmt.Sprintf("Hi %s,\n\nYour %s code is %s. %s\n\nIf you did not request this code, you can ignore this email.\n",
+		username, s.appName, code, purposeText(purpose))
+	if err := s.transport.SendEmail(ctx, destination, subject, body); err != nil {
+		return fmt.Errorf("send otp email: %w", err)
+	}
+	return nil
+}
+
+// SMSSender is a core.OTPSender that delivers codes by SMS.
+type SMSSender struct {
+	transport SMSTransport
+	appName   string
+}
+
+// NewSMSSender creates a new SMSSender.
+// appName is used as a prefix of the message.
+func NewSMSSender(transport SMSTransport, appName string) *SMSSender {
+	return &SMSSender{transport: transport, appName: appName}
+}
+
+func (s *SMSSender) SendOTP(ctx context.Context, destination, username, code string, purpose core.OTPPurpose) error {
+	message := fmt.Sprintf("%s: your code is %s. %s", s.appName, code, purposeText(purpose))
+	if err := s.transport.SendSMS(ctx, destination, message); err != nil {
+		return fmt.Errorf("send otp sms: %w", err)
+	}
+	return nil
+}
+
+func purposeText(purpose core.OTPPurpose) string {
+	switch purpose {
+	case core.OTPPurposeLogin:
+		return "Use it to sign in."
+	case core.OTPPurposeEmailVerification:
+		return "Use it to verify your email address."
+	default:
+		return ""
+	}
+}
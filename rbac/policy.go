@@ -0,0 +1,172 @@
+// Package rbac resolves role definitions with inheritance into permission sets
+// and answers permission checks for the roles a user holds.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// Wildcard grants every permission; "invoices:*" grants every permission starting with
+// "invoices:", such as "invoices:write:all".
+const Wildcard = "*"
+
+// Policy maps roles to their effective permissions, including inherited ones. Role names
+// are compared case-insensitively, like the role middlewares do; permissions are not.
+// It is safe for concurrent use and can be reloaded while in use.
+type Policy struct {
+	mu    sync.RWMutex
+	roles map[string]map[string]bool // lowercased role -> itself and all roles it inherits
+	perms map[string]map[string]bool // lowercased role -> effective permissions
+}
+
+// NewPolicy resolves the role definitions. It fails on unknown parents and inheritance cycles.
+func NewPolicy(roles []core.Role) (*Policy, error) {
+	p := &Policy{}
+	if err := p.Load(roles); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewPolicyFromStore loads the role definitions from store.
+func NewPolicyFromStore(ctx context.Context, store core.RoleStorer) (*Policy, error) {
+	p := &Policy{}
+	if err := p.LoadFromStore(ctx, store); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadFromStore replaces the role definitions with those in store, e.g. after an admin edited them.
+func (p *Policy) LoadFromStore(ctx context.Context, store core.RoleStorer) error {
+	roles, err := store.ListRoles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list roles: %w", err)
+	}
+	return p.Load(roles)
+}
+
+// Load replaces the role definitions. On error the previous definitions are kept.
+func (p *Policy) Load(roles []core.Role) error {
+	defs := make(map[string]core.Role, len(roles))
+	for _, role := range roles {
+		if role.Name == "" {
+			return fmt.Errorf("rbac: role without a name")
+		}
+		key := strings.ToLower(role.Name)
+		if _, dup := defs[key]; dup {
+			return fmt.Errorf("rbac: role %q is defined twice", role.Name)
+		}
+		defs[key] = role
+	}
+
+	closures := make(map[string]map[string]bool, len(defs))
+	var resolve func(name string, path []string) (map[string]bool, error)
+	resolve = func(name string, path []string) (map[string]bool, error) {
+		name = strings.ToLower(name)
+		if closure, ok := closures[name]; ok {
+			return closure, nil
+		}
+		for _, seen := range path {
+			if seen == name {
+				return nil, fmt.Errorf("rbac: inheritance cycle %s", strings.Join(append(path, name), " -> "))
+			}
+		}
+		role, ok := defs[name]
+		if !ok {
+			return nil, fmt.Errorf("rbac: role %q inherits unknown role %q", path[len(path)-1], name)
+		}
+		closure := map[string]bool{name: true}
+		for _, parent := range role.Inherits {
+			inherited, err := resolve(parent, append(path, name))
+			if err != nil {
+				return nil, err
+			}
+			for r := range inherited {
+				closure[r] = true
+			}
+		}
+		closures[name] = closure
+		return closure, nil
+	}
+
+	perms := make(map[string]map[string]bool, len(defs))
+	for name := range defs {
+		closure, err := resolve(name, nil)
+		if err != nil {
+			return err
+		}
+		set := make(map[string]bool)
+		for r := range closure {
+			for _, perm := range defs[r].Permissions {
+				set[perm] = true
+			}
+		}
+		perms[name] = set
+	}
+
+	p.mu.Lock()
+	p.roles = closures
+	p.perms = perms
+	p.mu.Unlock()
+	return nil
+}
+
+// Permissions returns the sorted effective permissions of the given roles.
+// Unknown roles grant nothing.
+func (p *Policy) Permissions(roles ...string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	set := make(map[string]bool)
+	for _, role := range roles {
+		for perm := range p.perms[strings.ToLower(role)] {
+			set[perm] = true
+		}
+	}
+	perms := make([]string, 0, len(set))
+	for perm := range set {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+// HasPermission reports whether any of roles grants permission, directly, through
+// inheritance or through a wildcard.
+func (p *Policy) HasPermission(roles []string, permission string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, role := range roles {
+		perms := p.perms[strings.ToLower(role)]
+		if perms[permission] || perms[Wildcard] {
+			return true
+		}
+		for i := range len(permission) {
+			if permission[i] == ':' && i > 0 && perms[permission[:i+1]+Wildcard] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasRole reports whether any of roles is target or inherits it.
+// Roles without a definition only match themselves.
+func (p *Policy) HasRole(roles []string, target string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	target = strings.ToLower(target)
+	for _, role := range roles {
+		role = strings.ToLower(role)
+		if role == target || p.roles[role][target] {
+			return true
+		}
+	}
+	return false
+}
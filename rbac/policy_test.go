@@ -0,0 +1,114 @@
+package rbac
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   []core.Role
+		wantErr string
+	}{
+		{"inheritance", []core.Role{
+			{Name: "viewer", Permissions: []string{"invoices:read"}},
+			{Name: "editor", Inherits: []string{"Viewer"}, Permissions: []string{"invoices:write"}},
+		}, ""},
+		{"unknown parent", []core.Role{{Name: "editor", Inherits: []string{"viewer"}}}, "unknown role"},
+		{"self inheritance", []core.Role{{Name: "admin", Inherits: []string{"admin"}}}, "cycle"},
+		{"cycle", []core.Role{
+			{Name: "a", Inherits: []string{"b"}},
+			{Name: "b", Inherits: []string{"c"}},
+			{Name: "c", Inherits: []string{"a"}},
+		}, "cycle"},
+		{"defined twice", []core.Role{{Name: "admin"}, {Name: "Admin"}}, "defined twice"},
+		{"no name", []core.Role{{Permissions: []string{"*"}}}, "without a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPolicy(tt.roles)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("NewPolicy() = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("NewPolicy() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadKeepsPreviousOnError(t *testing.T) {
+	p, err := NewPolicy([]core.Role{{Name: "viewer", Permissions: []string{"invoices:read"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Load([]core.Role{{Name: "a", Inherits: []string{"a"}}}); err == nil {
+		t.Fatal("Load() accepted a cycle")
+	}
+	if !p.HasPermission([]string{"viewer"}, "invoices:read") {
+		t.Error("the previous definitions were not kept")
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	p, err := NewPolicy([]core.Role{
+		{Name: "viewer", Permissions: []string{"invoices:read"}},
+		{Name: "editor", Inherits: []string{"viewer"}, Permissions: []string{"invoices:write"}},
+		{Name: "auditor", Inherits: []string{"viewer"}, Permissions: []string{"logs:read"}},
+		{Name: "manager", Inherits: []string{"editor", "auditor"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"invoices:read", "invoices:write", "logs:read"}
+	if got := p.Permissions("Manager"); !slices.Equal(got, want) {
+		t.Errorf("Permissions(Manager) = %v, want %v", got, want)
+	}
+	if got := p.Permissions("unknown"); len(got) != 0 {
+		t.Errorf("Permissions(unknown) = %v, want none", got)
+	}
+	if !p.HasRole([]string{"MANAGER"}, "viewer") || p.HasRole([]string{"auditor"}, "editor") {
+		t.Error("HasRole does not follow inheritance")
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	p, err := NewPolicy([]core.Role{
+		{Name: "root", Permissions: []string{Wildcard}},
+		{Name: "billing", Permissions: []string{"invoices:*"}},
+		{Name: "writer", Permissions: []string{"invoices:write:*"}},
+		{Name: "viewer", Permissions: []string{"invoices:read"}},
+		{Name: "editor", Inherits: []string{"viewer"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{"root", "anything:at:all", true},
+		{"billing", "invoices:read", true},
+		{"billing", "invoices:write:all", true},
+		{"billing", "invoicesx:read", false},
+		{"billing", "reports:read", false},
+		{"writer", "invoices:write:all", true},
+		{"writer", "invoices:read", false},
+		{"viewer", "invoices:read", true},
+		{"viewer", "invoices:write", false},
+		{"editor", "invoices:read", true},
+		{"Editor", "invoices:read", true},
+		{"viewer", "Invoices:read", false},
+		{"unknown", "invoices:read", false},
+	}
+	for _, tt := range tests {
+		if got := p.HasPermission([]string{tt.role}, tt.permission); got != tt.want {
+			t.Errorf("HasPermission(%s, %s) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/dpop"
+	"github.com/shawgichan/go-authkit/mtls"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// Authenticator verifies access tokens and the sessions behind them. It holds the
+// checks shared by the middlewares of all frameworks, including the extraction of
+// credentials from requests and the checks of sender-constrained tokens.
+type Authenticator struct {
+	tokenMaker token.Maker
+	users      core.UserStorer
+	config     *config.AuthConfig
+
+	Revocations   core.TokenRevocationStorer // Optional; revoked tokens are rejected
+	Organizations core.OrganizationStorer    // Optional; tenant roles are reloaded from the membership
+	OAuthClients  core.OAuthClientStorer     // Optional; tokens issued to a client are rejected once it is deleted
+	VerifyOptions []token.VerifyOption       // Defaults to TokenVerifyOptions(cfg)
+
+	// Request authentication (see AuthenticateRequest)
+	APIKeys     core.APIKeyStorer // Optional; "Authorization: ApiKey <key>" is accepted
+	DPoP        *dpop.Verifier    // Optional; "Authorization: DPoP <token>" is accepted with a proof
+	ClientCerts mtls.Source       // Where certificates of certificate-bound tokens are found
+}
+
+// NewAuthenticator creates an Authenticator checking tokens against cfg.
+func NewAuthenticator(tokenMaker token.Maker, users core.UserStorer, cfg *config.AuthConfig) *Authenticator {
+	return &Authenticator{
+		tokenMaker:    tokenMaker,
+		users:         users,
+		config:        cfg,
+		VerifyOptions: TokenVerifyOptions(cfg),
+	}
+}
+
+// VerifyToken verifies an access token and checks that it was not revoked. Failures are
+// returned as core.ErrTokenInvalid, core.ErrTokenExpired or core.ErrTokenRevoked.
+func (a *Authenticator) VerifyToken(ctx context.Context, accessToken string) (*token.Payload, error) {
+	payload, err := a.tokenMaker.VerifyToken(accessToken, a.VerifyOptions...)
+	if err != nil {
+		switch {
+		case errors.Is(err, token.ErrExpiredToken):
+			return nil, core.ErrTokenExpired
+		case errors.Is(err, token.ErrTokenNotYetValid), errors.Is(err, token.ErrInvalidIssuer), errors.Is(err, token.ErrInvalidAudience):
+			return nil, fmt.Errorf("%w: %v", core.ErrTokenInvalid, err)
+		default:
+			return nil, core.ErrTokenInvalid
+		}
+	}
+
+	if a.Revocations != nil {
+		revoked, err := a.Revocations.IsTokenRevoked(ctx, payload.TokenID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, core.ErrTokenRevoked
+		}
+	}
+	return payload, nil
+}
+
+// CheckSession checks the principal behind a verified payload: that the client a token
+// was issued to still exists (with OAuthClients), that the user still exists and is
+// active, that a login token is their active session under single-device login, and
+// reloads tenant roles. accessToken is the raw token the payload was verified from.
+// Payloads of API keys get the user's current name and those of the user's current roles
+// that the key's scopes grant (see token.RoleScope).
+func (a *Authenticator) CheckSession(ctx context.Context, payload *token.Payload, accessToken string) error {
+	if payload.ClientID != "" && a.OAuthClients != nil {
+		if _, err := a.OAuthClients.GetOAuthClient(ctx, payload.ClientID); err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				return core.ErrTokenInvalid
+			}
+			return err
+		}
+	}
+	// Client credentials tokens represent an OAuth2 client, not a user
+	if payload.IsClientCredentials() {
+		return nil
+	}
+
+	user, err := a.users.GetUserByID(ctx, payload.UserID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
+			return core.ErrUserNotFound
+		}
+		return err
+	}
+	if err := UserStatusError(user); err != nil {
+		return err
+	}
+	if payload.IsAPIKey() {
+		payload.Username = user.Username
+		payload.Role = ""
+		payload.Roles = scopedRoles(payload, append([]string{user.Role}, user.Roles...))
+		payload.PrincipalType = PrincipalType(user)
+	}
+
+	// Enforce single-device login if configured. Tokens issued to OAuth2 clients, API keys
+	// and impersonation tokens are delegated access, not the user's login session.
+	if a.config.EnforceSingleDeviceLogin && payload.IsLoginToken() && !payload.IsImpersonated() {
+		if user.ActiveToken == "" { // Logged out elsewhere or session expired
+			return core.ErrSessionExpired
+		}
+		if user.ActiveToken != accessToken {
+			return core.ErrSessionReplaced
+		}
+	}
+
+	if a.Organizations != nil && payload.HasTenant() {
+		if err := RefreshTenantRoles(ctx, a.Organizations, payload); err != nil {
+			return err
+		}
+	}
+	if payload.IsAPIKey() {
+		payload.TenantRoles = scopedRoles(payload, payload.TenantRoles)
+	}
+	return nil
+}
+
+// scopedRoles returns the roles an API key's scopes grant.
+func scopedRoles(payload *token.Payload, roles []string) []string {
+	var granted []string
+	for _, role := range roles {
+		if role != "" && payload.HasScope(token.RoleScope(role)) {
+			granted = append(granted, role)
+		}
+	}
+	return granted
+}
+
+// RefreshTenantRoles replaces the token's tenant roles with the user's current membership.
+func RefreshTenantRoles(ctx context.Context, store core.OrganizationStorer, payload *token.Payload) error {
+	orgID, err := uuid.Parse(payload.TenantID)
+	if err != nil {
+		return core.ErrTokenInvalid
+	}
+	membership, err := store.GetMembership(ctx, orgID, payload.UserID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return core.ErrNotMember
+		}
+		return err
+	}
+	payload.TenantRoles = membership.AllRoles()
+	return nil
+}
+
+// TokenVerifyOptions returns the verification options for the issuer, audience and leeway in cfg.
+func TokenVerifyOptions(cfg *config.AuthConfig) []token.VerifyOption {
+	opts := []token.VerifyOption{token.WithLeeway(cfg.TokenLeeway)}
+	if cfg.TokenIssuer != "" {
+		opts = append(opts, token.ExpectIssuer(cfg.TokenIssuer))
+	}
+	if len(cfg.TokenAudience) > 0 {
+		opts = append(opts, token.ExpectAudience(cfg.TokenAudience...))
+	}
+	return opts
+}
+
+// UserStatusError returns the error for a user whose status does not allow access, or nil.
+func UserStatusError(user core.User) error {
+	switch user.Status {
+	case core.StatusActive:
+		return nil
+	case core.StatusPending:
+		return core.ErrUserNotVerified
+	case core.StatusSuspended:
+		return core.ErrUserSuspended
+	default: // Other non-active statuses
+		return core.ErrUserInactive
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+type stubUserStore struct {
+	core.UserStorer
+	user core.User
+}
+
+func (s stubUserStore) GetUserByID(ctx context.Context, id uuid.UUID) (core.User, error) {
+	if id != s.user.ID {
+		return core.User{}, core.ErrNotFound
+	}
+	return s.user, nil
+}
+
+type stubClientStore struct {
+	core.OAuthClientStorer
+	clients map[string]core.OAuthClient
+}
+
+func (s stubClientStore) GetOAuthClient(ctx context.Context, clientID string) (core.OAuthClient, error) {
+	client, ok := s.clients[clientID]
+	if !ok {
+		return core.OAuthClient{}, core.ErrNotFound
+	}
+	return client, nil
+}
+
+func TestCheckSessionClientCredentials(t *testing.T) {
+	a := NewAuthenticator(nil, nil, config.DefaultAuthConfig())
+	a.OAuthClients = stubClientStore{clients: map[string]core.OAuthClient{"reports": {ClientID: "reports"}}}
+	ctx := context.Background()
+
+	active := &token.Payload{ClientID: "reports", GrantType: token.GrantTypeClientCredentials}
+	if err := a.CheckSession(ctx, active, ""); err != nil {
+		t.Fatalf("CheckSession() for an existing client = %v, want nil", err)
+	}
+	deleted := &token.Payload{ClientID: "deleted", GrantType: token.GrantTypeClientCredentials}
+	if err := a.CheckSession(ctx, deleted, ""); !errors.Is(err, core.ErrTokenInvalid) {
+		t.Fatalf("CheckSession() for a deleted client = %v, want ErrTokenInvalid", err)
+	}
+	delegated := &token.Payload{UserID: uuid.New(), ClientID: "deleted"}
+	if err := a.CheckSession(ctx, delegated, ""); !errors.Is(err, core.ErrTokenInvalid) {
+		t.Fatalf("CheckSession() for a token delegated to a deleted client = %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestCheckSessionAPIKeyRoles(t *testing.T) {
+	user := core.User{ID: uuid.New(), Username: "alice", Role: "admin", Roles: []string{"billing"}, Status: core.StatusActive}
+	a := NewAuthenticator(nil, stubUserStore{user: user}, config.DefaultAuthConfig())
+
+	tests := []struct {
+		scope string
+		want  []string
+	}{
+		{"reports:read", nil},
+		{"reports:read role:billing", []string{"billing"}},
+		{"role:admin role:billing role:support", []string{"admin", "billing"}}, // Not roles the user lacks
+	}
+	for _, tt := range tests {
+		payload := &token.Payload{UserID: user.ID, Scope: tt.scope, GrantType: token.GrantTypeAPIKey}
+		if err := a.CheckSession(context.Background(), payload, ""); err != nil {
+			t.Fatal(err)
+		}
+		if got := payload.AllRoles(); !slices.Equal(got, tt.want) && len(got)+len(tt.want) > 0 {
+			t.Errorf("scope %q: roles = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/dpop"
+	"github.com/shawgichan/go-authkit/mtls"
+	"github.com/shawgichan/go-authkit/oauthserver"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// Authorization schemes accepted by AuthenticateRequest, in lower case.
+const (
+	SchemeBearer = "bearer"
+	SchemeAPIKey = "apikey"
+	SchemeDPoP   = "dpop"
+)
+
+// apiKeyTouchInterval limits how often an API key's last-used time is written.
+const apiKeyTouchInterval = time.Minute
+
+// AuthenticateRequest authenticates an HTTP request the same way for every framework:
+// it reads the credential from the Authorization header, verifies it (see VerifyToken and
+// the APIKeys and DPoP fields), checks that sender-constrained tokens are presented by
+// their holder, and checks the session (see CheckSession).
+//
+// With cookies, a request without an Authorization header may carry the access token in
+// the session cookie (AuthConfig.AccessTokenCookieName). Browsers attach cookies to
+// cross-site requests too, so requests with unsafe methods must then pass CheckCSRF for
+// the token's session.
+func (a *Authenticator) AuthenticateRequest(r *http.Request, cookies bool) (*token.Payload, error) {
+	ctx := r.Context()
+	authorizationHeader := r.Header.Get("Authorization")
+	fromCookie := false
+	if authorizationHeader == "" && cookies {
+		if cookie, err := r.Cookie(a.config.AccessTokenCookieName); err == nil && cookie.Value != "" {
+			authorizationHeader = "Bearer " + cookie.Value
+			fromCookie = true
+		}
+	}
+	if authorizationHeader == "" {
+		return nil, core.ErrAuthorizationMissing
+	}
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("%w: invalid format", core.ErrAuthorizationInvalid)
+	}
+
+	scheme, credential := strings.ToLower(fields[0]), fields[1]
+	var payload *token.Payload
+	switch {
+	case scheme == SchemeBearer, scheme == SchemeDPoP && a.DPoP != nil:
+		var err error
+		if payload, err = a.VerifyToken(ctx, credential); err != nil {
+			return nil, err
+		}
+		if payload.CertificateThumbprint() != "" {
+			cert, err := a.ClientCerts.Certificate(r)
+			if err != nil {
+				return nil, err
+			}
+			if err := CheckCertificateBinding(payload, cert); err != nil {
+				return nil, err
+			}
+		}
+		if err := a.checkDPoPBinding(r, scheme, credential, payload); err != nil {
+			return nil, err
+		}
+	case scheme == SchemeAPIKey && a.APIKeys != nil:
+		var err error
+		if payload, err = a.authenticateAPIKey(ctx, credential); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", core.ErrAuthorizationInvalid, scheme)
+	}
+	if fromCookie {
+		if err := CheckCSRF(r, a.config, payload.SessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.CheckSession(ctx, payload, credential); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// CheckCertificateBinding verifies that a certificate-bound token (RFC 8705 "cnf" claim)
+// is presented with its certificate. cert is the verified client certificate of the
+// connection, or nil if there is none. Tokens that are not bound pass.
+func CheckCertificateBinding(payload *token.Payload, cert *x509.Certificate) error {
+	thumbprint := payload.CertificateThumbprint()
+	if thumbprint == "" {
+		return nil
+	}
+	if cert == nil {
+		return core.ErrClientCertRequired
+	}
+	if subtle.ConstantTimeCompare([]byte(mtls.Thumbprint(cert)), []byte(thumbprint)) != 1 {
+		return core.ErrCertificateMismatch
+	}
+	return nil
+}
+
+// checkDPoPBinding enforces DPoP for a verified access token sent with scheme: DPoP-bound
+// tokens need the DPoP scheme and a proof of their key, and only they may use the scheme.
+func (a *Authenticator) checkDPoPBinding(r *http.Request, scheme, accessToken string, payload *token.Payload) error {
+	thumbprint := payload.DPoPThumbprint()
+	if scheme != SchemeDPoP {
+		if thumbprint != "" {
+			return fmt.Errorf("%w: a DPoP-bound token must be sent with the DPoP authorization scheme", core.ErrDPoPProofInvalid)
+		}
+		return nil
+	}
+	if thumbprint == "" {
+		return fmt.Errorf("%w: token is not DPoP-bound", core.ErrDPoPProofInvalid)
+	}
+	proofs := r.Header.Values(dpop.HeaderName)
+	if len(proofs) != 1 {
+		return fmt.Errorf("%w: exactly one DPoP header is required", core.ErrDPoPProofInvalid)
+	}
+	proof, err := a.DPoP.Verify(r.Context(), proofs[0], r.Method, DPoPRequestURL(r, a.config), accessToken)
+	if err != nil {
+		return err
+	}
+	if proof.Thumbprint != thumbprint {
+		return fmt.Errorf("%w: proof key does not match the token", core.ErrDPoPProofInvalid)
+	}
+	return nil
+}
+
+// DPoPRequestURL returns the URL a DPoP proof for r must name in "htu".
+func DPoPRequestURL(r *http.Request, cfg *config.AuthConfig) string {
+	base := cfg.DPoPBaseURL
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	return strings.TrimSuffix(base, "/") + r.URL.Path
+}
+
+// authenticateAPIKey looks up an API key and returns a payload for it. CheckSession fills
+// in the user fields.
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, rawKey string) (*token.Payload, error) {
+	key, err := a.APIKeys.GetAPIKeyByHash(ctx, oauthserver.HashToken(rawKey))
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil, core.ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if key.IsExpired(now) {
+		return nil, core.ErrAPIKeyInvalid
+	}
+	if now.Sub(key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := a.APIKeys.UpdateAPIKeyLastUsed(ctx, key.ID, now); err != nil {
+			fmt.Printf("Warning: failed to update last use of API key %s: %v\n", key.ID, err)
+			// h.logger.Error("Failed to update API key last use", "error", err)
+		}
+	}
+	return &token.Payload{
+		TokenID:   key.ID,
+		UserID:    key.UserID,
+		IssuedAt:  key.CreatedAt,
+		ExpiredAt: key.ExpiresAt,
+		NotBefore: key.CreatedAt,
+		Scope:     strings.Join(key.Scopes, " "),
+		GrantType: token.GrantTypeAPIKey,
+		TenantID:  key.TenantID,
+	}, nil
+}
+
+// CSRFToken returns the CSRF token of a cookie session: an HMAC of the session ID, so a
+// token only passes CheckCSRF for the session it was issued with.
+func CSRFToken(cfg *config.AuthConfig, sessionID string) (string, error) {
+	key := cfg.CSRFKey
+	if key == "" {
+		key = cfg.TokenSymmetricKey
+	}
+	if key == "" {
+		return "", errors.New("neither CSRFKey nor TokenSymmetricKey is configured")
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("csrf:" + sessionID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// CheckCSRF enforces the double-submit check for cookie-authenticated requests with
+// unsafe methods: the CSRF header must repeat the CSRF cookie, which other sites can
+// neither read nor set, and both must be the CSRFToken of the request's session.
+func CheckCSRF(r *http.Request, cfg *config.AuthConfig, sessionID string) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+	if sessionID == "" {
+		return core.ErrCSRFTokenInvalid
+	}
+	cookie, err := r.Cookie(cfg.CSRFCookieName)
+	if err != nil || cookie.Value == "" {
+		return core.ErrCSRFTokenInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.Header.Get(cfg.CSRFHeaderName))) != 1 {
+		return core.ErrCSRFTokenInvalid
+	}
+	expected, err := CSRFToken(cfg, sessionID)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(expected)) != 1 {
+		return core.ErrCSRFTokenInvalid
+	}
+	return nil
+}
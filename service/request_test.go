@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+)
+
+func TestCheckCSRF(t *testing.T) {
+	cfg := config.DefaultAuthConfig()
+	cfg.TokenSymmetricKey = "12345678901234567890123456789012"
+	csrfToken, err := CSRFToken(cfg, "session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(method, cookie, header string) *http.Request {
+		r := httptest.NewRequest(method, "/api/me", nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: cfg.CSRFCookieName, Value: cookie})
+		}
+		if header != "" {
+			r.Header.Set(cfg.CSRFHeaderName, header)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name      string
+		r         *http.Request
+		sessionID string
+		wantErr   bool
+	}{
+		{"safe method", request(http.MethodGet, "", ""), "session-a", false},
+		{"token of the session", request(http.MethodPost, csrfToken, csrfToken), "session-a", false},
+		{"missing header", request(http.MethodPost, csrfToken, ""), "session-a", true},
+		{"header differs from cookie", request(http.MethodPost, csrfToken, "other"), "session-a", true},
+		{"token of another session", request(http.MethodPost, csrfToken, csrfToken), "session-b", true},
+		{"forged pair", request(http.MethodPost, "forged", "forged"), "session-a", true},
+		{"token without session", request(http.MethodPost, csrfToken, csrfToken), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckCSRF(tt.r, cfg, tt.sessionID)
+			if tt.wantErr && !errors.Is(err, core.ErrCSRFTokenInvalid) {
+				t.Fatalf("CheckCSRF() = %v, want ErrCSRFTokenInvalid", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("CheckCSRF() = %v, want nil", err)
+			}
+		})
+	}
+}
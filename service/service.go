@@ -0,0 +1,266 @@
+// Package service implements the authentication flows independently of any web
+// framework: registration, email verification, password login and access token
+// issuing and verification. The httphandler package serves it over net/http and
+// ginhandler over Gin.
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shawgichan/go-authkit/config"
+	"github.com/shawgichan/go-authkit/core"
+	"github.com/shawgichan/go-authkit/hash"
+	"github.com/shawgichan/go-authkit/token"
+)
+
+// MinPasswordLength is the shortest password Register accepts.
+const MinPasswordLength = 8
+
+// ClaimsEnricher returns application-specific claims for a user's login tokens, e.g. a
+// plan or feature flags. It runs on every login; returning an error fails the login.
+type ClaimsEnricher func(ctx context.Context, user core.User) (map[string]interface{}, error)
+
+// Auth implements registration, email verification and password login.
+type Auth struct {
+	store      core.UserStorer
+	tokenMaker token.Maker
+	hasher     hash.PasswordHasher
+	mailer     core.EmailSender // Can be nil if verification emails aren't sent
+	config     *config.AuthConfig
+
+	claimsEnricher ClaimsEnricher
+
+	// VerificationURI is the link sent to verify email addresses, with ?token=. It
+	// defaults to the config's EmailVerificationURI, or AppBaseURL + "/auth/verify-email".
+	VerificationURI string
+}
+
+// Option configures optional features of Auth.
+type Option func(*Auth)
+
+// WithClaimsEnricher sets the hook that adds application-specific claims to login tokens.
+func WithClaimsEnricher(enricher ClaimsEnricher) Option {
+	return func(a *Auth) {
+		a.claimsEnricher = enricher
+	}
+}
+
+// New creates the authentication service.
+func New(store core.UserStorer, tokenMaker token.Maker, hasher hash.PasswordHasher, mailer core.EmailSender, cfg *config.AuthConfig, opts ...Option) *Auth {
+	a := &Auth{
+		store:      store,
+		tokenMaker: tokenMaker,
+		hasher:     hasher,
+		mailer:     mailer,
+		config:     cfg,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.VerificationURI = cfg.EmailVerificationURI
+	if a.VerificationURI == "" {
+		a.VerificationURI = strings.TrimSuffix(cfg.AppBaseURL, "/") + "/auth/verify-email"
+	}
+	return a
+}
+
+// RegisterParams are the details of a new user.
+type RegisterParams struct {
+	Email       string
+	Password    string
+	FullName    string
+	PhoneNumber string // Optional, only needed for SMS one-time passcodes
+}
+
+// Register creates a user pending email verification. It does not send the
+// verification email; see SendVerificationLink.
+func (a *Auth) Register(ctx context.Context, params RegisterParams) (core.User, error) {
+	if len(params.Password) < MinPasswordLength {
+		return core.User{}, core.ErrPasswordTooShort
+	}
+
+	// Check if user already exists
+	_, err := a.store.GetUserByEmail(ctx, params.Email)
+	if err == nil {
+		return core.User{}, core.ErrDuplicateEmail
+	}
+	if !errors.Is(err, core.ErrNotFound) {
+		return core.User{}, err
+	}
+
+	hashedPassword, err := a.hasher.Hash(params.Password)
+	if err != nil {
+		return core.User{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+	return a.store.CreateUser(ctx, core.CreateUserParams{
+		Email:        params.Email,
+		Username:     params.Email, // Default username to email
+		PasswordHash: hashedPassword,
+		PhoneNumber:  params.PhoneNumber,
+		FullName:     params.FullName,
+		Role:         a.config.DefaultUserRole,
+		Status:       core.StatusPending, // New users start as pending verification
+	})
+}
+
+// SendVerificationLink stores a verification token for user and emails them a link to
+// verify their address. The email is sent in the background. It does nothing without
+// an email sender.
+func (a *Auth) SendVerificationLink(ctx context.Context, user core.User) error {
+	if a.mailer == nil {
+		return nil
+	}
+	verificationToken, err := generateSecureToken(16) // 16 bytes -> 32 hex chars
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	expiresAt := time.Now().Add(a.config.EmailVerificationTokenDuration)
+	if err := a.store.StoreVerificationData(ctx, user.ID, user.Email, verificationToken, expiresAt); err != nil {
+		return fmt.Errorf("failed to store verification data: %w", err)
+	}
+	verificationLink := fmt.Sprintf("%s?token=%s", a.VerificationURI, verificationToken)
+
+	// Send email asynchronously to avoid blocking the request
+	go func(mailTo, userNm, link string) {
+		if err := a.mailer.SendVerificationEmail(context.Background(), mailTo, userNm, link); err != nil {
+			// h.logger.Error("Failed to send verification email", "error", err, "email", mailTo)
+			fmt.Printf("Error sending verification email to %s: %v\n", mailTo, err)
+		}
+	}(user.Email, user.FullName, verificationLink)
+	return nil
+}
+
+// VerifyEmail activates the user a verification token was issued to and deletes the token.
+func (a *Auth) VerifyEmail(ctx context.Context, verificationToken string) (core.User, error) {
+	userID, _, err := a.store.GetVerificationData(ctx, verificationToken)
+	if err != nil {
+		return core.User{}, err // ErrVerificationNotFound
+	}
+
+	activeStatus := core.StatusActive
+	user, err := a.store.UpdateUser(ctx, userID, core.UpdateUserParams{Status: &activeStatus})
+	if err != nil {
+		return core.User{}, fmt.Errorf("failed to activate user: %w", err)
+	}
+
+	if err := a.store.DeleteVerificationData(ctx, verificationToken); err != nil {
+		// h.logger.Error("Failed to delete verification data", "error", err)
+		// Verification was successful anyway.
+		fmt.Printf("Warning: Failed to delete verification token %s: %v\n", verificationToken, err)
+	}
+	return user, nil
+}
+
+// Login checks a user's email and password. Unknown users and wrong passwords both
+// return core.ErrInvalidCredentials.
+func (a *Auth) Login(ctx context.Context, email, password string) (core.User, error) {
+	user, err := a.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrUserDeleted) {
+			return core.User{}, core.ErrInvalidCredentials // Generic error for non-existent user
+		}
+		return core.User{}, err
+	}
+
+	// Check user status before checking password
+	if err := CheckLoginStatus(user); err != nil {
+		return core.User{}, err
+	}
+	if err := a.hasher.Check(user.PasswordHash, password); err != nil {
+		return core.User{}, core.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// CheckLoginStatus returns the error for a user whose account may not log in, or nil.
+func CheckLoginStatus(user core.User) error {
+	if user.IsServiceAccount() { // Service accounts authenticate with API keys or client credentials only
+		return core.ErrInvalidCredentials
+	}
+	if user.Status == core.StatusPending {
+		return core.ErrUserNotVerified
+	}
+	if user.Status != core.StatusActive { // e.g. suspended
+		return core.ErrUserInactive
+	}
+	return nil
+}
+
+// GetUser returns a user by ID.
+func (a *Auth) GetUser(ctx context.Context, userID uuid.UUID) (core.User, error) {
+	return a.store.GetUserByID(ctx, userID)
+}
+
+// IssueLoginToken issues a first-party login token for an authenticated user, with the
+// user's roles and enriched claims. opts are applied last. With single-device login the
+// token becomes the user's only active one.
+func (a *Auth) IssueLoginToken(ctx context.Context, user core.User, opts ...token.PayloadOption) (string, *token.Payload, error) {
+	claims, err := a.EnrichClaims(ctx, user)
+	if err != nil {
+		return "", nil, err
+	}
+	opts = append([]token.PayloadOption{
+		token.WithRoles(user.Roles...),
+		token.WithClaims(claims),
+		token.WithPrincipalType(PrincipalType(user)),
+		token.WithAuthTime(time.Now()),
+	}, opts...)
+	accessToken, payload, err := a.CreateToken(user.ID, user.Username, user.Role, a.config.AccessTokenDuration, opts...)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	// If enforcing single device login, store the new access token as the active one
+	if a.config.EnforceSingleDeviceLogin {
+		_, err := a.store.UpdateUser(ctx, user.ID, core.UpdateUserParams{ActiveToken: &accessToken})
+		if err != nil {
+			// h.logger.Error("Failed to update active token for user", "error", err, "user_id", user.ID)
+			// Proceed with login, as token creation was successful.
+			fmt.Printf("Warning: Failed to update active token for %s: %v\n", user.Email, err)
+		}
+	}
+	return accessToken, payload, nil
+}
+
+// CreateToken creates an access token carrying the configured issuer and audience.
+func (a *Auth) CreateToken(userID uuid.UUID, username, role string, duration time.Duration, opts ...token.PayloadOption) (string, *token.Payload, error) {
+	claims := []token.PayloadOption{token.WithIssuer(a.config.TokenIssuer), token.WithAudience(a.config.TokenAudience...)}
+	return a.tokenMaker.CreateToken(userID, username, role, duration, append(claims, opts...)...)
+}
+
+// EnrichClaims returns the application-specific claims for user's tokens.
+func (a *Auth) EnrichClaims(ctx context.Context, user core.User) (map[string]interface{}, error) {
+	if a.claimsEnricher == nil {
+		return nil, nil
+	}
+	claims, err := a.claimsEnricher(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token claims: %w", err)
+	}
+	return claims, nil
+}
+
+// PrincipalType returns the token principal type for user.
+func PrincipalType(user core.User) string {
+	if user.IsServiceAccount() {
+		return token.PrincipalService
+	}
+	return token.PrincipalUser
+}
+
+// generateSecureToken creates a random hex token, e.g. for email verification.
+func generateSecureToken(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
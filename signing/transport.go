@@ -0,0 +1,56 @@
+package signing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Transport is an http.RoundTripper that signs every request with one key, for
+// clients of endpoints protected by Verifier:
+//
+//	client := &http.Client{Transport: &signing.Transport{KeyID: "billing", Secret: secret}}
+type Transport struct {
+	KeyID  string
+	Secret []byte
+	Base   http.RoundTripper // Defaults to http.DefaultTransport
+}
+
+// RoundTrip signs a copy of req and sends it through the base transport.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("signing: read request body: %w", err)
+		}
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, fmt.Errorf("signing: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// A RoundTripper must not modify the caller's request
+	signed := req.Clone(req.Context())
+	if body != nil {
+		signed.Body = io.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		signed.ContentLength = int64(len(body))
+	}
+	signed.Header.Set(HeaderKeyID, t.KeyID)
+	signed.Header.Set(HeaderTimestamp, timestamp)
+	signed.Header.Set(HeaderNonce, nonce)
+	signed.Header.Set(HeaderSignature, Sign(t.Secret, StringToSign(req.Method, req.URL.RequestURI(), timestamp, nonce, body)))
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
@@ -0,0 +1,108 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+// DefaultMaxBodyBytes limits the body Verifier reads to compute the body hash.
+const DefaultMaxBodyBytes = 10 << 20
+
+// Verifier checks signed requests against the keys in a core.SigningKeyStorer.
+type Verifier struct {
+	keys   core.SigningKeyStorer
+	nonces core.NonceStorer
+
+	MaxSkew      time.Duration // Allowed difference between the request timestamp and the server clock
+	MaxBodyBytes int64         // Larger bodies are rejected; defaults to DefaultMaxBodyBytes
+}
+
+// NewVerifier creates a verifier. Nonces are remembered in nonces until the request
+// timestamp falls out of the maxSkew window, after which the timestamp check rejects
+// a replay on its own.
+func NewVerifier(keys core.SigningKeyStorer, nonces core.NonceStorer, maxSkew time.Duration) *Verifier {
+	return &Verifier{keys: keys, nonces: nonces, MaxSkew: maxSkew, MaxBodyBytes: DefaultMaxBodyBytes}
+}
+
+// Verify checks the signature of r and returns the key it was signed with. The body is
+// read and replaced, so handlers can still read it. It returns core.ErrSignatureInvalid,
+// core.ErrSignatureExpired or core.ErrRequestReplayed if the request is not acceptable.
+func (v *Verifier) Verify(r *http.Request) (core.SigningKey, error) {
+	keyID := r.Header.Get(HeaderKeyID)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	signature := r.Header.Get(HeaderSignature)
+	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return core.SigningKey{}, core.ErrSignatureInvalid
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return core.SigningKey{}, core.ErrSignatureInvalid
+	}
+	now := time.Now()
+	signedAt := time.Unix(unix, 0)
+	if skew := now.Sub(signedAt); skew > v.MaxSkew || skew < -v.MaxSkew {
+		return core.SigningKey{}, core.ErrSignatureExpired
+	}
+
+	ctx := r.Context()
+	key, err := v.keys.GetSigningKey(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return core.SigningKey{}, core.ErrSignatureInvalid
+		}
+		return core.SigningKey{}, err
+	}
+	if key.IsExpired(now) {
+		return core.SigningKey{}, core.ErrSignatureInvalid
+	}
+
+	body, err := v.readBody(r)
+	if err != nil {
+		return core.SigningKey{}, err
+	}
+	expected := Sign(key.Secret, StringToSign(r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return core.SigningKey{}, core.ErrSignatureInvalid
+	}
+
+	// Only a correctly signed request may use up its nonce
+	fresh, err := v.nonces.UseNonce(ctx, keyID+":"+nonce, signedAt.Add(v.MaxSkew))
+	if err != nil {
+		return core.SigningKey{}, fmt.Errorf("failed to record nonce: %w", err)
+	}
+	if !fresh {
+		return core.SigningKey{}, core.ErrRequestReplayed
+	}
+	return key, nil
+}
+
+// readBody reads the request body and puts it back for the handler.
+func (v *Verifier) readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	limit := v.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w: body is too large to verify", core.ErrSignatureInvalid)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,159 @@
+package signing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shawgichan/go-authkit/core"
+)
+
+type keyStore map[string]core.SigningKey
+
+func (s keyStore) GetSigningKey(ctx context.Context, keyID string) (core.SigningKey, error) {
+	key, ok := s[keyID]
+	if !ok {
+		return core.SigningKey{}, core.ErrNotFound
+	}
+	return key, nil
+}
+
+type nonceStore map[string]bool
+
+func (s nonceStore) UseNonce(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	if s[nonce] {
+		return false, nil
+	}
+	s[nonce] = true
+	return true, nil
+}
+
+// signRequest signs r as Transport does, with the given timestamp and nonce.
+func signRequest(r *http.Request, keyID string, secret []byte, signedAt time.Time, nonce, body string) {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	r.Header.Set(HeaderKeyID, keyID)
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, Sign(secret, StringToSign(r.Method, r.URL.RequestURI(), timestamp, nonce, []byte(body))))
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("webhook-secret")
+	keys := keyStore{
+		"billing": {ID: "billing", Secret: secret},
+		"expired": {ID: "expired", Secret: secret, ExpiresAt: time.Now().Add(-time.Minute)},
+	}
+	const body = `{"event":"invoice.paid"}`
+
+	tests := []struct {
+		name    string
+		keyID   string
+		skew    time.Duration // Added to the signing time
+		tamper  func(r *http.Request)
+		maxBody int64
+		wantErr error
+	}{
+		{name: "valid"},
+		{name: "tampered body", tamper: func(r *http.Request) {
+			r.Body = io.NopCloser(strings.NewReader(`{"event":"invoice.refunded"}`))
+		}, wantErr: core.ErrSignatureInvalid},
+		{name: "tampered path", tamper: func(r *http.Request) { r.URL.Path = "/webhooks/payroll" }, wantErr: core.ErrSignatureInvalid},
+		{name: "tampered query", tamper: func(r *http.Request) { r.URL.RawQuery = "account=2" }, wantErr: core.ErrSignatureInvalid},
+		{name: "tampered method", tamper: func(r *http.Request) { r.Method = http.MethodPut }, wantErr: core.ErrSignatureInvalid},
+		{name: "missing signature", tamper: func(r *http.Request) { r.Header.Del(HeaderSignature) }, wantErr: core.ErrSignatureInvalid},
+		{name: "unknown key", keyID: "payroll", wantErr: core.ErrSignatureInvalid},
+		{name: "expired key", keyID: "expired", wantErr: core.ErrSignatureInvalid},
+		{name: "signed too long ago", skew: -6 * time.Minute, wantErr: core.ErrSignatureExpired},
+		{name: "signed in the future", skew: 6 * time.Minute, wantErr: core.ErrSignatureExpired},
+		{name: "skew within the window", skew: -4 * time.Minute},
+		{name: "body too large", maxBody: int64(len(body)) - 1, wantErr: core.ErrSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(keys, nonceStore{}, 5*time.Minute)
+			if tt.maxBody > 0 {
+				v.MaxBodyBytes = tt.maxBody
+			}
+			keyID := tt.keyID
+			if keyID == "" {
+				keyID = "billing"
+			}
+			r := httptest.NewRequest(http.MethodPost, "/webhooks/billing?account=1", strings.NewReader(body))
+			signRequest(r, keyID, secret, time.Now().Add(tt.skew), "nonce-1", body)
+			if tt.tamper != nil {
+				tt.tamper(r)
+			}
+
+			key, err := v.Verify(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if key.ID != "billing" {
+				t.Errorf("key = %q, want billing", key.ID)
+			}
+			// The handler can still read the body
+			if got, _ := io.ReadAll(r.Body); string(got) != body {
+				t.Errorf("body after Verify = %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	secret := []byte("webhook-secret")
+	v := NewVerifier(keyStore{"billing": {ID: "billing", Secret: secret}}, nonceStore{}, 5*time.Minute)
+	signedAt := time.Now()
+	request := func(nonce, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/billing", strings.NewReader(body))
+		signRequest(r, "billing", secret, signedAt, nonce, body)
+		return r
+	}
+
+	// A forged request must not use up the nonce of the genuine one
+	forged := request("nonce-1", "{}")
+	forged.Header.Set(HeaderSignature, strings.Repeat("0", 64))
+	if _, err := v.Verify(forged); !errors.Is(err, core.ErrSignatureInvalid) {
+		t.Fatalf("Verify(forged) = %v, want ErrSignatureInvalid", err)
+	}
+	if _, err := v.Verify(request("nonce-1", "{}")); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if _, err := v.Verify(request("nonce-1", "{}")); !errors.Is(err, core.ErrRequestReplayed) {
+		t.Errorf("Verify(replay) = %v, want ErrRequestReplayed", err)
+	}
+	if _, err := v.Verify(request("nonce-2", "{}")); err != nil {
+		t.Errorf("Verify() with a new nonce = %v, want nil", err)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestTransport(t *testing.T) {
+	secret := []byte("webhook-secret")
+	v := NewVerifier(keyStore{"billing": {ID: "billing", Secret: secret}}, nonceStore{}, 5*time.Minute)
+	var verifyErr error
+	client := &http.Client{Transport: &Transport{KeyID: "billing", Secret: secret, Base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		_, verifyErr = v.Verify(r)
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: r}, nil
+	})}}
+
+	resp, err := client.Post("https://api.example.com/webhooks/billing?account=1", "application/json", strings.NewReader(`{"event":"invoice.paid"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if verifyErr != nil {
+		t.Errorf("Verify() of a request signed by Transport = %v, want nil", verifyErr)
+	}
+}
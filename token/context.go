@@ -0,0 +1,16 @@
+package token
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the verified payload of the request.
+func NewContext(ctx context.Context, payload *Payload) context.Context {
+	return context.WithValue(ctx, contextKey{}, payload)
+}
+
+// FromContext returns the payload stored in ctx by the authentication middleware.
+func FromContext(ctx context.Context) (*Payload, bool) {
+	payload, ok := ctx.Value(contextKey{}).(*Payload)
+	return payload, ok && payload != nil
+}
@@ -7,9 +7,59 @@ import (
 )
 
 type Maker interface {
-	// CreateToken creates a new token for a specific username and duration
-	CreateToken(userID uuid.UUID, username string, role string, duration time.Duration) (string, *Payload, error)
+	// CreateToken creates a new token for a specific username and duration.
+	// opts set optional claims such as the OAuth2 client and scope.
+	CreateToken(userID uuid.UUID, username string, role string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error)
 
-	// VerifyToken checks if the token is valid or not
-	VerifyToken(token string) (*Payload, error)
+	// VerifyToken checks if the token is valid or not.
+	// opts add issuer and audience checks and a leeway for clock skew.
+	VerifyToken(token string, opts ...VerifyOption) (*Payload, error)
+}
+
+// Clock returns the current time. Makers use it for issue times and expiry checks,
+// so tests can control time.
+type Clock func() time.Time
+
+// MakerOption configures a Maker.
+type MakerOption func(*makerOptions)
+
+type makerOptions struct {
+	clock Clock
+}
+
+// WithClock replaces time.Now as the maker's source of the current time.
+func WithClock(clock Clock) MakerOption {
+	return func(o *makerOptions) {
+		o.clock = clock
+	}
+}
+
+// VerifyOption adds checks to Maker.VerifyToken.
+type VerifyOption func(*verifyOptions)
+
+type verifyOptions struct {
+	issuer    string
+	audiences []string
+	leeway    time.Duration
+}
+
+// ExpectIssuer rejects tokens not issued by issuer with ErrInvalidIssuer.
+func ExpectIssuer(issuer string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.issuer = issuer
+	}
+}
+
+// ExpectAudience rejects tokens whose audience contains none of audiences with ErrInvalidAudience.
+func ExpectAudience(audiences ...string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.audiences = append(o.audiences, audiences...)
+	}
+}
+
+// WithLeeway tolerates clock skew between servers when checking expiry and not-before.
+func WithLeeway(leeway time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.leeway = leeway
+	}
 }
@@ -0,0 +1,85 @@
+package token
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestVerifyToken(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := issuedAt
+	maker, err := NewPasetoMaker("12345678901234567890123456789012", WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		at      time.Duration // Time of verification after issuance
+		opts    []PayloadOption
+		verify  []VerifyOption
+		noUser  bool
+		wantErr error
+	}{
+		{name: "valid", at: time.Minute},
+		{name: "expired", at: time.Hour + time.Second, wantErr: ErrExpiredToken},
+		{name: "expired within the leeway", at: time.Hour + time.Second, verify: []VerifyOption{WithLeeway(time.Minute)}},
+		{name: "expired beyond the leeway", at: time.Hour + 2*time.Minute, verify: []VerifyOption{WithLeeway(time.Minute)}, wantErr: ErrExpiredToken},
+		{name: "not yet valid", opts: []PayloadOption{WithNotBefore(issuedAt.Add(time.Minute))}, wantErr: ErrTokenNotYetValid},
+		{name: "not yet valid within the leeway", opts: []PayloadOption{WithNotBefore(issuedAt.Add(time.Minute))}, verify: []VerifyOption{WithLeeway(2 * time.Minute)}},
+		{name: "valid from not before", at: time.Minute, opts: []PayloadOption{WithNotBefore(issuedAt.Add(time.Minute))}},
+		{name: "issuer", opts: []PayloadOption{WithIssuer("https://auth.example.com")}, verify: []VerifyOption{ExpectIssuer("https://auth.example.com")}},
+		{name: "issuer mismatch", opts: []PayloadOption{WithIssuer("https://evil.example.com")}, verify: []VerifyOption{ExpectIssuer("https://auth.example.com")}, wantErr: ErrInvalidIssuer},
+		{name: "issuer missing", verify: []VerifyOption{ExpectIssuer("https://auth.example.com")}, wantErr: ErrInvalidIssuer},
+		{name: "one of the audiences", opts: []PayloadOption{WithAudience("billing", "reports")}, verify: []VerifyOption{ExpectAudience("reports")}},
+		{name: "audience mismatch", opts: []PayloadOption{WithAudience("billing")}, verify: []VerifyOption{ExpectAudience("reports", "payroll")}, wantErr: ErrInvalidAudience},
+		{name: "audience missing", verify: []VerifyOption{ExpectAudience("reports")}, wantErr: ErrInvalidAudience},
+		{name: "no user ID", noUser: true, wantErr: ErrMissingUserID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := userID
+			if tt.noUser {
+				id = uuid.Nil
+			}
+			now = issuedAt
+			raw, _, err := maker.CreateToken(id, "alice", "user", time.Hour, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			now = issuedAt.Add(tt.at)
+			payload, err := maker.VerifyToken(raw, tt.verify...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyToken() = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (payload.UserID != userID || !payload.IssuedAt.Equal(issuedAt)) {
+				t.Errorf("payload user/iat = %s/%v, want %s/%v", payload.UserID, payload.IssuedAt, userID, issuedAt)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenRejectsOtherKeys(t *testing.T) {
+	maker, err := NewPasetoMaker("12345678901234567890123456789012")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewPasetoMaker("abcdefghijklmnopqrstuvwxyz012345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _, err := other.CreateToken(uuid.New(), "alice", "user", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := maker.VerifyToken(raw); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken() of another key's token = %v, want ErrInvalidToken", err)
+	}
+	if _, err := maker.VerifyToken("v2.local.garbage"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken() of garbage = %v, want ErrInvalidToken", err)
+	}
+}
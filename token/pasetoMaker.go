@@ -13,25 +13,32 @@ import (
 type PasetoMaker struct {
 	paseto       *paseto.V2
 	symmetricKey []byte
+	clock        Clock
 }
 
 // NewPasetoMaker creates a new PasetoMaker
-func NewPasetoMaker(symmetricKey string) (Maker, error) {
+func NewPasetoMaker(symmetricKey string, opts ...MakerOption) (Maker, error) {
 	if len(symmetricKey) != chacha20poly1305.KeySize {
 		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
 	}
 
+	options := makerOptions{clock: time.Now}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	maker := &PasetoMaker{
 		paseto:       paseto.NewV2(),
 		symmetricKey: []byte(symmetricKey),
+		clock:        options.clock,
 	}
 
 	return maker, nil
 }
 
 // CreateToken creates a new token for a specific username and duration
-func (maker *PasetoMaker) CreateToken(userID uuid.UUID, username string, role string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(userID, username, role, duration) // Pass userID
+func (maker *PasetoMaker) CreateToken(userID uuid.UUID, username string, role string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	payload, err := newPayloadAt(maker.clock(), userID, username, role, duration, opts...) // Pass userID
 	if err != nil {
 		return "", payload, err
 	}
@@ -40,13 +47,13 @@ func (maker *PasetoMaker) CreateToken(userID uuid.UUID, username string, role st
 }
 
 // VerifyToken checks if the token is valid or not
-func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+func (maker *PasetoMaker) VerifyToken(token string, opts ...VerifyOption) (*Payload, error) {
 	payload := &Payload{}
 	err := maker.paseto.Decrypt(token, maker.symmetricKey, payload, nil)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
-	err = payload.Valid() // Valid() method checks for UserID presence
+	err = payload.Verify(maker.clock(), opts...) // Also checks for UserID presence
 	if err != nil {
 		return nil, err
 	}
@@ -1,15 +1,21 @@
 package token
 
 import (
+	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrInvalidToken = errors.New("token is invalid")
-	ErrExpiredToken = errors.New("token has expired")
+	ErrInvalidToken     = errors.New("token is invalid")
+	ErrExpiredToken     = errors.New("token has expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("token issuer is not accepted")
+	ErrInvalidAudience  = errors.New("token audience is not accepted")
+	ErrMissingUserID    = errors.New("user ID missing in token payload")
 )
 
 // Payload contains the payload data of the token
@@ -18,13 +24,206 @@ type Payload struct {
 	UserID    uuid.UUID `json:"uid"`
 	Username  string    `json:"username"`
 	Role      string    `json:"role"`
+	Roles     []string  `json:"roles,omitempty"` // Additional roles besides Role
 	IssuedAt  time.Time `json:"iat"`
 	ExpiredAt time.Time `json:"exp"`
+	NotBefore time.Time `json:"nbf"`
+
+	Issuer   string   `json:"iss,omitempty"`
+	Audience []string `json:"aud,omitempty"`
+
+	// Set on tokens issued by the OAuth2 authorization server
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"` // Space-delimited
+	GrantType string `json:"gty,omitempty"`
+
+	// Kind of principal the subject is: PrincipalUser, PrincipalService or PrincipalClient
+	PrincipalType string `json:"pty,omitempty"`
+
+	// Set when the token is scoped to an organization (tenant). TenantRoles are the
+	// roles the user holds there and replace the global roles in role checks.
+	TenantID    string   `json:"tid,omitempty"`
+	TenantRoles []string `json:"tenant_roles,omitempty"`
+
+	// Set when someone other than the subject is acting with this token
+	Actor *Actor `json:"act,omitempty"`
+
+	// When the user last authenticated, if earlier than IssuedAt (e.g. for refreshed login tokens)
+	AuthTime *time.Time `json:"auth_time,omitempty"`
+
+	// Set on tokens of cookie sessions, whose CSRF tokens are bound to it
+	SessionID string `json:"sid,omitempty"`
+
+	// Set when the token may only be used together with a proof-of-possession key
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+
+	// Application-specific claims such as a session ID or feature flags.
+	// Values must be JSON encodable; read them back with Claim or ClaimAs.
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+// Actor identifies the party acting on behalf of the token's subject (RFC 8693 section 4.1):
+// an administrator impersonating the user or a service the token was exchanged by.
+type Actor struct {
+	Subject  uuid.UUID `json:"sub"`
+	Username string    `json:"username,omitempty"`
+	ClientID string    `json:"client_id,omitempty"`
+	Actor    *Actor    `json:"act,omitempty"` // Previous actor in a delegation chain
+}
+
+// Confirmation binds a token to a key the presenter must prove possession of (RFC 7800).
+type Confirmation struct {
+	X5tS256 string `json:"x5t#S256,omitempty"` // SHA-256 thumbprint of a client certificate (RFC 8705)
+	JKT     string `json:"jkt,omitempty"`      // JWK thumbprint of a DPoP key (RFC 9449)
+}
+
+const (
+	// GrantTypeClientCredentials marks tokens issued to a client acting on its own behalf.
+	// Their UserID is the client's ID, not a user's.
+	GrantTypeClientCredentials = "client_credentials"
+	// GrantTypeTokenExchange marks tokens issued by RFC 8693 token exchange.
+	GrantTypeTokenExchange = "token_exchange"
+	// GrantTypeImpersonation marks tokens an administrator minted to act as a user.
+	GrantTypeImpersonation = "impersonation"
+	// GrantTypeAPIKey marks payloads built from an API key. Their TokenID is the key's ID.
+	GrantTypeAPIKey = "api_key"
+	// GrantTypeClientCertificate marks payloads built from a verified client certificate.
+	// Their TokenID is random and their expiry is the certificate's.
+	GrantTypeClientCertificate = "client_certificate"
+)
+
+const (
+	// PrincipalUser marks a person.
+	PrincipalUser = "user"
+	// PrincipalService marks a service account, a machine identity with its own roles.
+	PrincipalService = "service"
+	// PrincipalClient marks an OAuth2 client acting on its own behalf.
+	PrincipalClient = "client"
+)
+
+// PayloadOption sets optional claims on a new payload.
+type PayloadOption func(*Payload)
+
+// WithClientID sets the OAuth2 client the token was issued to.
+func WithClientID(clientID string) PayloadOption {
+	return func(p *Payload) {
+		p.ClientID = clientID
+	}
+}
+
+// WithScope sets the granted scopes.
+func WithScope(scopes ...string) PayloadOption {
+	return func(p *Payload) {
+		p.Scope = strings.Join(scopes, " ")
+	}
+}
+
+// WithGrantType records the OAuth2 grant the token was issued through.
+func WithGrantType(grantType string) PayloadOption {
+	return func(p *Payload) {
+		p.GrantType = grantType
+	}
+}
+
+// WithActor records who acts on behalf of the subject.
+func WithActor(actor Actor) PayloadOption {
+	return func(p *Payload) {
+		p.Actor = &actor
+	}
+}
+
+// WithRoles sets the additional roles the user holds besides the primary role.
+func WithRoles(roles ...string) PayloadOption {
+	return func(p *Payload) {
+		p.Roles = append([]string(nil), roles...)
+	}
+}
+
+// WithPrincipalType records what kind of principal the subject is.
+func WithPrincipalType(principalType string) PayloadOption {
+	return func(p *Payload) {
+		p.PrincipalType = principalType
+	}
+}
+
+// WithConfirmation binds the token to a proof-of-possession key.
+func WithConfirmation(cnf Confirmation) PayloadOption {
+	return func(p *Payload) {
+		p.Confirmation = &cnf
+	}
+}
+
+// WithAuthTime records when the user authenticated, for tokens issued later in the same session.
+func WithAuthTime(authTime time.Time) PayloadOption {
+	return func(p *Payload) {
+		p.AuthTime = &authTime
+	}
+}
+
+// WithSessionID ties the token to a cookie session.
+func WithSessionID(sessionID string) PayloadOption {
+	return func(p *Payload) {
+		p.SessionID = sessionID
+	}
+}
+
+// WithTenant scopes the token to an organization in which the user holds roles.
+func WithTenant(tenantID string, roles ...string) PayloadOption {
+	return func(p *Payload) {
+		p.TenantID = tenantID
+		p.TenantRoles = append([]string(nil), roles...)
+	}
+}
+
+// WithIssuer sets the issuer, checked by ExpectIssuer.
+func WithIssuer(issuer string) PayloadOption {
+	return func(p *Payload) {
+		p.Issuer = issuer
+	}
+}
+
+// WithAudience sets the services the token is intended for, checked by ExpectAudience.
+func WithAudience(audience ...string) PayloadOption {
+	return func(p *Payload) {
+		p.Audience = append([]string(nil), audience...)
+	}
+}
+
+// WithNotBefore sets the time before which the token must not be accepted.
+// It defaults to the issue time.
+func WithNotBefore(notBefore time.Time) PayloadOption {
+	return func(p *Payload) {
+		p.NotBefore = notBefore
+	}
+}
+
+// WithClaims adds application-specific claims. Later options overwrite earlier keys.
+func WithClaims(claims map[string]interface{}) PayloadOption {
+	return func(p *Payload) {
+		if len(claims) == 0 {
+			return
+		}
+		if p.Claims == nil {
+			p.Claims = make(map[string]interface{}, len(claims))
+		}
+		for key, value := range claims {
+			p.Claims[key] = value
+		}
+	}
+}
+
+// WithClaim adds a single application-specific claim.
+func WithClaim(key string, value interface{}) PayloadOption {
+	return WithClaims(map[string]interface{}{key: value})
 }
 
 // NewPayload creates a new token payload.
 // requires userID.
-func NewPayload(userID uuid.UUID, username string, role string, duration time.Duration) (*Payload, error) {
+func NewPayload(userID uuid.UUID, username string, role string, duration time.Duration, opts ...PayloadOption) (*Payload, error) {
+	return newPayloadAt(time.Now(), userID, username, role, duration, opts...)
+}
+
+func newPayloadAt(now time.Time, userID uuid.UUID, username string, role string, duration time.Duration, opts ...PayloadOption) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -35,19 +234,207 @@ func NewPayload(userID uuid.UUID, username string, role string, duration time.Du
 		UserID:    userID,
 		Username:  username,
 		Role:      role,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		IssuedAt:  now,
+		ExpiredAt: now.Add(duration),
+		NotBefore: now,
+	}
+	for _, opt := range opts {
+		opt(payload)
 	}
 	return payload, nil
 }
 
-// Valid checks if the token payload is valid.
+// Scopes returns the granted scopes.
+func (payload *Payload) Scopes() []string {
+	return strings.Fields(payload.Scope)
+}
+
+// HasScope reports whether the token was granted scope.
+func (payload *Payload) HasScope(scope string) bool {
+	for _, s := range payload.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleScope returns the scope that lets an API key act with role, e.g. "role:admin".
+// API keys have only the roles of their owner that such scopes grant.
+func RoleScope(role string) string {
+	return "role:" + role
+}
+
+// HasTenant reports whether the token is scoped to an organization.
+func (payload *Payload) HasTenant() bool {
+	return payload.TenantID != ""
+}
+
+// EffectiveRoles returns the roles that apply to the current request: the tenant
+// roles when the token is scoped to an organization, the global roles otherwise.
+// Delegated tokens have no roles (see IsDelegated).
+func (payload *Payload) EffectiveRoles() []string {
+	if payload.IsDelegated() {
+		return nil
+	}
+	if payload.HasTenant() {
+		return append([]string(nil), payload.TenantRoles...)
+	}
+	return payload.AllRoles()
+}
+
+// AllRoles returns Role followed by the additional Roles, without duplicates.
+// Delegated tokens have no roles (see IsDelegated).
+func (payload *Payload) AllRoles() []string {
+	if payload.IsDelegated() {
+		return nil
+	}
+	roles := make([]string, 0, 1+len(payload.Roles))
+	seen := make(map[string]bool, 1+len(payload.Roles))
+	for _, role := range append([]string{payload.Role}, payload.Roles...) {
+		if role == "" || seen[role] {
+			continue
+		}
+		seen[role] = true
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+// IsClientCredentials reports whether the token represents an OAuth2 client rather than a user.
+// Client credentials tokens issued for a service account represent that account instead.
+func (payload *Payload) IsClientCredentials() bool {
+	return payload.GrantType == GrantTypeClientCredentials && payload.PrincipalType != PrincipalService
+}
+
+// IsDelegated reports whether the token was issued to an OAuth2 client acting on a user's
+// behalf, e.g. through the authorization code grant or token exchange. The client is
+// limited to the granted scopes; the user's roles do not apply, whatever the token carries.
+func (payload *Payload) IsDelegated() bool {
+	return payload.ClientID != "" && payload.GrantType != GrantTypeClientCredentials
+}
+
+// IsMachine reports whether the subject is a service account or an OAuth2 client rather than a person.
+func (payload *Payload) IsMachine() bool {
+	return payload.PrincipalType == PrincipalService || payload.PrincipalType == PrincipalClient
+}
+
+// IsAPIKey reports whether the payload was built from an API key rather than a token.
+func (payload *Payload) IsAPIKey() bool {
+	return payload.GrantType == GrantTypeAPIKey
+}
+
+// IsClientCertificate reports whether the payload was built from a client certificate rather than a token.
+func (payload *Payload) IsClientCertificate() bool {
+	return payload.GrantType == GrantTypeClientCertificate
+}
+
+// IsLoginToken reports whether the token is the user's own first-party login session,
+// as opposed to a token issued to an OAuth2 client, an API key or a client certificate.
+// Login tokens carry the user's full authority and are not scope restricted.
+func (payload *Payload) IsLoginToken() bool {
+	return payload.ClientID == "" && !payload.IsAPIKey() && !payload.IsClientCertificate()
+}
+
+// AuthenticatedAt returns when the user authenticated: AuthTime if set, otherwise IssuedAt.
+// Use it for re-authentication windows, since refreshed tokens are issued later.
+func (payload *Payload) AuthenticatedAt() time.Time {
+	if payload.AuthTime != nil {
+		return *payload.AuthTime
+	}
+	return payload.IssuedAt
+}
+
+// CertificateThumbprint returns the client certificate thumbprint the token is bound to, if any.
+func (payload *Payload) CertificateThumbprint() string {
+	if payload.Confirmation == nil {
+		return ""
+	}
+	return payload.Confirmation.X5tS256
+}
+
+// DPoPThumbprint returns the DPoP key thumbprint the token is bound to, if any.
+func (payload *Payload) DPoPThumbprint() string {
+	if payload.Confirmation == nil {
+		return ""
+	}
+	return payload.Confirmation.JKT
+}
+
+// IsImpersonated reports whether someone other than the subject is acting with the token,
+// either an impersonating administrator or a service holding an exchanged token.
+// Handlers for dangerous actions (changing credentials, granting access) should reject such tokens.
+func (payload *Payload) IsImpersonated() bool {
+	return payload.Actor != nil
+}
+
+// Claim returns an application-specific claim. After a round trip through a token,
+// numbers are float64, arrays []interface{} and objects map[string]interface{};
+// use ClaimAs to decode into a specific type.
+func (payload *Payload) Claim(key string) (interface{}, bool) {
+	value, ok := payload.Claims[key]
+	return value, ok
+}
+
+// ClaimAs decodes an application-specific claim into T. It returns false if the claim
+// is missing or does not decode into T.
+func ClaimAs[T any](payload *Payload, key string) (T, bool) {
+	var out T
+	value, ok := payload.Claim(key)
+	if !ok {
+		return out, false
+	}
+	if typed, ok := value.(T); ok { // Claims set on this payload before encoding
+		return typed, true
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return out, false
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return out, false
+	}
+	return out, true
+}
+
+// Valid checks if the token payload is valid now, without issuer or audience checks.
 func (payload *Payload) Valid() error {
-	if time.Now().After(payload.ExpiredAt) {
+	return payload.Verify(time.Now())
+}
+
+// Verify checks the payload at the given time: expiry and not-before (within the
+// leeway), the issuer and the audience as configured by opts.
+func (payload *Payload) Verify(now time.Time, opts ...VerifyOption) error {
+	options := verifyOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if now.After(payload.ExpiredAt.Add(options.leeway)) {
 		return ErrExpiredToken
 	}
+	if now.Add(options.leeway).Before(payload.NotBefore) {
+		return ErrTokenNotYetValid
+	}
 	if payload.UserID == uuid.Nil { // Ensure UserID is present
-		return errors.New("user ID missing in token payload")
+		return ErrMissingUserID
+	}
+	if options.issuer != "" && payload.Issuer != options.issuer {
+		return ErrInvalidIssuer
+	}
+	if len(options.audiences) > 0 && !payload.hasAnyAudience(options.audiences) {
+		return ErrInvalidAudience
 	}
 	return nil
 }
+
+func (payload *Payload) hasAnyAudience(audiences []string) bool {
+	for _, aud := range payload.Audience {
+		for _, accepted := range audiences {
+			if aud == accepted {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDelegatedTokensHaveNoRoles(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []PayloadOption
+		delegated bool
+	}{
+		{"login token", nil, false},
+		{"authorization code", []PayloadOption{WithClientID("app")}, true},
+		{"token exchange", []PayloadOption{WithClientID("app"), WithGrantType(GrantTypeTokenExchange)}, true},
+		{"client credentials", []PayloadOption{WithClientID("app"), WithGrantType(GrantTypeClientCredentials)}, false},
+		{"api key", []PayloadOption{WithGrantType(GrantTypeAPIKey)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]PayloadOption{WithRoles("auditor")}, tt.opts...)
+			payload, err := NewPayload(uuid.New(), "ada", "admin", time.Minute, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := payload.IsDelegated(); got != tt.delegated {
+				t.Fatalf("IsDelegated() = %v, want %v", got, tt.delegated)
+			}
+			wantRoles := 2
+			if tt.delegated {
+				wantRoles = 0
+			}
+			if got := payload.AllRoles(); len(got) != wantRoles {
+				t.Errorf("AllRoles() = %v, want %d roles", got, wantRoles)
+			}
+			if got := payload.EffectiveRoles(); len(got) != wantRoles {
+				t.Errorf("EffectiveRoles() = %v, want %d roles", got, wantRoles)
+			}
+		})
+	}
+}